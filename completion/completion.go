@@ -0,0 +1,292 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+// Package completion parses the code completions printed by the builder.
+package completion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+)
+
+// Completions are read from the output of arduino-preprocessor's
+// -output-code-completions, a JSON array with an object for each result:
+//
+//	[{"type":"Declaration","completion":{"chunks":[{"res":"void"},{"typedtext":"digitalWrite"},
+//	  {"t":"("},{"placeholder":"uint8_t pin"},{"t":", "},{"placeholder":"uint8_t val"},{"t":")"}],
+//	  "brief":"...","availability":"available"}}]
+//
+// The chunks follow the ones of clang's CodeCompletionString: "typedtext" is
+// the text being completed, "res" the result type, "info" informative text,
+// "placeholder" and "current" the parameters to fill in, "optional" an
+// optional part made of chunks in turn, "text" and "t" any other text.
+//
+// The raw output of clang's -code-completion-at is understood as well, e.g.
+//
+//	COMPLETION: digitalWrite : [#void#]digitalWrite(<#uint8_t pin#>, <#uint8_t val#>)
+//	COMPLETION: Pattern : for(<#init-statement#>; <#condition#>; <#inc-expression#>){<#statements#>}
+//	OVERLOAD: [#void#]begin(<#unsigned long baud#>)
+//
+// In the patterns, [#text#] is the result type or other informative text,
+// <#text#> a placeholder and {#text#} an optional part. The JSON chunks are
+// converted to such a pattern, so that both are handled the same way.
+var completionLine = regexp.MustCompile(`^(COMPLETION|OVERLOAD): (.*)$`)
+
+var macroName = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+const (
+	completionPriority        = 1
+	patternCompletionPriority = 2
+	hiddenCompletionPriority  = 3
+)
+
+// Parse parses the code completions printed by arduino-preprocessor, or
+// by clang
+func Parse(output string) []*pb.Completion {
+	if trimmed := strings.TrimSpace(output); strings.HasPrefix(trimmed, "[") {
+		return parseJSONCompletions(trimmed)
+	}
+	var res []*pb.Completion
+	for _, line := range strings.Split(output, "\n") {
+		match := completionLine.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		if match[1] == "OVERLOAD" {
+			res = append(res, parseOverload(match[2]))
+		} else if completion := parseCompletion(match[2]); completion != nil {
+			res = append(res, completion)
+		}
+	}
+	return res
+}
+
+type jsonCompletion struct {
+	Type       string          `json:"type"`
+	Completion json.RawMessage `json:"completion"`
+}
+
+type jsonCompletionString struct {
+	Chunks       []map[string]json.RawMessage `json:"chunks"`
+	Brief        string                       `json:"brief"`
+	Availability string                       `json:"availability"`
+}
+
+func parseJSONCompletions(output string) []*pb.Completion {
+	var results []*jsonCompletion
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		return nil
+	}
+	var res []*pb.Completion
+	for _, result := range results {
+		str, err := decodeCompletionString(result.Completion)
+		if err != nil || str.Availability == "notavailable" || str.Availability == "notaccessible" {
+			continue
+		}
+		label, pattern, overload := completionPattern(str)
+		if result.Type == "Overload" || overload {
+			res = append(res, parseOverload(pattern))
+			continue
+		}
+		if result.Type == "Pattern" {
+			label = "Pattern"
+		}
+		if label == "" {
+			continue
+		}
+		res = append(res, newCompletion(label, pattern, str.Brief, str.Availability == "deprecated"))
+	}
+	return res
+}
+
+// decodeCompletionString decodes a completion string, given either as an
+// object or as its bare list of chunks
+func decodeCompletionString(data json.RawMessage) (*jsonCompletionString, error) {
+	str := &jsonCompletionString{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return str, json.Unmarshal(trimmed, &str.Chunks)
+	}
+	return str, json.Unmarshal(data, str)
+}
+
+// completionPattern converts the chunks of str into a clang pattern,
+// returning the typed text too and whether it has the parameter being
+// filled in, as overloads do
+func completionPattern(str *jsonCompletionString) (typedText, pattern string, overload bool) {
+	var res strings.Builder
+	for _, chunk := range str.Chunks {
+		for kind, value := range chunk {
+			if kind == "optional" {
+				if optional, err := decodeCompletionString(value); err == nil {
+					_, optionalPattern, _ := completionPattern(optional)
+					res.WriteString("{#" + optionalPattern + "#}")
+				}
+				continue
+			}
+			var text string
+			if err := json.Unmarshal(value, &text); err != nil {
+				continue
+			}
+			switch kind {
+			case "typedtext":
+				typedText += text
+				res.WriteString(text)
+			case "res", "info":
+				res.WriteString("[#" + text + "#]")
+			case "current":
+				overload = true
+				res.WriteString("<#" + text + "#>")
+			case "placeholder":
+				res.WriteString("<#" + text + "#>")
+			default:
+				res.WriteString(text)
+			}
+		}
+	}
+	return typedText, res.String(), overload
+}
+
+func parseCompletion(line string) *pb.Completion {
+	parts := strings.SplitN(line, " : ", 3)
+	if len(parts) < 2 {
+		return nil
+	}
+	documentation := ""
+	if len(parts) == 3 {
+		documentation = parts[2]
+	}
+	pattern := parts[1]
+	hidden := strings.HasSuffix(pattern, " (Hidden)")
+	return newCompletion(parts[0], strings.TrimSuffix(pattern, " (Hidden)"), documentation, hidden)
+}
+
+// newCompletion returns the completion of label with the given pattern,
+// guessing its kind out of them
+func newCompletion(label, pattern, documentation string, hidden bool) *pb.Completion {
+	completion := &pb.Completion{Label: label, Documentation: documentation, Priority: completionPriority}
+	if hidden {
+		completion.Priority = hiddenCompletionPriority
+	}
+	completion.ReturnType, completion.Signature, completion.InsertText = parseCompletionPattern(pattern)
+
+	switch {
+	case completion.Label == "Pattern":
+		completion.Kind = pb.Completion_PATTERN
+		completion.Label = completion.Signature
+		if completion.Priority < patternCompletionPriority {
+			completion.Priority = patternCompletionPriority
+		}
+	case strings.HasPrefix(completion.Signature, completion.Label+"("):
+		completion.Kind = pb.Completion_FUNCTION
+	case completion.ReturnType != "":
+		completion.Kind = pb.Completion_VARIABLE
+	case macroName.MatchString(completion.Label):
+		completion.Kind = pb.Completion_MACRO
+	default:
+		completion.Kind = pb.Completion_TEXT
+	}
+	return completion
+}
+
+func parseOverload(pattern string) *pb.Completion {
+	completion := &pb.Completion{Kind: pb.Completion_OVERLOAD, Priority: completionPriority}
+	completion.ReturnType, completion.Signature, completion.InsertText = parseCompletionPattern(pattern)
+	completion.Label = completion.Signature
+	if i := strings.Index(completion.Signature, "("); i != -1 {
+		completion.Label = completion.Signature[:i]
+	}
+	return completion
+}
+
+// parseCompletionPattern splits a completion pattern into the result type,
+// the signature as it should be shown to the user and the text to insert
+func parseCompletionPattern(pattern string) (returnType, signature, insertText string) {
+	placeholders := 0
+	var parse func(pattern string, optional bool) (string, string)
+	parse = func(pattern string, optional bool) (string, string) {
+		var signature, insertText strings.Builder
+		for len(pattern) > 0 {
+			switch {
+			case strings.HasPrefix(pattern, "[#"):
+				var text string
+				text, pattern = takeChunk(pattern, "[#", "#]")
+				if signature.Len() == 0 && returnType == "" && !optional {
+					returnType = text
+				} else {
+					signature.WriteString(text)
+				}
+			case strings.HasPrefix(pattern, "<#"):
+				var text string
+				text, pattern = takeChunk(pattern, "<#", "#>")
+				signature.WriteString(text)
+				placeholders++
+				insertText.WriteString(fmt.Sprintf("${%d:%s}", placeholders, text))
+			case strings.HasPrefix(pattern, "{#"):
+				var text string
+				text, pattern = takeChunk(pattern, "{#", "#}")
+				// optional parts are shown, but left out of the text to insert
+				optionalSignature, _ := parse(text, true)
+				signature.WriteString(optionalSignature)
+			default:
+				signature.WriteByte(pattern[0])
+				insertText.WriteByte(pattern[0])
+				pattern = pattern[1:]
+			}
+		}
+		return signature.String(), insertText.String()
+	}
+	signature, insertText = parse(pattern, false)
+	return returnType, signature, insertText
+}
+
+// takeChunk splits s, which begins with the open marker, into the text
+// between the markers and what follows the matching close marker. A
+// missing close marker is treated as if it were at the end of s.
+func takeChunk(s, open, close string) (text, rest string) {
+	depth := 0
+	for i := 0; i < len(s)-1; i++ {
+		switch s[i : i+2] {
+		case open:
+			depth++
+			i++
+		case close:
+			depth--
+			if depth == 0 {
+				return s[len(open):i], s[i+len(close):]
+			}
+			i++
+		}
+	}
+	return s[len(open):], ""
+}
@@ -0,0 +1,129 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package completion
+
+import (
+	"testing"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+)
+
+// preprocessorCompletions follows the output of arduino-preprocessor, with a
+// result of each kind it prints
+const preprocessorCompletions = `[
+{"type":"Declaration","completion":{"chunks":[{"res":"void"},{"typedtext":"digitalWrite"},{"t":"("},{"placeholder":"uint8_t pin"},{"t":", "},{"placeholder":"uint8_t val"},{"t":")"}],"brief":"Write a HIGH or a LOW value to a digital pin","availability":"available"}},
+{"type":"Declaration","completion":{"chunks":[{"res":"size_t"},{"typedtext":"print"},{"t":"("},{"placeholder":"int"},{"optional":{"chunks":[{"t":", "},{"placeholder":"int = DEC"}]}},{"t":")"}],"availability":"available"}},
+{"type":"Declaration","completion":{"chunks":[{"res":"int"},{"typedtext":"ledPin"}],"availability":"available"}},
+{"type":"Macro","completion":{"chunks":[{"typedtext":"HIGH"}],"availability":"available"}},
+{"type":"Pattern","completion":{"chunks":[{"typedtext":"for"},{"t":"("},{"placeholder":"init-statement"},{"t":"; "},{"placeholder":"condition"},{"t":")"}],"availability":"available"}},
+{"type":"Declaration","completion":[{"res":"void"},{"typedtext":"flush"},{"t":"("},{"t":")"}]},
+{"type":"Declaration","completion":{"chunks":[{"res":"int"},{"typedtext":"_rx_buffer_head"}],"availability":"notaccessible"}},
+{"type":"Overload","completion":{"chunks":[{"res":"void"},{"t":"begin"},{"t":"("},{"current":"unsigned long baud"},{"t":")"}],"availability":"available"}}
+]`
+
+// clangCompletions are the same completions as printed by clang
+// -code-completion-at
+const clangCompletions = `COMPLETION: digitalWrite : [#void#]digitalWrite(<#uint8_t pin#>, <#uint8_t val#>) : Write a HIGH or a LOW value to a digital pin
+COMPLETION: print : [#size_t#]print(<#int#>{#, <#int = DEC#>#})
+COMPLETION: ledPin : [#int#]ledPin
+COMPLETION: HIGH : HIGH
+COMPLETION: Pattern : for(<#init-statement#>; <#condition#>)
+COMPLETION: flush : [#void#]flush()
+COMPLETION: operator= : [#Print &#]operator=(<#const Print &#>) (Hidden)
+OVERLOAD: [#void#]begin(<#unsigned long baud#>)
+`
+
+func TestParse(t *testing.T) {
+	digitalWrite := &pb.Completion{
+		Label:         "digitalWrite",
+		Kind:          pb.Completion_FUNCTION,
+		Signature:     "digitalWrite(uint8_t pin, uint8_t val)",
+		ReturnType:    "void",
+		Documentation: "Write a HIGH or a LOW value to a digital pin",
+		InsertText:    "digitalWrite(${1:uint8_t pin}, ${2:uint8_t val})",
+		Priority:      completionPriority,
+	}
+	print := &pb.Completion{
+		Label:      "print",
+		Kind:       pb.Completion_FUNCTION,
+		Signature:  "print(int, int = DEC)",
+		ReturnType: "size_t",
+		InsertText: "print(${1:int})",
+		Priority:   completionPriority,
+	}
+	ledPin := &pb.Completion{Label: "ledPin", Kind: pb.Completion_VARIABLE, Signature: "ledPin", ReturnType: "int", InsertText: "ledPin", Priority: completionPriority}
+	high := &pb.Completion{Label: "HIGH", Kind: pb.Completion_MACRO, Signature: "HIGH", InsertText: "HIGH", Priority: completionPriority}
+	forPattern := &pb.Completion{
+		Label:      "for(init-statement; condition)",
+		Kind:       pb.Completion_PATTERN,
+		Signature:  "for(init-statement; condition)",
+		InsertText: "for(${1:init-statement}; ${2:condition})",
+		Priority:   patternCompletionPriority,
+	}
+	flush := &pb.Completion{Label: "flush", Kind: pb.Completion_FUNCTION, Signature: "flush()", ReturnType: "void", InsertText: "flush()", Priority: completionPriority}
+	begin := &pb.Completion{
+		Label:      "begin",
+		Kind:       pb.Completion_OVERLOAD,
+		Signature:  "begin(unsigned long baud)",
+		ReturnType: "void",
+		InsertText: "begin(${1:unsigned long baud})",
+		Priority:   completionPriority,
+	}
+	hiddenOperator := &pb.Completion{
+		Label:      "operator=",
+		Kind:       pb.Completion_FUNCTION,
+		Signature:  "operator=(const Print &)",
+		ReturnType: "Print &",
+		InsertText: "operator=(${1:const Print &})",
+		Priority:   hiddenCompletionPriority,
+	}
+
+	tests := []struct {
+		name   string
+		output string
+		want   []*pb.Completion
+	}{
+		{"arduino-preprocessor", preprocessorCompletions, []*pb.Completion{digitalWrite, print, ledPin, high, forPattern, flush, begin}},
+		{"clang", clangCompletions, []*pb.Completion{digitalWrite, print, ledPin, high, forPattern, flush, hiddenOperator, begin}},
+		{"empty", "", nil},
+		{"invalid JSON", "[{", nil},
+	}
+	for _, test := range tests {
+		got := Parse(test.output)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %d completions, want %d: %v", test.name, len(got), len(test.want), got)
+			continue
+		}
+		for i := range got {
+			if got[i].String() != test.want[i].String() {
+				t.Errorf("%s: completion %d is\n%v\nwant\n%v", test.name, i, got[i], test.want[i])
+			}
+		}
+	}
+}
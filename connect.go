@@ -0,0 +1,273 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/arduino/arduino-builder/events"
+	"github.com/arduino/arduino-builder/grpc/client"
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/legacy/builder/i18n"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	paths "github.com/arduino/go-paths-helper"
+	"golang.org/x/net/context"
+)
+
+// daemonAction is the action requested on the command line
+type daemonAction int
+
+const (
+	daemonBuild daemonAction = iota
+	daemonPreprocess
+	daemonCodeComplete
+	daemonDumpPrefs
+)
+
+// runOnDaemon runs the action on the daemon at address, with the settings
+// parsed from the command line into ctx, printing its output like a local
+// run would: the events of a build are replayed to the logger of ctx, or
+// written as they are with jsonLines, for the json logger. It returns the
+// exit code.
+func runOnDaemon(address string, opts client.Options, ctx *types.Context, action daemonAction, jsonLines bool) int {
+	params, err := daemonParams(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	c, err := client.Dial(address, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer c.Close()
+
+	// canceling the call stops the build on the daemon too
+	callCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	switch action {
+	case daemonDumpPrefs:
+		props, err := c.DumpProperties(callCtx, params)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, prop := range props.Properties {
+			fmt.Println(prop.Key + "=" + prop.Value)
+		}
+	case daemonPreprocess:
+		res, err := c.Preprocess(callCtx, params)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Print(res.Source)
+	case daemonCodeComplete:
+		list, err := c.Autocomplete(callCtx, params)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if list.Error != "" {
+			fmt.Fprintln(os.Stderr, list.Error)
+			return 1
+		}
+		output := list.Output
+		if output == "" {
+			// like the builder does, keep the output valid JSON
+			output = "[]"
+		}
+		fmt.Println(output)
+	default:
+		build := c.Build(callCtx, params)
+		print := replayEvent(ctx.GetLogger())
+		if jsonLines {
+			stream := &jsonLinesStream{encoder: json.NewEncoder(os.Stdout)}
+			print = func(event *pb.BuildEvent) { stream.Send(event) }
+		}
+		for event := range build.Events {
+			print(event)
+		}
+		result, err := build.Wait()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if !result.Success {
+			fmt.Fprintln(os.Stderr, result.Error)
+			return int(result.ExitCode)
+		}
+	}
+	return 0
+}
+
+// replayEvent returns a function printing the events of a build to logger,
+// the way the builder would have logged them
+func replayEvent(logger i18n.Logger) func(event *pb.BuildEvent) {
+	return func(event *pb.BuildEvent) {
+		switch e := event.Event.(type) {
+		case *pb.BuildEvent_Log:
+			// compiler output has no message id
+			if e.Log.MessageID == "" {
+				logger.UnformattedFprintln(os.Stderr, e.Log.Message)
+				return
+			}
+			var args []interface{}
+			for _, arg := range e.Log.Arguments {
+				args = append(args, arg)
+			}
+			logger.Println(strings.ToLower(e.Log.Level.String()), e.Log.MessageID, args...)
+		case *pb.BuildEvent_Progress:
+			if logger.Name() == "machine" {
+				logger.Println("info", events.ProgressMessageID, strconv.FormatFloat(float64(e.Progress.Percent), 'f', 2, 32))
+			}
+		}
+	}
+}
+
+// daemonParams converts the settings in ctx into the parameters of the
+// daemon calls. Paths are made absolute, since the daemon doesn't share the
+// working directory of the command line.
+func daemonParams(ctx *types.Context) (*pb.BuildParamsV2, error) {
+	fqbn, err := pb.ParseFQBN(ctx.FQBN.String())
+	if err != nil {
+		return nil, err
+	}
+	params := &pb.BuildParamsV2{
+		HardwareFolders:         absolutePaths(ctx.HardwareDirs),
+		ToolsFolders:            absolutePaths(ctx.BuiltInToolsDirs),
+		BuiltInLibrariesFolders: absolutePaths(ctx.BuiltInLibrariesDirs),
+		OtherLibrariesFolders:   absolutePaths(ctx.OtherLibrariesDirs),
+		SketchLocation:          absolutePath(ctx.SketchLocation),
+		Fqbn:                    fqbn,
+		ArduinoAPIVersion:       ctx.ArduinoAPIVersion,
+		BuildCachePath:          absolutePath(ctx.BuildCachePath),
+		BuildPath:               absolutePath(ctx.BuildPath),
+		WarningsLevel:           ctx.WarningsLevel,
+		CodeCompleteAt:          ctx.CodeCompleteAt,
+		Verbose:                 ctx.Verbose,
+		VidPid:                  ctx.USBVidPid,
+	}
+	if params.CustomBuildProperties, err = buildProperties(ctx.CustomBuildProperties); err != nil {
+		return nil, err
+	}
+	// the location is "file:line:column", and the file may contain colons
+	if parts := strings.Split(params.CodeCompleteAt, ":"); len(parts) >= 3 {
+		file := strings.Join(parts[:len(parts)-2], ":")
+		position := parts[len(parts)-2:]
+		params.CodeCompleteAt = absolutePath(paths.New(file)) + ":" + strings.Join(position, ":")
+	}
+	return params, nil
+}
+
+// preloadParams converts a build options file into the parameters of the
+// warmup of the daemon, which compiles the core of the board of the file
+// only if buildCachePath is given
+func preloadParams(file, buildCachePath string) (*pb.WarmupParams, error) {
+	buildOptions, err := readBuildOptions(file)
+	if err != nil {
+		return nil, err
+	}
+	ctx := &types.Context{}
+	ctx.InjectBuildOptions(buildOptions)
+	params := &pb.WarmupParams{
+		HardwareFolders:         absolutePaths(ctx.HardwareDirs),
+		ToolsFolders:            absolutePaths(ctx.BuiltInToolsDirs),
+		BuiltInLibrariesFolders: absolutePaths(ctx.BuiltInLibrariesDirs),
+		OtherLibrariesFolders:   absolutePaths(ctx.OtherLibrariesDirs),
+		ArduinoAPIVersion:       ctx.ArduinoAPIVersion,
+		BuildCachePath:          absolutePath(paths.New(buildCachePath)),
+	}
+	if params.CustomBuildProperties, err = buildProperties(ctx.CustomBuildProperties); err != nil {
+		return nil, err
+	}
+	if ctx.FQBN != nil && buildCachePath != "" {
+		fqbn, err := pb.ParseFQBN(ctx.FQBN.String())
+		if err != nil {
+			return nil, err
+		}
+		params.Fqbns = []*pb.FQBN{fqbn}
+	}
+	return params, nil
+}
+
+// buildProperties converts "key=value" build properties into BuildProperty
+func buildProperties(props []string) ([]*pb.BuildProperty, error) {
+	var res []*pb.BuildProperty
+	for _, prop := range props {
+		// like the builder, skip the empty properties that build options
+		// files without any leave
+		if strings.TrimSpace(prop) == "" {
+			continue
+		}
+		kv := strings.SplitN(prop, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid build property %q, should be 'key=value'", prop)
+		}
+		res = append(res, &pb.BuildProperty{Key: kv[0], Value: kv[1]})
+	}
+	return res, nil
+}
+
+func absolutePath(path *paths.Path) string {
+	if path == nil {
+		return ""
+	}
+	if abs, err := path.Abs(); err == nil {
+		return abs.String()
+	}
+	return path.String()
+}
+
+func absolutePaths(list paths.PathList) []string {
+	var res []string
+	for _, path := range list {
+		// build options files without any folder of a kind leave an empty
+		// one in the list
+		if path != nil {
+			res = append(res, absolutePath(path))
+		}
+	}
+	return res
+}
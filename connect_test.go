@@ -0,0 +1,175 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/arduino/cores"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"github.com/arduino/go-paths-helper"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestDaemonParams(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fqbn, err := cores.ParseFQBN("arduino:avr:mega:cpu=atmega2560")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &types.Context{}
+	ctx.HardwareDirs = paths.NewPathList("hardware", filepath.Join(wd, "opt", "hardware"))
+	ctx.BuiltInToolsDirs = paths.NewPathList("tools")
+	ctx.OtherLibrariesDirs = paths.NewPathList("libraries")
+	ctx.SketchLocation = paths.New("Blink", "Blink.ino")
+	ctx.FQBN = fqbn
+	ctx.ArduinoAPIVersion = "10810"
+	ctx.CustomBuildProperties = []string{"build.extra_flags=-DFOO=1", "compiler.warning_flags="}
+	ctx.BuildCachePath = paths.New(wd, "cache")
+	ctx.BuildPath = paths.New("build")
+	ctx.WarningsLevel = "all"
+	ctx.CodeCompleteAt = filepath.Join("Blink", "Blink.ino") + ":3:5"
+	ctx.Verbose = true
+	ctx.USBVidPid = "0x2341_0x0042"
+
+	got, err := daemonParams(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &pb.BuildParamsV2{
+		HardwareFolders:       []string{filepath.Join(wd, "hardware"), filepath.Join(wd, "opt", "hardware")},
+		ToolsFolders:          []string{filepath.Join(wd, "tools")},
+		OtherLibrariesFolders: []string{filepath.Join(wd, "libraries")},
+		SketchLocation:        filepath.Join(wd, "Blink", "Blink.ino"),
+		Fqbn: &pb.FQBN{
+			Package:      "arduino",
+			Architecture: "avr",
+			BoardID:      "mega",
+			Options:      []*pb.BoardOption{{Name: "cpu", Value: "atmega2560"}},
+		},
+		ArduinoAPIVersion: "10810",
+		CustomBuildProperties: []*pb.BuildProperty{
+			{Key: "build.extra_flags", Value: "-DFOO=1"},
+			{Key: "compiler.warning_flags", Value: ""},
+		},
+		BuildCachePath: filepath.Join(wd, "cache"),
+		BuildPath:      filepath.Join(wd, "build"),
+		WarningsLevel:  "all",
+		CodeCompleteAt: filepath.Join(wd, "Blink", "Blink.ino") + ":3:5",
+		Verbose:        true,
+		VidPid:         "0x2341_0x0042",
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("daemonParams() = %v, want %v", got, want)
+	}
+
+	ctx.CustomBuildProperties = []string{"nonsense"}
+	if _, err := daemonParams(ctx); err == nil {
+		t.Error("daemonParams() accepted an invalid build property")
+	}
+}
+
+func TestPreloadParams(t *testing.T) {
+	tmp, err := paths.MkTempDir("", "arduino-builder-preload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.RemoveAll()
+	hardware := tmp.Join("hardware").String()
+	tools := tmp.Join("tools").String()
+
+	// written by a build without libraries folders nor custom properties
+	file := tmp.Join("build.options.json")
+	err = file.WriteFile([]byte(`{
+  "builtInLibrariesFolders": "",
+  "builtInToolsFolders": "` + tools + `",
+  "customBuildProperties": "",
+  "fqbn": "arduino:avr:mega:cpu=atmega2560",
+  "hardwareFolders": "` + hardware + `",
+  "otherLibrariesFolders": "",
+  "runtime.ide.version": "10810",
+  "sketchLocation": "/work/Blink"
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fqbn := &pb.FQBN{
+		Package:      "arduino",
+		Architecture: "avr",
+		BoardID:      "mega",
+		Options:      []*pb.BoardOption{{Name: "cpu", Value: "atmega2560"}},
+	}
+
+	tests := []struct {
+		name           string
+		buildCachePath string
+		want           *pb.WarmupParams
+	}{
+		{
+			// without a build cache there is no core to compile ahead
+			"no build cache",
+			"",
+			&pb.WarmupParams{
+				HardwareFolders:   []string{hardware},
+				ToolsFolders:      []string{tools},
+				ArduinoAPIVersion: "10810",
+			},
+		},
+		{
+			"build cache",
+			tmp.Join("cache").String(),
+			&pb.WarmupParams{
+				HardwareFolders:   []string{hardware},
+				ToolsFolders:      []string{tools},
+				ArduinoAPIVersion: "10810",
+				BuildCachePath:    tmp.Join("cache").String(),
+				Fqbns:             []*pb.FQBN{fqbn},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := preloadParams(file.String(), test.buildCachePath)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		} else if !proto.Equal(got, test.want) {
+			t.Errorf("%s: preloadParams() = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	if _, err := preloadParams(tmp.Join("missing.json").String(), ""); err == nil {
+		t.Error("preloadParams() accepted a missing file")
+	}
+}
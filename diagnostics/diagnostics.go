@@ -0,0 +1,213 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+// Package diagnostics parses the errors and warnings printed by gcc and clang
+// into structured records, maps them back to the sketch files and writes them
+// as JSON or SARIF for code review tools.
+package diagnostics
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/arduino/arduino-cli/legacy/builder/i18n"
+)
+
+// Location is a position in a source file. Line and Column are 1-based,
+// Column is 0 when the compiler doesn't report it.
+type Location struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column,omitempty"`
+}
+
+// Origin tells which part of the build a file belongs to
+type Origin struct {
+	// Kind is one of OriginSketch, OriginLibrary, OriginCore, OriginVariant,
+	// OriginPlatform or OriginOther
+	Kind string `json:"kind"`
+	// Name is the name of the library, core or variant
+	Name string `json:"name,omitempty"`
+}
+
+// The kinds of Origin
+const (
+	OriginSketch   = "sketch"
+	OriginLibrary  = "library"
+	OriginCore     = "core"
+	OriginVariant  = "variant"
+	OriginPlatform = "platform"
+	// OriginOther is any other file, like the headers of the toolchain
+	OriginOther = "other"
+)
+
+// The severities of a Diagnostic, as printed by the compiler
+const (
+	SeverityFatalError = "fatal error"
+	SeverityError      = "error"
+	SeverityWarning    = "warning"
+	SeverityNote       = "note"
+)
+
+// Diagnostic is an error or a warning of the compiler
+type Diagnostic struct {
+	Location
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Option is the command line option enabling the warning, like
+	// "-Wunused-variable", if the compiler reports it
+	Option string `json:"option,omitempty"`
+	// Context is the function or scope the diagnostic is in, like
+	// "In function 'void setup()'"
+	Context string `json:"context,omitempty"`
+	// IncludedFrom is the chain of #include leading to File, innermost first
+	IncludedFrom []Location `json:"includedFrom,omitempty"`
+	// Notes are the notes the compiler printed after the diagnostic
+	Notes  []*Diagnostic `json:"notes,omitempty"`
+	Origin *Origin       `json:"origin,omitempty"`
+}
+
+// IsError tells if the diagnostic is an error, fatal or not
+func (d *Diagnostic) IsError() bool {
+	return d.Severity == SeverityError || d.Severity == SeverityFatalError
+}
+
+var (
+	// e.g. "sketch.ino:12:5: warning: unused variable 'foo' [-Wunused-variable]"
+	diagnosticRegexp = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)? (fatal error|error|warning|note): (.*)$`)
+	optionRegexp     = regexp.MustCompile(` \[(-W[^\]]+)\]$`)
+	// e.g. "In file included from sketch.ino:1:0:" followed by lines like
+	// "                 from other.h:3,"
+	includedFromRegexp     = regexp.MustCompile(`^In file included from (.+?):(\d+)(?::(\d+))?[:,]$`)
+	includedFromMoreRegexp = regexp.MustCompile(`^\s+from (.+?):(\d+)(?::(\d+))?[:,]$`)
+	// e.g. "sketch.ino: In function 'void setup()':"
+	contextRegexp = regexp.MustCompile(`^(.+?): ((?:In|At) [^:].*):$`)
+)
+
+// Parse returns the diagnostics in the output of a run of the compiler.
+// Notes are attached to the diagnostic they follow and the "In file included
+// from" lines to the diagnostic below them; any other line is ignored.
+func Parse(output string) []*Diagnostic {
+	res := []*Diagnostic{}
+	var last *Diagnostic
+	var includedFrom []Location
+	contextFile, context := "", ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := includedFromRegexp.FindStringSubmatch(line); m != nil {
+			includedFrom = []Location{toLocation(m[1], m[2], m[3])}
+			continue
+		}
+		if m := includedFromMoreRegexp.FindStringSubmatch(line); m != nil && includedFrom != nil {
+			includedFrom = append(includedFrom, toLocation(m[1], m[2], m[3]))
+			continue
+		}
+		m := diagnosticRegexp.FindStringSubmatch(line)
+		if m == nil {
+			if m := contextRegexp.FindStringSubmatch(line); m != nil {
+				contextFile, context = m[1], m[2]
+			}
+			continue
+		}
+		d := &Diagnostic{
+			Location:     toLocation(m[1], m[2], m[3]),
+			Severity:     m[4],
+			Message:      m[5],
+			IncludedFrom: includedFrom,
+		}
+		includedFrom = nil
+		if option := optionRegexp.FindStringSubmatch(d.Message); option != nil {
+			d.Option = option[1]
+			d.Message = strings.TrimSuffix(d.Message, option[0])
+		}
+		if d.File == contextFile {
+			d.Context = context
+		}
+		if d.Severity == SeverityNote && last != nil {
+			last.Notes = append(last.Notes, d)
+			continue
+		}
+		res = append(res, d)
+		last = d
+	}
+	return res
+}
+
+func toLocation(file, line, column string) Location {
+	l := Location{File: file}
+	l.Line, _ = strconv.Atoi(line)
+	l.Column, _ = strconv.Atoi(column)
+	return l
+}
+
+// Collector is an i18n.Logger collecting the diagnostics in the output of the
+// compiler, passing everything through to the wrapped logger
+type Collector struct {
+	i18n.Logger
+
+	mux         sync.Mutex
+	diagnostics []*Diagnostic
+}
+
+// NewCollector returns a Collector wrapping logger
+func NewCollector(logger i18n.Logger) *Collector {
+	return &Collector{Logger: logger}
+}
+
+func (c *Collector) UnformattedFprintln(w io.Writer, str string) {
+	c.collect(str)
+	c.Logger.UnformattedFprintln(w, str)
+}
+
+func (c *Collector) UnformattedWrite(w io.Writer, data []byte) {
+	c.collect(string(data))
+	c.Logger.UnformattedWrite(w, data)
+}
+
+// collect parses output on its own: the builder logs the whole output of a
+// run of the compiler at once, so a diagnostic is never split between calls
+func (c *Collector) collect(output string) {
+	diagnostics := Parse(output)
+	if len(diagnostics) == 0 {
+		return
+	}
+	c.mux.Lock()
+	c.diagnostics = append(c.diagnostics, diagnostics...)
+	c.mux.Unlock()
+}
+
+// Diagnostics returns the diagnostics collected so far
+func (c *Collector) Diagnostics() []*Diagnostic {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return append([]*Diagnostic{}, c.diagnostics...)
+}
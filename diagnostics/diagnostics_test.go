@@ -0,0 +1,92 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package diagnostics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	output := `/tmp/build/sketch/Blink.ino.cpp: In function 'void setup()':
+/tmp/build/sketch/Blink.ino.cpp:10:7: warning: unused variable 'x' [-Wunused-variable]
+/tmp/build/sketch/Blink.ino.cpp:12:3: error: 'foo' was not declared in this scope
+/tmp/build/sketch/Blink.ino.cpp:12:3: note: suggested alternative: 'for'
+In file included from /tmp/build/sketch/Blink.ino.cpp:1:0,
+                 from /tmp/build/sketch/other.h:2:
+/libs/Servo/Servo.h:5: fatal error: foo.h: No such file or directory
+compilation terminated.
+exit status 1
+`
+	context := "In function 'void setup()'"
+	want := []*Diagnostic{
+		{
+			Location: Location{File: "/tmp/build/sketch/Blink.ino.cpp", Line: 10, Column: 7},
+			Severity: SeverityWarning,
+			Message:  "unused variable 'x'",
+			Option:   "-Wunused-variable",
+			Context:  context,
+		},
+		{
+			Location: Location{File: "/tmp/build/sketch/Blink.ino.cpp", Line: 12, Column: 3},
+			Severity: SeverityError,
+			Message:  "'foo' was not declared in this scope",
+			Context:  context,
+			Notes: []*Diagnostic{{
+				Location: Location{File: "/tmp/build/sketch/Blink.ino.cpp", Line: 12, Column: 3},
+				Severity: SeverityNote,
+				Message:  "suggested alternative: 'for'",
+				Context:  context,
+			}},
+		},
+		{
+			Location: Location{File: "/libs/Servo/Servo.h", Line: 5},
+			Severity: SeverityFatalError,
+			Message:  "foo.h: No such file or directory",
+			IncludedFrom: []Location{
+				{File: "/tmp/build/sketch/Blink.ino.cpp", Line: 1},
+				{File: "/tmp/build/sketch/other.h", Line: 2},
+			},
+		},
+	}
+	got := Parse(output)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() returned %d diagnostics:", len(got))
+		for _, d := range got {
+			t.Errorf("  %+v", *d)
+		}
+	}
+}
+
+func TestParseWithoutDiagnostics(t *testing.T) {
+	if got := Parse("Sketch uses 924 bytes (2%) of program storage space.\n"); len(got) != 0 {
+		t.Errorf("Parse() = %v, want none", got)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package diagnostics
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/arduino/arduino-builder/sourcemap"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+)
+
+// Remapper maps the locations in the copy of a sketch made by the builder to
+// the files of the sketch, and tells which part of the build a file belongs to
+type Remapper struct {
+	sketchFolder    string
+	sketchBuildPath string
+	generated       sourcemap.Map
+	// folders are sorted longest first, so that a folder nested in another
+	// one is matched first
+	folders []*originFolder
+}
+
+type originFolder struct {
+	path   string
+	origin *Origin
+}
+
+// NewRemapper returns a Remapper for the build in ctx of the sketch in
+// sketchFolder. The libraries and the core are known once the build ran.
+func NewRemapper(ctx *types.Context, sketchFolder string) *Remapper {
+	r := &Remapper{
+		sketchFolder: sketchFolder,
+		generated:    sourcemap.Parse(ctx.Source),
+	}
+	if ctx.SketchBuildPath != nil {
+		r.sketchBuildPath = ctx.SketchBuildPath.String()
+	} else if ctx.BuildPath != nil {
+		r.sketchBuildPath = ctx.BuildPath.Join("sketch").String()
+	}
+
+	r.addFolder(sketchFolder, &Origin{Kind: OriginSketch})
+	for _, lib := range ctx.ImportedLibraries {
+		if lib.InstallDir != nil {
+			r.addFolder(lib.InstallDir.String(), &Origin{Kind: OriginLibrary, Name: lib.Name})
+		}
+	}
+	if props := ctx.BuildProperties; props != nil {
+		r.addFolder(props.Get("build.core.path"), &Origin{Kind: OriginCore, Name: props.Get("build.core")})
+		r.addFolder(props.Get("build.variant.path"), &Origin{Kind: OriginVariant, Name: props.Get("build.variant")})
+		r.addFolder(props.Get("runtime.platform.path"), &Origin{Kind: OriginPlatform})
+	}
+	sort.SliceStable(r.folders, func(i, j int) bool {
+		return len(r.folders[i].path) > len(r.folders[j].path)
+	})
+	return r
+}
+
+func (r *Remapper) addFolder(path string, origin *Origin) {
+	if path == "" {
+		return
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	r.folders = append(r.folders, &originFolder{path: path, origin: origin})
+}
+
+// ToOriginal returns the location in the sketch the given location comes
+// from. The lines of the .cpp generated out of the .ino files are mapped
+// through its #line directives, while the other files of the sketch are
+// simply copied.
+func (r *Remapper) ToOriginal(file string, line int) (string, int) {
+	rel, err := filepath.Rel(r.sketchBuildPath, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file, line
+	}
+	if strings.HasSuffix(rel, ".ino.cpp") {
+		if originalFile, originalLine, ok := r.generated.ToOriginal(line); ok {
+			return originalFile, originalLine
+		}
+		// the lines added by the builder, like the #include of Arduino.h,
+		// are reported at the beginning of the sketch
+		if len(r.generated) > 0 {
+			return r.generated[0].File, 1
+		}
+	}
+	return filepath.Join(r.sketchFolder, rel), line
+}
+
+// Origin tells which part of the build file belongs to
+func (r *Remapper) Origin(file string) *Origin {
+	for _, folder := range r.folders {
+		if isInside(folder.path, file) {
+			origin := *folder.origin
+			return &origin
+		}
+	}
+	return &Origin{Kind: OriginOther}
+}
+
+func isInside(folder, file string) bool {
+	rel, err := filepath.Rel(folder, file)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Resolve maps the given diagnostics, with their notes and #include chains,
+// to the files of the sketch and tags them with their origin. The duplicates,
+// like the warnings in a header included by many files, are dropped.
+func (r *Remapper) Resolve(diagnostics []*Diagnostic) []*Diagnostic {
+	res := []*Diagnostic{}
+	seen := map[string]bool{}
+	for _, d := range diagnostics {
+		r.resolve(d)
+		key := fmt.Sprintf("%s:%d:%d:%s:%s", d.File, d.Line, d.Column, d.Severity, d.Message)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, d)
+	}
+	return res
+}
+
+func (r *Remapper) resolve(d *Diagnostic) {
+	d.Location = r.toOriginalLocation(d.Location)
+	d.Origin = r.Origin(d.File)
+	for i, location := range d.IncludedFrom {
+		d.IncludedFrom[i] = r.toOriginalLocation(location)
+	}
+	for _, note := range d.Notes {
+		r.resolve(note)
+	}
+}
+
+func (r *Remapper) toOriginalLocation(location Location) Location {
+	if filepath.IsAbs(location.File) {
+		location.File = filepath.Clean(location.File)
+	}
+	location.File, location.Line = r.ToOriginal(location.File, location.Line)
+	return location
+}
@@ -0,0 +1,176 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package diagnostics
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arduino/arduino-cli/arduino/libraries"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	paths "github.com/arduino/go-paths-helper"
+	properties "github.com/arduino/go-properties-orderedmap"
+)
+
+// resolvedBuild returns the context of a build of /work/Blink, with the
+// Servo library, in /tmp/build
+func resolvedBuild() *types.Context {
+	ctx := &types.Context{}
+	ctx.BuildPath = paths.New("/tmp/build")
+	ctx.Source = `#include <Arduino.h>
+#line 1 "/work/Blink/Blink.ino"
+void setup() {
+  int x;
+}
+#line 1 "/work/Blink/tab.ino"
+void loop() {}
+`
+	ctx.ImportedLibraries = libraries.List{{Name: "Servo", InstallDir: paths.New("/libs/Servo")}}
+	ctx.BuildProperties = properties.NewFromHashmap(map[string]string{
+		"build.core.path":       "/hw/avr/cores/arduino",
+		"build.core":            "arduino",
+		"build.variant.path":    "/hw/avr/variants/standard",
+		"build.variant":         "standard",
+		"runtime.platform.path": "/hw/avr",
+	})
+	return ctx
+}
+
+func TestResolve(t *testing.T) {
+	r := NewRemapper(resolvedBuild(), "/work/Blink")
+	unused := func() *Diagnostic {
+		return &Diagnostic{
+			Location: Location{File: "/tmp/build/sketch/Blink.ino.cpp", Line: 4, Column: 7},
+			Severity: SeverityWarning,
+			Message:  "unused variable 'x'",
+		}
+	}
+	got := r.Resolve([]*Diagnostic{
+		unused(),
+		// e.g. the same warning of a header included twice
+		unused(),
+		{
+			Location:     Location{File: "/libs/Servo/Servo.h", Line: 5},
+			Severity:     SeverityError,
+			Message:      "expected ';'",
+			IncludedFrom: []Location{{File: "/tmp/build/sketch/Blink.ino.cpp", Line: 1}},
+		},
+		{
+			Location: Location{File: "/hw/avr/cores/arduino/main.cpp", Line: 3},
+			Severity: SeverityWarning,
+			Message:  "unused parameter",
+			Notes: []*Diagnostic{{
+				Location: Location{File: "/hw/avr/variants/standard/pins_arduino.h", Line: 7},
+				Severity: SeverityNote,
+				Message:  "declared here",
+			}},
+		},
+		{
+			Location: Location{File: "/tmp/build/sketch/Blink.ino.cpp", Line: 7},
+			Severity: SeverityError,
+			Message:  "'bar' was not declared in this scope",
+		},
+		{
+			Location: Location{File: "/tmp/build/sketch/util.cpp", Line: 3},
+			Severity: SeverityWarning,
+			Message:  "comparison between signed and unsigned",
+		},
+		{
+			Location: Location{File: "/hw/avr/platform.h", Line: 2},
+			Severity: SeverityWarning,
+			Message:  "deprecated",
+		},
+		{
+			Location: Location{File: "/usr/include/stdio.h", Line: 1},
+			Severity: SeverityWarning,
+			Message:  "redefined",
+		},
+	})
+
+	want := []*Diagnostic{
+		{
+			Location: Location{File: "/work/Blink/Blink.ino", Line: 2, Column: 7},
+			Severity: SeverityWarning,
+			Message:  "unused variable 'x'",
+			Origin:   &Origin{Kind: OriginSketch},
+		},
+		{
+			Location:     Location{File: "/libs/Servo/Servo.h", Line: 5},
+			Severity:     SeverityError,
+			Message:      "expected ';'",
+			IncludedFrom: []Location{{File: "/work/Blink/Blink.ino", Line: 1}},
+			Origin:       &Origin{Kind: OriginLibrary, Name: "Servo"},
+		},
+		{
+			Location: Location{File: "/hw/avr/cores/arduino/main.cpp", Line: 3},
+			Severity: SeverityWarning,
+			Message:  "unused parameter",
+			Notes: []*Diagnostic{{
+				Location: Location{File: "/hw/avr/variants/standard/pins_arduino.h", Line: 7},
+				Severity: SeverityNote,
+				Message:  "declared here",
+				Origin:   &Origin{Kind: OriginVariant, Name: "standard"},
+			}},
+			Origin: &Origin{Kind: OriginCore, Name: "arduino"},
+		},
+		{
+			Location: Location{File: "/work/Blink/tab.ino", Line: 1},
+			Severity: SeverityError,
+			Message:  "'bar' was not declared in this scope",
+			Origin:   &Origin{Kind: OriginSketch},
+		},
+		{
+			Location: Location{File: "/work/Blink/util.cpp", Line: 3},
+			Severity: SeverityWarning,
+			Message:  "comparison between signed and unsigned",
+			Origin:   &Origin{Kind: OriginSketch},
+		},
+		{
+			Location: Location{File: "/hw/avr/platform.h", Line: 2},
+			Severity: SeverityWarning,
+			Message:  "deprecated",
+			Origin:   &Origin{Kind: OriginPlatform},
+		},
+		{
+			Location: Location{File: "/usr/include/stdio.h", Line: 1},
+			Severity: SeverityWarning,
+			Message:  "redefined",
+			Origin:   &Origin{Kind: OriginOther},
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Resolve() returned %d diagnostics, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+}
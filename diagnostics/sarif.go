@@ -0,0 +1,222 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// WriteJSON writes diagnostics to w as a JSON object, along with the number
+// of errors and warnings
+func WriteJSON(w io.Writer, diagnostics []*Diagnostic) error {
+	res := struct {
+		Errors      int           `json:"errors"`
+		Warnings    int           `json:"warnings"`
+		Diagnostics []*Diagnostic `json:"diagnostics"`
+	}{Diagnostics: diagnostics}
+	if res.Diagnostics == nil {
+		res.Diagnostics = []*Diagnostic{}
+	}
+	for _, d := range diagnostics {
+		if d.IsError() {
+			res.Errors++
+		} else if d.Severity == SeverityWarning {
+			res.Warnings++
+		}
+	}
+	return writeIndented(w, res)
+}
+
+func writeIndented(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// sketchURIBaseID is the base of the URIs of the files of the sketch, so that
+// the annotations don't depend on where the sketch is checked out
+const sketchURIBaseID = "SKETCH"
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                    `json:"tool"`
+	OriginalURIBaseIDs map[string]*sarifArtifactLoc `json:"originalUriBaseIds,omitempty"`
+	Results            []*sarifResult               `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	RuleID           string           `json:"ruleId,omitempty"`
+	Level            string           `json:"level"`
+	Message          sarifMessage     `json:"message"`
+	Locations        []*sarifLocation `json:"locations"`
+	RelatedLocations []*sarifLocation `json:"relatedLocations,omitempty"`
+	Properties       *sarifProperties `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLoc `json:"artifactLocation"`
+	Region           *sarifRegion     `json:"region,omitempty"`
+}
+
+type sarifArtifactLoc struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifProperties struct {
+	Severity string  `json:"severity"`
+	Context  string  `json:"context,omitempty"`
+	Origin   *Origin `json:"origin,omitempty"`
+}
+
+// WriteSARIF writes diagnostics to w as a SARIF 2.1.0 log of a run of the
+// builder with the given version. The files in sketchFolder are given
+// relative to the SKETCH base URI, the others with their absolute path. The
+// notes and the #include chain of a diagnostic are its related locations.
+func WriteSARIF(w io.Writer, diagnostics []*Diagnostic, sketchFolder, version string) error {
+	run := &sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "arduino-builder",
+			Version:        version,
+			InformationURI: "https://github.com/arduino/arduino-builder",
+		}},
+		Results: []*sarifResult{},
+	}
+	if sketchFolder != "" {
+		run.OriginalURIBaseIDs = map[string]*sarifArtifactLoc{
+			sketchURIBaseID: {URI: fileURI(sketchFolder) + "/"},
+		}
+	}
+	for _, d := range diagnostics {
+		result := &sarifResult{
+			RuleID:     d.Option,
+			Level:      sarifLevel(d.Severity),
+			Message:    sarifMessage{Text: d.Message},
+			Locations:  []*sarifLocation{newSARIFLocation(d.Location, sketchFolder)},
+			Properties: &sarifProperties{Severity: d.Severity, Context: d.Context, Origin: d.Origin},
+		}
+		for _, note := range d.Notes {
+			location := newSARIFLocation(note.Location, sketchFolder)
+			location.Message = &sarifMessage{Text: note.Message}
+			result.RelatedLocations = append(result.RelatedLocations, location)
+		}
+		for _, includedFrom := range d.IncludedFrom {
+			location := newSARIFLocation(includedFrom, sketchFolder)
+			location.Message = &sarifMessage{Text: "In file included from here"}
+			result.RelatedLocations = append(result.RelatedLocations, location)
+		}
+		for i, location := range result.RelatedLocations {
+			location.ID = i + 1
+		}
+		run.Results = append(run.Results, result)
+	}
+	return writeIndented(w, &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []*sarifRun{run},
+	})
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityError, SeverityFatalError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func newSARIFLocation(location Location, sketchFolder string) *sarifLocation {
+	res := &sarifLocation{}
+	if sketchFolder != "" && isInside(sketchFolder, location.File) {
+		rel, _ := filepath.Rel(sketchFolder, location.File)
+		res.PhysicalLocation.ArtifactLocation = sarifArtifactLoc{
+			URI:       (&url.URL{Path: filepath.ToSlash(rel)}).String(),
+			URIBaseID: sketchURIBaseID,
+		}
+	} else if filepath.IsAbs(location.File) {
+		res.PhysicalLocation.ArtifactLocation.URI = fileURI(location.File)
+	} else {
+		res.PhysicalLocation.ArtifactLocation.URI = (&url.URL{Path: filepath.ToSlash(location.File)}).String()
+	}
+	if location.Line > 0 {
+		res.PhysicalLocation.Region = &sarifRegion{StartLine: location.Line, StartColumn: location.Column}
+	}
+	return res
+}
+
+// fileURI returns the file:// URI of an absolute path
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows paths like C:/foo
+		path = "/" + path
+	}
+	return strings.TrimSuffix((&url.URL{Scheme: "file", Path: path}).String(), "/")
+}
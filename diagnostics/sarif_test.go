@@ -0,0 +1,83 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package diagnostics
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestWriteSARIF(t *testing.T) {
+	list := []*Diagnostic{
+		{
+			Location: Location{File: "/work/Blink/Blink.ino", Line: 2, Column: 7},
+			Severity: SeverityWarning,
+			Message:  "unused variable 'x'",
+			Option:   "-Wunused-variable",
+			Context:  "In function 'void setup()'",
+			Origin:   &Origin{Kind: OriginSketch},
+		},
+		{
+			Location: Location{File: "/libs/Servo/Servo.h", Line: 5},
+			Severity: SeverityFatalError,
+			Message:  "foo.h: No such file or directory",
+			Notes: []*Diagnostic{{
+				Location: Location{File: "/libs/Servo/Servo.h", Line: 5},
+				Severity: SeverityNote,
+				Message:  "in expansion of macro 'INCLUDE'",
+			}},
+			IncludedFrom: []Location{{File: "/work/Blink/my tab.ino", Line: 1}},
+			Origin:       &Origin{Kind: OriginLibrary, Name: "Servo"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, list, "/work/Blink", "1.6.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "diagnostics.sarif")
+	if *update {
+		if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteSARIF() wrote:\n%s\nwant:\n%s", buf.Bytes(), want)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arduino/arduino-builder/diagnostics"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+)
+
+// writeDiagnostics maps the diagnostics collected during the build in ctx to
+// the sketch files and writes them to each of files: as SARIF if its name
+// ends with .sarif or .sarif.json, as JSON otherwise
+func writeDiagnostics(ctx *types.Context, collected []*diagnostics.Diagnostic, files []string) error {
+	sketchFolder := ""
+	if ctx.SketchLocation != nil {
+		sketchFolder = ctx.SketchLocation.String()
+		if !ctx.SketchLocation.IsDir() {
+			sketchFolder = filepath.Dir(sketchFolder)
+		}
+		if abs, err := filepath.Abs(sketchFolder); err == nil {
+			sketchFolder = abs
+		}
+	}
+	resolved := diagnostics.NewRemapper(ctx, sketchFolder).Resolve(collected)
+
+	for _, file := range files {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		name := strings.ToLower(file)
+		if strings.HasSuffix(name, ".sarif") || strings.HasSuffix(name, ".sarif.json") {
+			err = diagnostics.WriteSARIF(f, resolved, sketchFolder, VERSION)
+		} else {
+			err = diagnostics.WriteJSON(f, resolved)
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+// Package events turns what the builder logs into structured BuildEvents,
+// for the daemon, the language server and the json logger.
+package events
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/arduino/arduino-builder/diagnostics"
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/legacy/builder/i18n"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"github.com/pkg/errors"
+)
+
+// ProgressMessageID is the message the builder logs to report its progress
+// to machine loggers
+const ProgressMessageID = "Progress {0}"
+
+var logLevels = map[string]pb.LogRecord_Level{
+	"debug": pb.LogRecord_DEBUG,
+	"info":  pb.LogRecord_INFO,
+	"warn":  pb.LogRecord_WARN,
+	"error": pb.LogRecord_ERROR,
+}
+
+var diagnosticSeverities = map[string]pb.CompilerDiagnostic_Severity{
+	diagnostics.SeverityFatalError: pb.CompilerDiagnostic_FATAL_ERROR,
+	diagnostics.SeverityError:      pb.CompilerDiagnostic_ERROR,
+	diagnostics.SeverityWarning:    pb.CompilerDiagnostic_WARNING,
+	diagnostics.SeverityNote:       pb.CompilerDiagnostic_NOTE,
+}
+
+// Stream receives the events of a build, one at a time
+type Stream interface {
+	Send(*pb.BuildEvent) error
+}
+
+// DiagnosticsCollector is a Stream keeping only the diagnostics
+type DiagnosticsCollector struct {
+	Diagnostics []*pb.CompilerDiagnostic
+}
+
+func (c *DiagnosticsCollector) Send(event *pb.BuildEvent) error {
+	if diagnostic := event.GetDiagnostic(); diagnostic != nil {
+		c.Diagnostics = append(c.Diagnostics, diagnostic)
+	}
+	return nil
+}
+
+// EventLogger is an i18n.Logger that sends everything the builder logs as
+// structured BuildEvents. It is safe for concurrent use, as the builder logs
+// from many goroutines when compiling in parallel.
+type EventLogger struct {
+	mux    sync.Mutex
+	stream Stream
+	ctx    *types.Context
+	phase  pb.BuildPhase
+	// remapper maps the diagnostics to the sketch files, it is made again
+	// whenever the builder regenerates the source of the sketch
+	remapper       *diagnostics.Remapper
+	remapperSource string
+}
+
+var _ i18n.Logger = (*EventLogger)(nil)
+
+// NewEventLogger returns an EventLogger sending the events of the build in
+// ctx to stream
+func NewEventLogger(stream Stream, ctx *types.Context) *EventLogger {
+	return &EventLogger{stream: stream, ctx: ctx}
+}
+
+func (s *EventLogger) Fprintln(w io.Writer, level string, format string, a ...interface{}) {
+	s.Println(level, format, a...)
+}
+
+func (s *EventLogger) UnformattedFprintln(w io.Writer, str string) {
+	s.compilerOutput(str)
+}
+
+func (s *EventLogger) UnformattedWrite(w io.Writer, data []byte) {
+	s.compilerOutput(string(data))
+}
+
+func (s *EventLogger) Println(level string, format string, a ...interface{}) {
+	if percent, ok := ParseProgress(format, a); ok {
+		s.progress(percent)
+		return
+	}
+
+	record := &pb.LogRecord{
+		Level:     logLevels[level],
+		MessageID: format,
+		Message:   i18n.Format(format, a...),
+	}
+	for _, arg := range a {
+		record.Arguments = append(record.Arguments, fmt.Sprint(arg))
+	}
+	s.send(&pb.BuildEvent{Event: &pb.BuildEvent_Log{Log: record}})
+}
+
+func (s *EventLogger) Flush() string {
+	return ""
+}
+
+// Name pretends to be the machine logger, since the builder reports its
+// progress only to that one.
+func (s *EventLogger) Name() string {
+	return "machine"
+}
+
+// progress sends the progress of the build, along with the phase it is in
+func (s *EventLogger) progress(percent float32) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if phase := BuildPhase(s.ctx); phase > s.phase {
+		s.phase = phase
+	}
+	s.stream.Send(&pb.BuildEvent{Event: &pb.BuildEvent_Progress{Progress: &pb.Progress{Phase: s.phase, Percent: percent}}})
+}
+
+// ParseProgress returns the percentage of a progress message of the builder
+func ParseProgress(format string, a []interface{}) (float32, bool) {
+	if format != ProgressMessageID || len(a) != 1 {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(fmt.Sprint(a[0]), 32)
+	if err != nil {
+		return 0, false
+	}
+	return float32(percent), true
+}
+
+// BuildPhase returns the phase the build in ctx is in, judging from what the
+// builder filled in so far. The builder reports its progress right after
+// each of its steps, and while compiling, so this is checked then: the
+// messages announcing the phases are logged only by verbose builds.
+func BuildPhase(ctx *types.Context) pb.BuildPhase {
+	switch {
+	case ctx == nil:
+		return pb.BuildPhase_UNKNOWN_PHASE
+	case ctx.CoreArchiveFilePath != nil:
+		return pb.BuildPhase_LINKING
+	case ctx.LibrariesObjectFiles != nil:
+		return pb.BuildPhase_COMPILING_CORE
+	case ctx.SketchObjectFiles != nil:
+		return pb.BuildPhase_COMPILING_LIBRARIES
+	case ctx.CTagsOutput != "":
+		return pb.BuildPhase_COMPILING_SKETCH
+	case ctx.IncludeFolders != nil:
+		return pb.BuildPhase_GENERATING_PROTOTYPES
+	case ctx.Source != "":
+		return pb.BuildPhase_DETECTING_LIBRARIES
+	}
+	return pb.BuildPhase_UNKNOWN_PHASE
+}
+
+// compilerOutput sends the output of the tools run by the builder, followed
+// by the diagnostics found in it, mapped to the sketch files.
+func (s *EventLogger) compilerOutput(output string) {
+	for _, line := range strings.Split(strings.TrimRight(output, "\r\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		s.send(&pb.BuildEvent{Event: &pb.BuildEvent_Log{Log: &pb.LogRecord{Message: line}}})
+	}
+	found := diagnostics.Parse(output)
+	if len(found) == 0 {
+		return
+	}
+	if remapper := s.getRemapper(); remapper != nil {
+		found = remapper.Resolve(found)
+	}
+	for _, d := range found {
+		s.send(&pb.BuildEvent{Event: &pb.BuildEvent_Diagnostic{Diagnostic: toProtoDiagnostic(d)}})
+	}
+}
+
+// getRemapper returns the Remapper for the current state of the build, nil
+// if the sketch is not known
+func (s *EventLogger) getRemapper() *diagnostics.Remapper {
+	if s.ctx == nil || s.ctx.SketchLocation == nil {
+		return nil
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.remapper == nil || s.remapperSource != s.ctx.Source {
+		sketchFolder := s.ctx.SketchLocation
+		if info, err := sketchFolder.Stat(); err == nil && !info.IsDir() {
+			sketchFolder = sketchFolder.Parent()
+		}
+		s.remapper = diagnostics.NewRemapper(s.ctx, sketchFolder.String())
+		s.remapperSource = s.ctx.Source
+	}
+	return s.remapper
+}
+
+func toProtoDiagnostic(d *diagnostics.Diagnostic) *pb.CompilerDiagnostic {
+	res := &pb.CompilerDiagnostic{
+		File:     d.File,
+		Line:     int32(d.Line),
+		Column:   int32(d.Column),
+		Severity: diagnosticSeverities[d.Severity],
+		Message:  d.Message,
+		Option:   d.Option,
+		Context:  d.Context,
+	}
+	for _, location := range d.IncludedFrom {
+		res.IncludedFrom = append(res.IncludedFrom, &pb.SourceLocation{
+			File:   location.File,
+			Line:   int32(location.Line),
+			Column: int32(location.Column),
+		})
+	}
+	for _, note := range d.Notes {
+		res.Notes = append(res.Notes, toProtoDiagnostic(note))
+	}
+	if d.Origin != nil {
+		res.Origin = &pb.DiagnosticOrigin{Kind: d.Origin.Kind, Name: d.Origin.Name}
+	}
+	return res
+}
+
+// SendArtifacts sends an event for each of the files produced by the
+// build in ctx.
+func (s *EventLogger) SendArtifacts(ctx *types.Context) {
+	for _, artifact := range Artifacts(ctx) {
+		s.send(&pb.BuildEvent{Event: &pb.BuildEvent_Artifact{Artifact: artifact}})
+	}
+}
+
+// Artifacts returns the files produced by the build in ctx, named after the
+// sketch
+func Artifacts(ctx *types.Context) []*pb.Artifact {
+	res := []*pb.Artifact{}
+	if ctx.BuildPath == nil || ctx.BuildProperties == nil {
+		return res
+	}
+	files, err := ctx.BuildPath.ReadDir()
+	if err != nil {
+		return res
+	}
+	prefix := ctx.BuildProperties.Get("build.project_name") + "."
+	for _, file := range files {
+		info, err := file.Stat()
+		if err != nil || !info.Mode().IsRegular() || !strings.HasPrefix(file.Base(), prefix) {
+			continue
+		}
+		res = append(res, &pb.Artifact{Path: file.String(), Size: info.Size()})
+	}
+	return res
+}
+
+// SendResult sends the final event of the build in ctx, which ended with
+// the given error.
+func (s *EventLogger) SendResult(ctx *types.Context, err error) {
+	result := &pb.BuildResult{Success: err == nil}
+	if err != nil {
+		result.ExitCode = int32(ExitCode(err))
+		result.Error = err.Error()
+	}
+	for _, section := range ctx.ExecutableSectionsSize {
+		result.Sizes = append(result.Sizes, &pb.ExecutableSectionSize{
+			Name:    section.Name,
+			Size:    int64(section.Size),
+			MaxSize: int64(section.MaxSize),
+		})
+	}
+	s.send(&pb.BuildEvent{Event: &pb.BuildEvent_Result{Result: result}})
+}
+
+func (s *EventLogger) send(event *pb.BuildEvent) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.stream.Send(event)
+}
+
+// ExitCode returns the exit code the command line builder would have
+// returned for the given error
+func ExitCode(err error) int {
+	if exiterr, ok := errors.Cause(err).(*exec.ExitError); ok {
+		if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
+		}
+	}
+	return 1
+}
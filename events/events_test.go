@@ -0,0 +1,128 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package events
+
+import (
+	"sync"
+	"testing"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"github.com/arduino/go-paths-helper"
+)
+
+type eventRecorder struct {
+	events []*pb.BuildEvent
+}
+
+func (r *eventRecorder) Send(event *pb.BuildEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestBuildPhase(t *testing.T) {
+	ctx := &types.Context{}
+	steps := []struct {
+		step func()
+		want pb.BuildPhase
+	}{
+		{func() {}, pb.BuildPhase_UNKNOWN_PHASE},
+		{func() { ctx.Source = "void setup() {}" }, pb.BuildPhase_DETECTING_LIBRARIES},
+		{func() { ctx.IncludeFolders = paths.NewPathList("/core") }, pb.BuildPhase_GENERATING_PROTOTYPES},
+		{func() { ctx.CTagsOutput = "setup\t/sketch.ino.cpp" }, pb.BuildPhase_COMPILING_SKETCH},
+		{func() { ctx.SketchObjectFiles = paths.NewPathList() }, pb.BuildPhase_COMPILING_LIBRARIES},
+		{func() { ctx.LibrariesObjectFiles = paths.NewPathList() }, pb.BuildPhase_COMPILING_CORE},
+		{func() { ctx.CoreArchiveFilePath = paths.New("/build/core/core.a") }, pb.BuildPhase_LINKING},
+	}
+	for _, step := range steps {
+		step.step()
+		if got := BuildPhase(ctx); got != step.want {
+			t.Errorf("got phase %s, want %s", got, step.want)
+		}
+	}
+}
+
+func TestEventLoggerProgress(t *testing.T) {
+	ctx := &types.Context{Source: "void setup() {}"}
+	recorder := &eventRecorder{}
+	logger := NewEventLogger(recorder, ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Println("info", ProgressMessageID, 50)
+			logger.Println("info", "Using library {0}", "Servo")
+		}()
+	}
+	wg.Wait()
+
+	progress := 0
+	for _, event := range recorder.events {
+		if p := event.GetProgress(); p != nil {
+			progress++
+			if p.Phase != pb.BuildPhase_DETECTING_LIBRARIES || p.Percent != 50 {
+				t.Errorf("unexpected progress %v", p)
+			}
+		}
+	}
+	if progress != 10 || len(recorder.events) != 20 {
+		t.Errorf("got %d progress events out of %d, want 10 out of 20", progress, len(recorder.events))
+	}
+}
+
+func TestEventLoggerDiagnostics(t *testing.T) {
+	ctx := &types.Context{}
+	ctx.SketchLocation = paths.New("/work/Blink")
+	ctx.BuildPath = paths.New("/tmp/build")
+	ctx.Source = "#include <Arduino.h>\n#line 1 \"/work/Blink/Blink.ino\"\nvoid setup() {\n  int x;\n}\n"
+	collector := &DiagnosticsCollector{}
+	logger := NewEventLogger(collector, ctx)
+	logger.UnformattedFprintln(nil, "In file included from /tmp/build/sketch/Blink.ino.cpp:1:0:\n"+
+		"/tmp/build/sketch/Blink.ino.cpp:4:7: warning: unused variable 'x' [-Wunused-variable]\n"+
+		"/tmp/build/sketch/Blink.ino.cpp:4:7: note: declared here")
+
+	if len(collector.Diagnostics) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(collector.Diagnostics))
+	}
+	d := collector.Diagnostics[0]
+	if d.File != "/work/Blink/Blink.ino" || d.Line != 2 || d.Column != 7 {
+		t.Errorf("diagnostic at %s:%d:%d, want /work/Blink/Blink.ino:2:7", d.File, d.Line, d.Column)
+	}
+	if d.Option != "-Wunused-variable" || d.Origin.GetKind() != "sketch" {
+		t.Errorf("option %q and origin %v, want -Wunused-variable and sketch", d.Option, d.Origin)
+	}
+	if len(d.IncludedFrom) != 1 || d.IncludedFrom[0].File != "/work/Blink/Blink.ino" || d.IncludedFrom[0].Line != 1 {
+		t.Errorf("included from %v, want /work/Blink/Blink.ino:1", d.IncludedFrom)
+	}
+	if len(d.Notes) != 1 || d.Notes[0].Severity != pb.CompilerDiagnostic_NOTE {
+		t.Errorf("notes %v, want one note", d.Notes)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// activity keeps track of the calls in progress, so that the daemon can be
+// shut down once it has been idle for too long
+type activity struct {
+	mux      sync.Mutex
+	running  int
+	lastCall time.Time
+}
+
+func newActivity() *activity {
+	return &activity{lastCall: time.Now()}
+}
+
+func (a *activity) begin() {
+	a.mux.Lock()
+	a.running++
+	a.mux.Unlock()
+}
+
+func (a *activity) end() {
+	a.mux.Lock()
+	a.running--
+	a.lastCall = time.Now()
+	a.mux.Unlock()
+}
+
+// idleFor returns how long it's been since the last call completed, or 0
+// if a call is in progress
+func (a *activity) idleFor() time.Duration {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if a.running > 0 {
+		return 0
+	}
+	return time.Since(a.lastCall)
+}
+
+func (a *activity) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	a.begin()
+	defer a.end()
+	return handler(ctx, req)
+}
+
+func (a *activity) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	a.begin()
+	defer a.end()
+	return handler(srv, stream)
+}
@@ -0,0 +1,231 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/go-paths-helper"
+)
+
+// Limits of the sketches uploaded for remote builds, against the archives
+// filling the disk of the daemon host
+const (
+	maxRemoteArchiveSize = 64 << 20
+	maxRemoteSketchSize  = 256 << 20
+	maxRemoteSketchFiles = 10000
+)
+
+// extractArchive extracts the archive in the given format into dest. Only
+// regular files and folders are extracted: the entries pointing out of dest
+// and the archives expanding beyond the limits of remote builds are
+// refused.
+func extractArchive(format pb.RemoteBuildParams_ArchiveFormat, archive, dest *paths.Path) error {
+	x := &extractor{dest: dest, maxSize: maxRemoteSketchSize, maxFiles: maxRemoteSketchFiles}
+	switch format {
+	case pb.RemoteBuildParams_ZIP:
+		return x.zip(archive)
+	case pb.RemoteBuildParams_TAR, pb.RemoteBuildParams_TAR_GZ:
+		f, err := archive.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		var r io.Reader = f
+		if format == pb.RemoteBuildParams_TAR_GZ {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return err
+			}
+			defer gz.Close()
+			r = gz
+		}
+		return x.tar(r)
+	}
+	return fmt.Errorf("unknown archive format %s", format)
+}
+
+// extractor extracts the entries of an archive into dest, as long as they
+// take at most maxSize bytes and maxFiles files
+type extractor struct {
+	dest     *paths.Path
+	maxSize  int64
+	maxFiles int
+	size     int64
+	files    int
+}
+
+func (x *extractor) zip(archive *paths.Path) error {
+	r, err := zip.OpenReader(archive.String())
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, file := range r.File {
+		mode := file.Mode()
+		if mode.IsDir() {
+			if err := x.dir(file.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if !mode.IsRegular() {
+			continue
+		}
+		contents, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = x.file(file.Name, contents)
+		contents.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(header.Name)
+		case tar.TypeReg, tar.TypeRegA:
+			err = x.file(header.Name, tr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (x *extractor) dir(name string) error {
+	target, err := archiveEntryPath(x.dest, name)
+	if err != nil {
+		return err
+	}
+	return target.MkdirAll()
+}
+
+func (x *extractor) file(name string, contents io.Reader) error {
+	target, err := archiveEntryPath(x.dest, name)
+	if err != nil {
+		return err
+	}
+	if x.files++; x.files > x.maxFiles {
+		return fmt.Errorf("the archive contains more than %d files", x.maxFiles)
+	}
+	if err := target.Parent().MkdirAll(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target.String(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, io.LimitReader(contents, x.maxSize-x.size+1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if x.size += n; x.size > x.maxSize {
+		return fmt.Errorf("the archive expands to more than %d bytes", x.maxSize)
+	}
+	return nil
+}
+
+// archiveEntryPath returns the path of the archive entry name once
+// extracted into dest, refusing the names pointing out of it
+func archiveEntryPath(dest *paths.Path, name string) (*paths.Path, error) {
+	slashed := strings.Replace(name, "\\", "/", -1)
+	if path.IsAbs(slashed) || filepath.VolumeName(name) != "" {
+		return nil, fmt.Errorf("invalid archive entry %s: absolute path", name)
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return nil, fmt.Errorf("invalid archive entry %s: out of the archive", name)
+		}
+	}
+	return dest.Join(filepath.FromSlash(slashed)), nil
+}
+
+// remoteSketchLocation returns the sketch to build out of an archive
+// extracted into root: the given path, or the only folder at the root of
+// the archive, or the root itself. A folder is replaced by its only sketch
+// file when the file is not named after it, since archives may be
+// extracted into folders with any name.
+func remoteSketchLocation(root *paths.Path, sketchPath string) (*paths.Path, error) {
+	location := root
+	if sketchPath != "" {
+		var err error
+		if location, err = archiveEntryPath(root, sketchPath); err != nil {
+			return nil, err
+		}
+	} else if entries, err := root.ReadDir(); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		location = entries[0]
+	}
+	if !location.Exist() {
+		return nil, fmt.Errorf("%s not found in the archive", sketchPath)
+	}
+	if !location.IsDir() {
+		return location, nil
+	}
+	for _, ext := range []string{".ino", ".pde"} {
+		if location.Join(location.Base() + ext).Exist() {
+			return location, nil
+		}
+	}
+	entries, err := location.ReadDir()
+	if err != nil {
+		return nil, err
+	}
+	entries.FilterSuffix(".ino", ".pde")
+	if len(entries) != 1 {
+		return nil, fmt.Errorf("no sketch found in the archive")
+	}
+	return entries[0], nil
+}
@@ -0,0 +1,126 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arduino/go-paths-helper"
+)
+
+func TestArchiveEntryPath(t *testing.T) {
+	dest := paths.New("/tmp", "remote", "sketch")
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Blink/Blink.ino", filepath.Join("/tmp", "remote", "sketch", "Blink", "Blink.ino")},
+		{"Blink\\src\\util.h", filepath.Join("/tmp", "remote", "sketch", "Blink", "src", "util.h")},
+		{"./Blink.ino", filepath.Join("/tmp", "remote", "sketch", "Blink.ino")},
+		{"Blink/..foo/x.h", filepath.Join("/tmp", "remote", "sketch", "Blink", "..foo", "x.h")},
+		{"../Blink.ino", ""},
+		{"Blink/../../etc/passwd", ""},
+		{"Blink\\..\\..\\x", ""},
+		{"/etc/passwd", ""},
+		{"\\etc\\passwd", ""},
+	}
+	for _, test := range tests {
+		got, err := archiveEntryPath(dest, test.name)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("archiveEntryPath(%q) = %s, want an error", test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("archiveEntryPath(%q) failed: %s", test.name, err)
+		} else if got.String() != test.want {
+			t.Errorf("archiveEntryPath(%q) = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+// tarOf returns a tar archive with the given files
+func tarOf(t *testing.T, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for name, contents := range files {
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestExtractorLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{"fits", map[string]string{"Blink/Blink.ino": "void setup() {}", "Blink/a.h": "//"}, ""},
+		{"too many files", map[string]string{"a": "", "b": "", "c": ""}, "more than 2 files"},
+		{"too large", map[string]string{"Blink/Blink.ino": strings.Repeat("x", 33)}, "more than 32 bytes"},
+		{"too large in total", map[string]string{"a": strings.Repeat("x", 20), "b": strings.Repeat("x", 20)}, "more than 32 bytes"},
+		{"out of the archive", map[string]string{"../evil.ino": ""}, "out of the archive"},
+	}
+	for _, test := range tests {
+		dest, err := paths.MkTempDir("", "arduino-builder-extract")
+		if err != nil {
+			t.Fatal(err)
+		}
+		x := &extractor{dest: dest, maxSize: 32, maxFiles: 2}
+		err = x.tar(tarOf(t, test.files))
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: extraction failed: %s", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		case test.err == "":
+			for name, contents := range test.files {
+				data, err := ioutil.ReadFile(dest.Join(name).String())
+				if err != nil || string(data) != contents {
+					t.Errorf("%s: %s extracted as %q (%v), want %q", test.name, name, data, err, contents)
+				}
+			}
+		}
+		dest.RemoveAll()
+	}
+}
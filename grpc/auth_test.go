@@ -0,0 +1,201 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/arduino/go-paths-helper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenAuth(t *testing.T) {
+	auth := &tokenAuth{token: "secret"}
+	tests := []struct {
+		name           string
+		authorizations []string
+		want           codes.Code
+	}{
+		{"no metadata", nil, codes.Unauthenticated},
+		{"empty", []string{""}, codes.Unauthenticated},
+		{"wrong token", []string{"Bearer secrets"}, codes.Unauthenticated},
+		{"not bearer", []string{"Basic secret"}, codes.Unauthenticated},
+		{"no scheme", []string{"secret"}, codes.Unauthenticated},
+		{"valid", []string{"Bearer secret"}, codes.OK},
+		{"valid among others", []string{"Basic c2VjcmV0", "Bearer secret"}, codes.OK},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		if test.authorizations != nil {
+			md := metadata.MD{"authorization": test.authorizations}
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+		called := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return req, nil
+		}
+		_, err := auth.unaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+		if got := status.Code(err); got != test.want {
+			t.Errorf("%s: got %s (%v), want %s", test.name, got, err, test.want)
+		}
+		if called != (test.want == codes.OK) {
+			t.Errorf("%s: handler called: %v", test.name, called)
+		}
+	}
+}
+
+func TestReadToken(t *testing.T) {
+	tmp, err := paths.MkTempDir("", "arduino-builder-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.RemoveAll()
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{"token", "secret", "secret"},
+		{"trailing newline", "  secret\n", "secret"},
+		{"empty", " \n", ""},
+	}
+	for _, test := range tests {
+		file := tmp.Join(test.name)
+		if err := file.WriteFile([]byte(test.contents)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadToken(file.String())
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%s: ReadToken() = %q, want an error", test.name, got)
+			}
+		} else if err != nil || got != test.want {
+			t.Errorf("%s: ReadToken() = %q, %v, want %q", test.name, got, err, test.want)
+		}
+	}
+	if _, err := ReadToken(tmp.Join("missing").String()); err == nil {
+		t.Error("ReadToken() read a missing file")
+	}
+}
+
+// writeCertificate writes a self-signed certificate and its key into
+// folder, returning their files
+func writeCertificate(t *testing.T, folder *paths.Path) (*paths.Path, *paths.Path) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := folder.Join("cert.pem")
+	keyFile := folder.Join("key.pem")
+	if err := certFile.WriteFile(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyFile.WriteFile(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	tmp, err := paths.MkTempDir("", "arduino-builder-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.RemoveAll()
+	certFile, keyFile := writeCertificate(t, tmp)
+	notPEM := tmp.Join("ca.txt")
+	if err := notPEM.WriteFile([]byte("not a certificate")); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := tlsConfig(certFile.String(), keyFile.String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Certificates) != 1 || config.ClientAuth != tls.NoClientCert || config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("got %d certificates, client auth %v and min version %x, want 1, none and TLS 1.2", len(config.Certificates), config.ClientAuth, config.MinVersion)
+	}
+
+	config, err = tlsConfig(certFile.String(), keyFile.String(), certFile.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ClientAuth != tls.RequireAndVerifyClientCert || config.ClientCAs == nil {
+		t.Errorf("got client auth %v, want client certificates required", config.ClientAuth)
+	}
+
+	invalid := []struct {
+		name                            string
+		certFile, keyFile, clientCAFile string
+	}{
+		{"no key", certFile.String(), "", ""},
+		{"no certificate", "", keyFile.String(), ""},
+		{"key as certificate", keyFile.String(), keyFile.String(), ""},
+		{"missing client CA", certFile.String(), keyFile.String(), tmp.Join("missing.pem").String()},
+		{"client CA without certificates", certFile.String(), keyFile.String(), notPEM.String()},
+	}
+	for _, test := range invalid {
+		if _, err := tlsConfig(test.certFile, test.keyFile, test.clientCAFile); err == nil {
+			t.Errorf("%s: tlsConfig() succeeded, want an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"container/list"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arduino/arduino-cli/arduino/cores"
+	"github.com/arduino/arduino-cli/arduino/cores/packagemanager"
+	"github.com/arduino/arduino-cli/arduino/libraries/librariesmanager"
+	"github.com/arduino/arduino-cli/legacy/builder"
+	"github.com/arduino/arduino-cli/legacy/builder/i18n"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"github.com/arduino/go-paths-helper"
+)
+
+// hardwareCache keeps the hardware and the tools parsed from a set of
+// hardware and tools folders, so that requests targeting the same folders
+// don't have to load them again. The cached PackageManagers are shared
+// between concurrent builds and must be treated as read-only: that's why the
+// tools are loaded here once and for all, instead of letting each build
+// load them into the PackageManager, and why the platform keys are rewritten
+// and the build.board properties added here too, leaving nothing for the
+// builds to write when they run these steps again. The hardware doesn't
+// depend on the board, so the entries are keyed by the folders only.
+type hardwareCache struct {
+	mux     sync.Mutex
+	entries *lru
+	// hits and misses count the lookups, for the metrics
+	hits   int
+	misses int
+}
+
+type hardwareCacheEntry struct {
+	once     sync.Once
+	folders  paths.PathList
+	pm       *packagemanager.PackageManager
+	allTools []*cores.ToolRelease
+	err      error
+}
+
+// newHardwareCache creates a cache keeping the hardware of up to
+// maxEntries sets of folders
+func newHardwareCache(maxEntries int) *hardwareCache {
+	return &hardwareCache{entries: newLRU(maxEntries)}
+}
+
+// Get returns the PackageManager and the tools for the given hardware and
+// tools folders, loading them on first use.
+func (c *hardwareCache) Get(hardwareDirs, toolsDirs paths.PathList) (*packagemanager.PackageManager, []*cores.ToolRelease, error) {
+	key := cacheKey(hardwareDirs, toolsDirs)
+
+	c.mux.Lock()
+	var entry *hardwareCacheEntry
+	if value, ok := c.entries.get(key); ok {
+		c.hits++
+		entry = value.(*hardwareCacheEntry)
+	} else {
+		c.misses++
+		entry = &hardwareCacheEntry{}
+		entry.folders.AddAll(hardwareDirs)
+		entry.folders.AddAll(toolsDirs)
+		c.entries.add(key, entry)
+	}
+	c.mux.Unlock()
+
+	entry.once.Do(func() {
+		ctx := &types.Context{HardwareDirs: hardwareDirs, BuiltInToolsDirs: toolsDirs}
+		ctx.SetLogger(i18n.NoopLogger{})
+		commands := []types.Command{
+			&builder.HardwareLoader{},
+			&builder.PlatformKeysRewriteLoader{},
+			&builder.RewriteHardwareKeys{},
+			&builder.ToolsLoader{},
+			&builder.AddBuildBoardPropertyIfMissing{},
+		}
+		for _, command := range commands {
+			if err := command.Run(ctx); err != nil {
+				entry.err = err
+				return
+			}
+		}
+		entry.pm = ctx.PackageManager
+		entry.allTools = ctx.AllTools
+	})
+
+	if entry.err != nil {
+		// don't keep failures around, the folders may be fixed later
+		c.mux.Lock()
+		if value, ok := c.entries.peek(key); ok && value == entry {
+			c.entries.remove(key)
+		}
+		c.mux.Unlock()
+	}
+	return entry.pm, entry.allTools, entry.err
+}
+
+// DropContaining drops the hardware loaded from the folders containing path
+func (c *hardwareCache) DropContaining(path string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.entries.removeIf(func(value interface{}) bool {
+		return anyContains(value.(*hardwareCacheEntry).folders, path)
+	})
+}
+
+// Drop drops the hardware loaded from folders related to the given ones,
+// or all the hardware if none is given, returning the lists of folders of
+// the dropped entries
+func (c *hardwareCache) Drop(folders paths.PathList) []string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var dropped []string
+	for _, value := range c.entries.removeIf(func(value interface{}) bool {
+		return len(folders) == 0 || related(value.(*hardwareCacheEntry).folders, folders)
+	}) {
+		dropped = append(dropped, strings.Join(value.(*hardwareCacheEntry).folders.AsStrings(), ", "))
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// stats returns the lookups that found the hardware already loaded and the
+// ones that had to load it, along with the state of the LRU
+func (c *hardwareCache) stats() cacheStats {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return cacheStats{hits: c.hits, misses: c.misses, entries: c.entries.len(), evictions: c.entries.evictions}
+}
+
+// Clear drops all the cached hardware.
+func (c *hardwareCache) Clear() {
+	c.mux.Lock()
+	c.entries.clear()
+	c.mux.Unlock()
+}
+
+// oldest returns when the least recently used hardware was last used, if
+// it can be evicted: the most recent entry is always kept
+func (c *hardwareCache) oldest() (time.Time, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.entries.oldest()
+}
+
+func (c *hardwareCache) evictOldest() {
+	c.mux.Lock()
+	c.entries.evictOldest()
+	c.mux.Unlock()
+}
+
+// librariesCache keeps the LibrariesManagers built by previous builds, so
+// that the following builds using the same libraries folders and platform
+// don't have to scan the libraries again.
+type librariesCache struct {
+	mux     sync.Mutex
+	entries *lru
+	// hits and misses count the lookups, for the metrics
+	hits   int
+	misses int
+}
+
+type librariesCacheEntry struct {
+	// folders contains both the libraries folders and the platforms
+	// folders, since platforms can bundle libraries too
+	folders paths.PathList
+	lm      *librariesmanager.LibrariesManager
+}
+
+// newLibrariesCache creates a cache keeping the libraries of up to
+// maxEntries sets of folders and platforms
+func newLibrariesCache(maxEntries int) *librariesCache {
+	return &librariesCache{entries: newLRU(maxEntries)}
+}
+
+// librariesCacheKey returns the key of the LibrariesManager used by the
+// build in ctx, which depends on the libraries folders and on the platform
+// of the board.
+func librariesCacheKey(ctx *types.Context) string {
+	platform := ctx.FQBN.Package + ":" + ctx.FQBN.PlatformArch
+	return cacheKey(ctx.HardwareDirs, ctx.BuiltInLibrariesDirs, ctx.OtherLibrariesDirs) + "\n\n" + platform
+}
+
+// Get returns the LibrariesManager for the build in ctx, if any
+func (c *librariesCache) Get(ctx *types.Context) *librariesmanager.LibrariesManager {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if value, ok := c.entries.get(librariesCacheKey(ctx)); ok {
+		c.hits++
+		return value.(*librariesCacheEntry).lm
+	}
+	c.misses++
+	return nil
+}
+
+// Store keeps the LibrariesManager used by the build in ctx
+func (c *librariesCache) Store(ctx *types.Context) {
+	if ctx.LibrariesManager == nil || ctx.TargetPlatform == nil {
+		return
+	}
+	entry := &librariesCacheEntry{lm: ctx.LibrariesManager}
+	entry.folders.AddAll(ctx.BuiltInLibrariesDirs)
+	entry.folders.AddAll(ctx.OtherLibrariesDirs)
+	entry.folders.Add(ctx.TargetPlatform.InstallDir)
+	if ctx.ActualPlatform != nil {
+		entry.folders.Add(ctx.ActualPlatform.InstallDir)
+	}
+
+	c.mux.Lock()
+	c.entries.add(librariesCacheKey(ctx), entry)
+	c.mux.Unlock()
+}
+
+// DropContaining drops the libraries loaded from the folders containing path
+func (c *librariesCache) DropContaining(path string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.entries.removeIf(func(value interface{}) bool {
+		return anyContains(value.(*librariesCacheEntry).folders, path)
+	})
+}
+
+// Drop drops the libraries loaded from folders related to the given ones,
+// or all the libraries if none is given, returning the lists of folders of
+// the dropped entries
+func (c *librariesCache) Drop(folders paths.PathList) []string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var dropped []string
+	for _, value := range c.entries.removeIf(func(value interface{}) bool {
+		return len(folders) == 0 || related(value.(*librariesCacheEntry).folders, folders)
+	}) {
+		dropped = append(dropped, strings.Join(value.(*librariesCacheEntry).folders.AsStrings(), ", "))
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// stats returns the lookups that found the libraries already loaded and
+// the ones that did not, along with the state of the LRU
+func (c *librariesCache) stats() cacheStats {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return cacheStats{hits: c.hits, misses: c.misses, entries: c.entries.len(), evictions: c.entries.evictions}
+}
+
+// Clear drops all the cached libraries.
+func (c *librariesCache) Clear() {
+	c.mux.Lock()
+	c.entries.clear()
+	c.mux.Unlock()
+}
+
+// oldest returns when the least recently used libraries were last used, if
+// they can be evicted: the most recent entry is always kept
+func (c *librariesCache) oldest() (time.Time, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.entries.oldest()
+}
+
+func (c *librariesCache) evictOldest() {
+	c.mux.Lock()
+	c.entries.evictOldest()
+	c.mux.Unlock()
+}
+
+// trimCaches evicts the least recently used hardware and libraries, the
+// oldest first, while the heap of the daemon is larger than maxHeap bytes.
+// The cached data can't be measured on its own, so the whole heap is
+// checked, collecting the garbage after each eviction.
+func trimCaches(maxHeap uint64, hardware *hardwareCache, libraries *librariesCache) {
+	var stats runtime.MemStats
+	for {
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc <= maxHeap {
+			return
+		}
+		hardwareUsed, hardwareEvictable := hardware.oldest()
+		librariesUsed, librariesEvictable := libraries.oldest()
+		switch {
+		case hardwareEvictable && (!librariesEvictable || hardwareUsed.Before(librariesUsed)):
+			hardware.evictOldest()
+		case librariesEvictable:
+			libraries.evictOldest()
+		default:
+			return
+		}
+		runtime.GC()
+	}
+}
+
+type cacheStats struct {
+	hits      int
+	misses    int
+	entries   int
+	evictions int
+}
+
+// lru keeps the entries of a cache in the order they were used, evicting
+// the least recently used ones beyond maxEntries. It is not safe for
+// concurrent use, the caches lock around it.
+type lru struct {
+	maxEntries int
+	// order has the most recently used entries at the front
+	order *list.List
+	items map[string]*list.Element
+	// evictions counts the entries dropped to make room, for the metrics
+	evictions int
+}
+
+type lruItem struct {
+	key   string
+	value interface{}
+	used  time.Time
+}
+
+func newLRU(maxEntries int) *lru {
+	return &lru{maxEntries: maxEntries, order: list.New(), items: map[string]*list.Element{}}
+}
+
+// get returns the value stored with key, marking it as just used
+func (l *lru) get(key string) (interface{}, bool) {
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	elem.Value.(*lruItem).used = time.Now()
+	l.order.MoveToFront(elem)
+	return elem.Value.(*lruItem).value, true
+}
+
+// peek returns the value stored with key, without marking it as used
+func (l *lru) peek(key string) (interface{}, bool) {
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	return elem.Value.(*lruItem).value, true
+}
+
+// add stores value with key, evicting the least recently used entries if
+// there are more than maxEntries
+func (l *lru) add(key string, value interface{}) {
+	item := &lruItem{key: key, value: value, used: time.Now()}
+	if elem, ok := l.items[key]; ok {
+		elem.Value = item
+		l.order.MoveToFront(elem)
+	} else {
+		l.items[key] = l.order.PushFront(item)
+	}
+	for l.maxEntries > 0 && l.order.Len() > l.maxEntries {
+		l.evictOldest()
+	}
+}
+
+func (l *lru) remove(key string) {
+	if elem, ok := l.items[key]; ok {
+		l.order.Remove(elem)
+		delete(l.items, key)
+	}
+}
+
+// removeIf removes the entries whose value matches, returning their values
+func (l *lru) removeIf(match func(value interface{}) bool) []interface{} {
+	var removed []interface{}
+	for key, elem := range l.items {
+		if value := elem.Value.(*lruItem).value; match(value) {
+			l.order.Remove(elem)
+			delete(l.items, key)
+			removed = append(removed, value)
+		}
+	}
+	return removed
+}
+
+func (l *lru) clear() {
+	l.order.Init()
+	l.items = map[string]*list.Element{}
+}
+
+func (l *lru) len() int {
+	return l.order.Len()
+}
+
+// oldest returns when the least recently used entry was last used, unless
+// it is the only one
+func (l *lru) oldest() (time.Time, bool) {
+	if l.order.Len() < 2 {
+		return time.Time{}, false
+	}
+	return l.order.Back().Value.(*lruItem).used, true
+}
+
+func (l *lru) evictOldest() {
+	if elem := l.order.Back(); elem != nil {
+		l.remove(elem.Value.(*lruItem).key)
+		l.evictions++
+	}
+}
+
+func cacheKey(lists ...paths.PathList) string {
+	var key []string
+	for _, list := range lists {
+		key = append(key, strings.Join(list.AsStrings(), "\n"))
+	}
+	return strings.Join(key, "\n\n")
+}
+
+// related returns true if one of entryFolders is one of folders, or is
+// inside or contains one of them
+func related(entryFolders, folders paths.PathList) bool {
+	for _, folder := range folders {
+		if anyContains(entryFolders, folder.String()) {
+			return true
+		}
+	}
+	for _, folder := range entryFolders {
+		if folder != nil && anyContains(folders, folder.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// affectsHardware returns true if the changed path may change the hardware
+// and tools loaded from the folder it is in: the hardware loader reads only
+// the .txt and .json files, along with the layout of the folders. A path
+// that is gone may have been a folder.
+func affectsHardware(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txt", ".json":
+		return true
+	}
+	info, err := os.Stat(path)
+	return err != nil || info.IsDir()
+}
+
+// anyContains returns true if path is one of folders or is inside one of them
+func anyContains(folders paths.PathList, path string) bool {
+	for _, folder := range folders {
+		if folder == nil {
+			continue
+		}
+		dir := folder.String()
+		if path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(os.PathSeparator))+string(os.PathSeparator)) {
+			return true
+		}
+	}
+	return false
+}
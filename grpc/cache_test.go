@@ -0,0 +1,103 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// lruKeys returns the keys in l, most recently used first
+func lruKeys(l *lru) string {
+	var keys []string
+	for elem := l.order.Front(); elem != nil; elem = elem.Next() {
+		keys = append(keys, elem.Value.(*lruItem).key)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestLRU(t *testing.T) {
+	l := newLRU(3)
+	l.add("a", 1)
+	l.add("b", 2)
+	l.add("c", 3)
+	if got := lruKeys(l); got != "c,b,a" {
+		t.Fatalf("keys = %s, want c,b,a", got)
+	}
+
+	// get marks the entry as used, peek doesn't
+	if value, ok := l.get("a"); !ok || value != 1 {
+		t.Errorf("get(a) = %v, %v, want 1, true", value, ok)
+	}
+	if value, ok := l.peek("b"); !ok || value != 2 {
+		t.Errorf("peek(b) = %v, %v, want 2, true", value, ok)
+	}
+	if got := lruKeys(l); got != "a,c,b" {
+		t.Errorf("keys = %s, want a,c,b", got)
+	}
+
+	// the least recently used entry makes room for the new ones
+	l.add("d", 4)
+	if _, ok := l.get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	if got := lruKeys(l); got != "d,a,c" || l.evictions != 1 {
+		t.Errorf("keys = %s with %d evictions, want d,a,c with 1", got, l.evictions)
+	}
+
+	// adding an existing key replaces its value without evicting anything
+	l.add("c", 30)
+	if value, _ := l.peek("c"); value != 30 || lruKeys(l) != "c,d,a" || l.evictions != 1 {
+		t.Errorf("after replacing c: value %v, keys %s, %d evictions", value, lruKeys(l), l.evictions)
+	}
+
+	removed := l.removeIf(func(value interface{}) bool { return value.(int) >= 4 })
+	sort.Slice(removed, func(i, j int) bool { return removed[i].(int) < removed[j].(int) })
+	if len(removed) != 2 || removed[0] != 4 || removed[1] != 30 || lruKeys(l) != "a" {
+		t.Errorf("removeIf removed %v leaving %s, want [4 30] leaving a", removed, lruKeys(l))
+	}
+	if _, ok := l.oldest(); ok {
+		t.Error("oldest() of a single entry should not be reported")
+	}
+	l.clear()
+	if l.len() != 0 {
+		t.Errorf("len() = %d after clear, want 0", l.len())
+	}
+}
+
+func TestLRUUnlimited(t *testing.T) {
+	l := newLRU(0)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		l.add(key, key)
+	}
+	if l.len() != 4 || l.evictions != 0 {
+		t.Errorf("len() = %d with %d evictions, want 4 with none", l.len(), l.evictions)
+	}
+}
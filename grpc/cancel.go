@@ -0,0 +1,185 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"github.com/arduino/go-paths-helper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/status"
+)
+
+// runCancelable runs a build step on buildCtx, stopping it as soon as ctx is
+// canceled or its deadline expires. In that case the compiler processes
+// spawned by the build are killed, the partial outputs are removed from the
+// build path, so that the next incremental build doesn't pick them up, and
+// the gRPC status matching the ctx error is returned.
+func runCancelable(ctx context.Context, buildCtx *types.Context, run func(*types.Context) error) error {
+	start := time.Now()
+	buildPath := buildCtx.BuildPath
+
+	done := make(chan error, 1)
+	go func() {
+		done <- run(buildCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	// The builder doesn't know about ctx, so it may go on spawning new
+	// processes until it notices that the previous ones failed: keep
+	// killing them until it gives up.
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for stopped := false; !stopped; {
+		if buildPath != nil {
+			killBuildProcesses(buildPath.String())
+		}
+		select {
+		case <-done:
+			stopped = true
+		case <-ticker.C:
+		}
+	}
+
+	if buildPath != nil {
+		removePartialOutputs(buildPath, start)
+	}
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+// defaultBuildPath returns the build path the builder uses for the given
+// sketch when none is given: a folder named after the MD5 of the sketch path
+// in the temporary folder, reused by the following builds
+func defaultBuildPath(sketchLocation *paths.Path) *paths.Path {
+	if sketchLocation == nil {
+		return nil
+	}
+	sum := md5.Sum([]byte(sketchLocation.String()))
+	return paths.TempDir().Join("arduino-sketch-" + strings.ToUpper(hex.EncodeToString(sum[:])))
+}
+
+// removePartialOutputs removes the object files and archives written into
+// buildPath since the given time, since they may have been truncated when
+// their compiler was killed.
+func removePartialOutputs(buildPath *paths.Path, since time.Time) {
+	// leave some room for filesystems with a coarse mtime resolution
+	since = since.Add(-2 * time.Second)
+
+	filepath.Walk(buildPath.String(), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".o", ".d", ".a":
+			if info.ModTime().After(since) {
+				os.Remove(path)
+			}
+		}
+		return nil
+	})
+}
+
+// process is a process running on the machine
+type process struct {
+	pid, ppid int
+	cmdline   string
+}
+
+// killBuildProcesses kills the child processes whose command line refers to
+// buildPath, together with all their descendants. Every compile and link
+// recipe writes into the build path, so this singles out the processes of a
+// build even when other builds are running at the same time. The builder
+// spawns them itself, so they can't be put in a process group of their own.
+func killBuildProcesses(buildPath string) {
+	processes, err := listProcesses()
+	if err != nil {
+		return
+	}
+	self := os.Getpid()
+	children := map[int][]int{}
+	var targets []int
+	for _, p := range processes {
+		children[p.ppid] = append(children[p.ppid], p.pid)
+		if p.ppid == self && refersTo(p.cmdline, buildPath) {
+			targets = append(targets, p.pid)
+		}
+	}
+	for _, pid := range targets {
+		killTree(pid, children)
+	}
+}
+
+// childProcesses returns the number of processes the daemon is running,
+// which are the compilers and the other tools run by the builds
+func childProcesses() (int, bool) {
+	processes, err := listProcesses()
+	if err != nil {
+		return 0, false
+	}
+	self := os.Getpid()
+	count := 0
+	for _, p := range processes {
+		if p.ppid == self {
+			count++
+		}
+	}
+	return count, true
+}
+
+// refersTo tells if cmdline refers to path or to a file inside it, and not
+// just to a sibling sharing its prefix, like /tmp/build10 for /tmp/build1.
+// The arguments in cmdline may be separated by spaces or NULs.
+func refersTo(cmdline, path string) bool {
+	path = strings.TrimRight(path, `/\`)
+	if path == "" {
+		return false
+	}
+	for start := 0; ; {
+		i := strings.Index(cmdline[start:], path)
+		if i == -1 {
+			return false
+		}
+		end := start + i + len(path)
+		if end == len(cmdline) || strings.IndexByte("/\\\x00 \"'", cmdline[end]) != -1 {
+			return true
+		}
+		start += i + 1
+	}
+}
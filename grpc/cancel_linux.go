@@ -0,0 +1,78 @@
+//go:build linux
+// +build linux
+
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package grpc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listProcesses lists the running processes out of /proc, the arguments of
+// their command lines are separated by NULs
+func listProcesses() ([]process, error) {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var res []process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		ppid, err := parentPid(pid)
+		if err != nil {
+			continue
+		}
+		cmdline, _ := ioutil.ReadFile("/proc/" + entry.Name() + "/cmdline")
+		res = append(res, process{pid: pid, ppid: ppid, cmdline: string(cmdline)})
+	}
+	return res, nil
+}
+
+func parentPid(pid int) (int, error) {
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, err
+	}
+	// the process name may contain spaces and parentheses, so skip it
+	// entirely: the parent pid is the second field after it
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	if len(fields) < 2 {
+		return 0, syscall.EINVAL
+	}
+	return strconv.Atoi(fields[1])
+}
@@ -0,0 +1,74 @@
+//go:build !windows && !linux
+// +build !windows,!linux
+
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package grpc
+
+import (
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// listProcesses lists the running processes with ps, the arguments of their
+// command lines are separated by spaces
+func listProcesses() ([]process, error) {
+	var output bytes.Buffer
+	cmd := exec.Command("ps", "-axww", "-o", "pid=", "-o", "ppid=", "-o", "command=")
+	cmd.Stdout = &output
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	// ps is a child of the daemon as well, leave it out
+	ps := cmd.Process.Pid
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	var res []process
+	for _, line := range strings.Split(output.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil || pid == ps {
+			continue
+		}
+		ppid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		res = append(res, process{pid: pid, ppid: ppid, cmdline: strings.Join(fields[2:], " ")})
+	}
+	return res, nil
+}
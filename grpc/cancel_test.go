@@ -0,0 +1,53 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import "testing"
+
+func TestRefersTo(t *testing.T) {
+	tests := []struct {
+		cmdline, path string
+		want          bool
+	}{
+		{"gcc\x00-c\x00-o\x00/tmp/build1/sketch/Blink.ino.cpp.o", "/tmp/build1", true},
+		{"gcc\x00-o\x00/tmp/build10/sketch/Blink.ino.cpp.o", "/tmp/build1", false},
+		{"gcc -o /tmp/build10/core.a /tmp/build1/core/main.cpp.o", "/tmp/build1", true},
+		{"ar rcs /tmp/build1", "/tmp/build1", true},
+		{"size \"/tmp/build1\"", "/tmp/build1/", true},
+		{`gcc -o C:\build1\sketch\x.o`, `C:\build1`, true},
+		{`gcc -o C:\build10\sketch\x.o`, `C:\build1`, false},
+		{"gcc -o /tmp/build1x", "/tmp/build1", false},
+		{"gcc", "", false},
+	}
+	for _, test := range tests {
+		if got := refersTo(test.cmdline, test.path); got != test.want {
+			t.Errorf("refersTo(%q, %q) = %v, want %v", test.cmdline, test.path, got, test.want)
+		}
+	}
+}
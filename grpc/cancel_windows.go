@@ -0,0 +1,145 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package grpc
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	processTerminate               = 0x0001
+	processSetQuota                = 0x0100
+	processQueryLimitedInformation = 0x1000
+
+	processCommandLineInformation = 60
+	statusInfoLengthMismatch      = 0xc0000004
+)
+
+var (
+	kernel32                      = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW          = kernel32.NewProc("CreateJobObjectW")
+	procAssignProcessToJobObject  = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject        = kernel32.NewProc("TerminateJobObject")
+	ntdll                         = syscall.NewLazyDLL("ntdll.dll")
+	procNtQueryInformationProcess = ntdll.NewProc("NtQueryInformationProcess")
+)
+
+// listProcesses lists the running processes with a Toolhelp snapshot, the
+// command lines are read only for the children of the daemon, since those
+// are the only ones killBuildProcesses looks at
+func listProcesses() ([]process, error) {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	self := syscall.Getpid()
+	var res []process
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = syscall.Process32First(snapshot, &entry); err == nil; err = syscall.Process32Next(snapshot, &entry) {
+		p := process{pid: int(entry.ProcessID), ppid: int(entry.ParentProcessID)}
+		if p.ppid == self {
+			p.cmdline = commandLine(entry.ProcessID)
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// unicodeString is the UNICODE_STRING returned by NtQueryInformationProcess
+type unicodeString struct {
+	Length        uint16
+	MaximumLength uint16
+	Buffer        *uint16
+}
+
+// commandLine returns the command line of the process with the given pid,
+// or an empty string if it can't be read (before Windows 8.1)
+func commandLine(pid uint32) string {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, pid)
+	if err != nil {
+		return ""
+	}
+	defer syscall.CloseHandle(handle)
+
+	buf := make([]byte, 1024)
+	for {
+		var size uint32
+		status, _, _ := procNtQueryInformationProcess.Call(uintptr(handle), processCommandLineInformation,
+			uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), uintptr(unsafe.Pointer(&size)))
+		if status == statusInfoLengthMismatch && int(size) > len(buf) {
+			buf = make([]byte, size)
+			continue
+		}
+		if status != 0 {
+			return ""
+		}
+		break
+	}
+	str := (*unicodeString)(unsafe.Pointer(&buf[0]))
+	if str.Buffer == nil || str.Length == 0 {
+		return ""
+	}
+	chars := (*[1 << 20]uint16)(unsafe.Pointer(str.Buffer))[: str.Length/2 : str.Length/2]
+	return syscall.UTF16ToString(chars)
+}
+
+// killTree kills pid and its descendants at once by putting them in a job
+// object: the processes they spawn meanwhile join the job too
+func killTree(pid int, children map[int][]int) {
+	job, _, _ := procCreateJobObjectW.Call(0, 0)
+	if job != 0 {
+		defer syscall.CloseHandle(syscall.Handle(job))
+	}
+	var kill func(pid int)
+	kill = func(pid int) {
+		handle, err := syscall.OpenProcess(processTerminate|processSetQuota, false, uint32(pid))
+		if err != nil {
+			return
+		}
+		defer syscall.CloseHandle(handle)
+		if job == 0 {
+			syscall.TerminateProcess(handle, 1)
+		} else if ok, _, _ := procAssignProcessToJobObject.Call(job, uintptr(handle)); ok == 0 {
+			// already in a job that can't be nested, before Windows 8
+			syscall.TerminateProcess(handle, 1)
+		}
+		for _, child := range children[pid] {
+			kill(child)
+		}
+	}
+	kill(pid)
+	if job != 0 {
+		procTerminateJobObject.Call(job, 1)
+	}
+}
@@ -0,0 +1,295 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+// Package client is a client of the builder daemon, as started with the
+// -daemon command line flag.
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// Options are the settings of the connection to the daemon
+type Options struct {
+	// TLS enables TLS, it is implied by the other TLS settings
+	TLS bool
+	// TLSCAFile contains the CAs the certificate of the daemon is checked
+	// against, defaults to the CAs of the system
+	TLSCAFile string
+	// TLSCertFile and TLSKeyFile are the certificate presented to the
+	// daemons requiring one
+	TLSCertFile string
+	TLSKeyFile  string
+	// Token is sent as a bearer token with every call, for the daemons
+	// started with a token file
+	Token string
+	// Retries is the number of times the calls failing because the daemon
+	// is unavailable, e.g. still starting, are retried. Defaults to 3,
+	// negative values disable retries.
+	Retries int
+	// RetryDelay is the time waited before each retry, defaults to 500ms
+	RetryDelay time.Duration
+}
+
+// Client calls a builder daemon. It is safe for concurrent use.
+type Client struct {
+	conn    *grpc.ClientConn
+	builder pb.BuilderClient
+	opts    Options
+}
+
+// Dial creates a client of the daemon at address, either "host:port" or
+// "unix:/path/to/socket". The connection is established lazily, so a daemon
+// that can't be reached makes the calls fail rather than Dial.
+func Dial(address string, opts Options) (*Client, error) {
+	if opts.Retries == 0 {
+		opts.Retries = 3
+	}
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = 500 * time.Millisecond
+	}
+
+	var dialOpts []grpc.DialOption
+	secure := opts.TLS || opts.TLSCAFile != "" || opts.TLSCertFile != "" || opts.TLSKeyFile != ""
+	if secure {
+		config, err := tlsConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+	if opts.Token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenCredentials{token: opts.Token, secure: secure}))
+	}
+
+	target := address
+	if strings.HasPrefix(address, "unix:") {
+		socket := strings.TrimPrefix(address, "unix:")
+		target = "passthrough:///localhost"
+		dialOpts = append(dialOpts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}))
+	}
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %s", address, err)
+	}
+	return &Client{conn: conn, builder: pb.NewBuilderClient(conn), opts: opts}, nil
+}
+
+func tlsConfig(opts Options) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.TLSCAFile != "" {
+		data, err := ioutil.ReadFile(opts.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading TLS CA: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("loading TLS CA: no certificates found in %s", opts.TLSCAFile)
+		}
+		config.RootCAs = pool
+	}
+	if opts.TLSCertFile != "" || opts.TLSKeyFile != "" {
+		if opts.TLSCertFile == "" || opts.TLSKeyFile == "" {
+			return nil, errors.New("both a TLS certificate and a key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading TLS certificate: %s", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// tokenCredentials sends the token in the "authorization" metadata
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (c tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+// RequireTransportSecurity lets the token go over insecure connections,
+// for Unix sockets and local daemons
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
+
+// Close closes the connection to the daemon
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Builder returns the underlying gRPC client, for the calls without a
+// helper
+func (c *Client) Builder() pb.BuilderClient {
+	return c.builder
+}
+
+// shouldRetry returns true, once the retry delay has elapsed, if the call
+// failed with err at the given attempt can be retried
+func (c *Client) shouldRetry(ctx context.Context, err error, attempt int) bool {
+	if status.Code(err) != codes.Unavailable || attempt >= c.opts.Retries {
+		return false
+	}
+	select {
+	case <-time.After(c.opts.RetryDelay):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retry runs the unary call, retrying it while the daemon is unavailable
+func (c *Client) retry(ctx context.Context, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if !c.shouldRetry(ctx, err, attempt) {
+			return err
+		}
+	}
+}
+
+// Build is a build run by the daemon
+type Build struct {
+	// Events receives the events of the build and is closed once the build
+	// ends. It must be drained, or Wait called, for the build to progress.
+	Events <-chan *pb.BuildEvent
+	result *pb.BuildResult
+	err    error
+}
+
+// Wait discards the events not received yet and returns the result of the
+// build. An error is returned only if the build could not start or was
+// canceled: a failed build is reported by the result.
+func (b *Build) Wait() (*pb.BuildResult, error) {
+	for range b.Events {
+	}
+	if b.err == nil && b.result == nil {
+		return nil, errors.New("the daemon ended the build without a result")
+	}
+	return b.result, b.err
+}
+
+// Build starts building the sketch. Canceling ctx cancels the build.
+func (c *Client) Build(ctx context.Context, params *pb.BuildParamsV2) *Build {
+	events := make(chan *pb.BuildEvent, 64)
+	b := &Build{Events: events}
+	go func() {
+		defer close(events)
+		for attempt := 0; ; attempt++ {
+			started, err := c.build(ctx, params, b, events)
+			// the events already sent can't be taken back
+			if started || !c.shouldRetry(ctx, err, attempt) {
+				b.err = err
+				return
+			}
+		}
+	}()
+	return b
+}
+
+func (c *Client) build(ctx context.Context, params *pb.BuildParamsV2, b *Build, events chan<- *pb.BuildEvent) (bool, error) {
+	stream, err := c.builder.BuildV2(ctx, params)
+	if err != nil {
+		return false, err
+	}
+	started := false
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return started, nil
+		}
+		if err != nil {
+			return started, err
+		}
+		started = true
+		if result := event.GetResult(); result != nil {
+			b.result = result
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			// the caller may have stopped receiving the events
+			return started, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// Autocomplete returns the completions at params.CodeCompleteAt
+func (c *Client) Autocomplete(ctx context.Context, params *pb.BuildParamsV2) (*pb.CompletionList, error) {
+	var res *pb.CompletionList
+	err := c.retry(ctx, func() (err error) {
+		res, err = c.builder.AutocompleteV2(ctx, params)
+		return err
+	})
+	return res, err
+}
+
+// Preprocess returns the preprocessed source of the sketch
+func (c *Client) Preprocess(ctx context.Context, params *pb.BuildParamsV2) (*pb.PreprocessResult, error) {
+	var res *pb.PreprocessResult
+	err := c.retry(ctx, func() (err error) {
+		res, err = c.builder.Preprocess(ctx, params)
+		return err
+	})
+	return res, err
+}
+
+// DumpProperties returns the build properties of the sketch
+func (c *Client) DumpProperties(ctx context.Context, params *pb.BuildParamsV2) (*pb.BuildProperties, error) {
+	var res *pb.BuildProperties
+	err := c.retry(ctx, func() (err error) {
+		res, err = c.builder.DumpProperties(ctx, params)
+		return err
+	})
+	return res, err
+}
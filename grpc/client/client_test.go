@@ -0,0 +1,168 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package client
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeBuilder answers the calls of the client. The unary calls fail with
+// the given failures, in order, then succeed; each BuildV2 call gets the
+// next of the given streams.
+type fakeBuilder struct {
+	pb.BuilderClient
+	failures []error
+	streams  []*fakeBuildStream
+	calls    int
+}
+
+func (b *fakeBuilder) AutocompleteV2(ctx context.Context, in *pb.BuildParamsV2, opts ...grpc.CallOption) (*pb.CompletionList, error) {
+	b.calls++
+	if b.calls <= len(b.failures) {
+		return nil, b.failures[b.calls-1]
+	}
+	return &pb.CompletionList{Output: "[]"}, nil
+}
+
+func (b *fakeBuilder) BuildV2(ctx context.Context, in *pb.BuildParamsV2, opts ...grpc.CallOption) (pb.Builder_BuildV2Client, error) {
+	b.calls++
+	return b.streams[b.calls-1], nil
+}
+
+// fakeBuildStream sends the given events, then ends with err, if any
+type fakeBuildStream struct {
+	grpc.ClientStream
+	events []*pb.BuildEvent
+	err    error
+}
+
+func (s *fakeBuildStream) Recv() (*pb.BuildEvent, error) {
+	if len(s.events) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	event := s.events[0]
+	s.events = s.events[1:]
+	return event, nil
+}
+
+func newFakeClient(builder *fakeBuilder, retries int) *Client {
+	return &Client{builder: builder, opts: Options{Retries: retries, RetryDelay: time.Millisecond}}
+}
+
+func TestRetry(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	tests := []struct {
+		name      string
+		retries   int
+		failures  []error
+		wantCode  codes.Code
+		wantCalls int
+	}{
+		{"available", 3, nil, codes.OK, 1},
+		{"available after retries", 3, []error{unavailable, unavailable}, codes.OK, 3},
+		{"unavailable", 3, []error{unavailable, unavailable, unavailable, unavailable}, codes.Unavailable, 4},
+		{"other error", 3, []error{status.Error(codes.InvalidArgument, "invalid fqbn")}, codes.InvalidArgument, 1},
+		{"retries disabled", -1, []error{unavailable}, codes.Unavailable, 1},
+	}
+	for _, test := range tests {
+		builder := &fakeBuilder{failures: test.failures}
+		_, err := newFakeClient(builder, test.retries).Autocomplete(context.Background(), &pb.BuildParamsV2{})
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%s: got %s (%v), want %s", test.name, got, err, test.wantCode)
+		}
+		if builder.calls != test.wantCalls {
+			t.Errorf("%s: called %d times, want %d", test.name, builder.calls, test.wantCalls)
+		}
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	builder := &fakeBuilder{failures: []error{status.Error(codes.Unavailable, "connection refused")}}
+	c := newFakeClient(builder, 3)
+	c.opts.RetryDelay = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Autocomplete(ctx, &pb.BuildParamsV2{}); status.Code(err) != codes.Unavailable || builder.calls != 1 {
+		t.Errorf("got %v after %d calls, want the first error without retries", err, builder.calls)
+	}
+}
+
+func TestBuildRetry(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	log := &pb.BuildEvent{Event: &pb.BuildEvent_Log{Log: &pb.LogRecord{Message: "Compiling sketch..."}}}
+	result := &pb.BuildEvent{Event: &pb.BuildEvent_Result{Result: &pb.BuildResult{Success: true}}}
+	tests := []struct {
+		name       string
+		streams    []*fakeBuildStream
+		wantCode   codes.Code
+		wantEvents int
+		wantCalls  int
+	}{
+		{
+			"retried before the first event",
+			[]*fakeBuildStream{{err: unavailable}, {events: []*pb.BuildEvent{log, result}}},
+			codes.OK, 2, 2,
+		},
+		{
+			// the events already received can't be taken back
+			"not retried once started",
+			[]*fakeBuildStream{{events: []*pb.BuildEvent{log}, err: unavailable}, {events: []*pb.BuildEvent{log, result}}},
+			codes.Unavailable, 1, 1,
+		},
+	}
+	for _, test := range tests {
+		builder := &fakeBuilder{streams: test.streams}
+		build := newFakeClient(builder, 3).Build(context.Background(), &pb.BuildParamsV2{})
+		events := 0
+		for range build.Events {
+			events++
+		}
+		res, err := build.Wait()
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%s: got %s (%v), want %s", test.name, got, err, test.wantCode)
+		}
+		if err == nil && !res.GetSuccess() {
+			t.Errorf("%s: got result %v, want a successful build", test.name, res)
+		}
+		if events != test.wantEvents || builder.calls != test.wantCalls {
+			t.Errorf("%s: got %d events in %d calls, want %d in %d", test.name, events, builder.calls, test.wantEvents, test.wantCalls)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/go-paths-helper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// buildOptionsFile is written by the builder in every build path, it tells
+// a build path from any other folder
+const buildOptionsFile = "build.options.json"
+
+func (s *builderServer) DropCacheV2(ctx context.Context, args *pb.DropCacheParams) (*pb.DropCacheResult, error) {
+	scopes := args.Scopes
+	if len(scopes) == 0 {
+		scopes = []pb.DropCacheParams_Scope{pb.DropCacheParams_HARDWARE, pb.DropCacheParams_LIBRARIES}
+	}
+	// check everything first, not to drop only some of the scopes
+	buildCachePath := paths.New(args.BuildCachePath)
+	buildPath := paths.New(args.BuildPath)
+	for _, scope := range scopes {
+		switch scope {
+		case pb.DropCacheParams_HARDWARE, pb.DropCacheParams_LIBRARIES:
+		case pb.DropCacheParams_CORES:
+			if buildCachePath == nil || !buildCachePath.IsAbs() {
+				return nil, status.Error(codes.InvalidArgument, "dropping the cores requires an absolute build cache path")
+			}
+		case pb.DropCacheParams_BUILD_PATH:
+			if buildPath == nil || !buildPath.IsAbs() {
+				return nil, status.Error(codes.InvalidArgument, "dropping a build path requires an absolute build path")
+			}
+			if !buildPath.Join(buildOptionsFile).Exist() {
+				return nil, status.Errorf(codes.FailedPrecondition, "%s is not a build path: %s not found", buildPath, buildOptionsFile)
+			}
+			known := false
+			s.scheduler.exclusive(func() { known = s.scheduler.knownBuildPath(buildPath.String()) })
+			if !known {
+				return nil, status.Errorf(codes.FailedPrecondition, "%s is not a build path of this daemon", buildPath)
+			}
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown scope %s", scope)
+		}
+	}
+
+	folders := paths.NewPathList(args.Folders...)
+	res := &pb.DropCacheResult{}
+	for _, scope := range scopes {
+		var dropped []*pb.DroppedCache
+		var err error
+		switch scope {
+		case pb.DropCacheParams_HARDWARE:
+			for _, description := range s.hardware.Drop(folders) {
+				dropped = append(dropped, &pb.DroppedCache{Scope: scope, Description: description})
+			}
+		case pb.DropCacheParams_LIBRARIES:
+			for _, description := range s.libraries.Drop(folders) {
+				dropped = append(dropped, &pb.DroppedCache{Scope: scope, Description: description})
+			}
+		case pb.DropCacheParams_CORES, pb.DropCacheParams_BUILD_PATH:
+			path := buildCachePath
+			if scope == pb.DropCacheParams_BUILD_PATH {
+				path = buildPath
+			}
+			// no job may start using the files while they are deleted
+			s.scheduler.exclusive(func() {
+				if s.scheduler.inUse(path.String()) {
+					res.Skipped = append(res.Skipped, &pb.DroppedCache{Scope: scope, Description: path.String()})
+				} else if scope == pb.DropCacheParams_CORES {
+					dropped, err = dropCores(path)
+				} else {
+					dropped, err = dropBuildPath(path)
+				}
+			})
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "dropping %s: %s", scope, err)
+		}
+		for _, d := range dropped {
+			res.FreedBytes += d.FreedBytes
+		}
+		res.Dropped = append(res.Dropped, dropped...)
+	}
+	return res, nil
+}
+
+// dropCores deletes the core archives compiled into buildCachePath, leaving
+// anything else alone
+func dropCores(buildCachePath *paths.Path) ([]*pb.DroppedCache, error) {
+	files, err := buildCachePath.ReadDir()
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var dropped []*pb.DroppedCache
+	for _, file := range files {
+		base := file.Base()
+		if !strings.HasPrefix(base, "core_") || !strings.HasSuffix(base, ".a") {
+			continue
+		}
+		info, err := file.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if err := file.Remove(); err != nil {
+			return dropped, err
+		}
+		dropped = append(dropped, &pb.DroppedCache{
+			Scope:       pb.DropCacheParams_CORES,
+			Description: file.String(),
+			FreedBytes:  info.Size(),
+		})
+	}
+	return dropped, nil
+}
+
+// dropBuildPath deletes buildPath with all its contents
+func dropBuildPath(buildPath *paths.Path) ([]*pb.DroppedCache, error) {
+	size := folderSize(buildPath)
+	if err := buildPath.RemoveAll(); err != nil {
+		return nil, err
+	}
+	return []*pb.DroppedCache{{
+		Scope:       pb.DropCacheParams_BUILD_PATH,
+		Description: buildPath.String(),
+		FreedBytes:  size,
+	}}, nil
+}
+
+// folderSize returns the size of the regular files inside folder
+func folderSize(folder *paths.Path) int64 {
+	var size int64
+	filepath.Walk(folder.String(), func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,239 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"testing"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"github.com/arduino/go-paths-helper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newDropCacheServer() *builderServer {
+	return &builderServer{
+		hardware:  newHardwareCache(8),
+		libraries: newLibrariesCache(8),
+		scheduler: newScheduler(2, 4),
+	}
+}
+
+// startBuild starts a job building buildCtx
+func startBuild(t *testing.T, s *scheduler, buildCtx *types.Context) *job {
+	j, err := s.enqueue(context.Background(), buildCtx, pb.JobPriority_VERIFY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+// writeFiles creates the given files in folder, with the given contents
+func writeFiles(t *testing.T, folder *paths.Path, files map[string]string) {
+	for name, contents := range files {
+		file := folder.Join(name)
+		if err := file.Parent().MkdirAll(); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.WriteFile([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDropCacheChecks(t *testing.T) {
+	tmp, err := paths.MkTempDir("", "arduino-builder-dropcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.RemoveAll()
+
+	s := newDropCacheServer()
+	// a build path this daemon built into, a folder it never built into
+	// and a folder that doesn't look like a build path
+	known := tmp.Join("known")
+	unknown := tmp.Join("unknown")
+	notBuildPath := tmp.Join("sketch")
+	writeFiles(t, known, map[string]string{buildOptionsFile: "{}"})
+	writeFiles(t, unknown, map[string]string{buildOptionsFile: "{}"})
+	writeFiles(t, notBuildPath, map[string]string{"sketch.ino": "void setup() {}"})
+	s.scheduler.done(startBuild(t, s.scheduler, &types.Context{BuildPath: known}))
+
+	tests := []struct {
+		name   string
+		params *pb.DropCacheParams
+		want   codes.Code
+	}{
+		{
+			"relative build path",
+			&pb.DropCacheParams{Scopes: []pb.DropCacheParams_Scope{pb.DropCacheParams_BUILD_PATH}, BuildPath: "build"},
+			codes.InvalidArgument,
+		},
+		{
+			"missing build path",
+			&pb.DropCacheParams{Scopes: []pb.DropCacheParams_Scope{pb.DropCacheParams_BUILD_PATH}},
+			codes.InvalidArgument,
+		},
+		{
+			"relative build cache path",
+			&pb.DropCacheParams{Scopes: []pb.DropCacheParams_Scope{pb.DropCacheParams_CORES}, BuildCachePath: "cache"},
+			codes.InvalidArgument,
+		},
+		{
+			"no build options",
+			&pb.DropCacheParams{Scopes: []pb.DropCacheParams_Scope{pb.DropCacheParams_BUILD_PATH}, BuildPath: notBuildPath.String()},
+			codes.FailedPrecondition,
+		},
+		{
+			"unknown build path",
+			&pb.DropCacheParams{Scopes: []pb.DropCacheParams_Scope{pb.DropCacheParams_BUILD_PATH}, BuildPath: unknown.String()},
+			codes.FailedPrecondition,
+		},
+		{
+			// the hardware must not be dropped when the build path can't be
+			"valid scope along an invalid one",
+			&pb.DropCacheParams{Scopes: []pb.DropCacheParams_Scope{pb.DropCacheParams_HARDWARE, pb.DropCacheParams_BUILD_PATH}, BuildPath: unknown.String()},
+			codes.FailedPrecondition,
+		},
+	}
+	for _, test := range tests {
+		_, err := s.DropCacheV2(context.Background(), test.params)
+		if got := status.Code(err); got != test.want {
+			t.Errorf("%s: got %s (%v), want %s", test.name, got, err, test.want)
+		}
+	}
+	for _, folder := range []*paths.Path{known, unknown, notBuildPath} {
+		if !folder.Exist() {
+			t.Errorf("%s was deleted", folder)
+		}
+	}
+}
+
+func TestDropCacheBuildPath(t *testing.T) {
+	tmp, err := paths.MkTempDir("", "arduino-builder-dropcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.RemoveAll()
+
+	s := newDropCacheServer()
+	idle := tmp.Join("idle")
+	busy := tmp.Join("busy")
+	writeFiles(t, idle, map[string]string{buildOptionsFile: "{}", "sketch/Blink.ino.cpp.o": "12345"})
+	writeFiles(t, busy, map[string]string{buildOptionsFile: "{}"})
+	s.scheduler.done(startBuild(t, s.scheduler, &types.Context{BuildPath: idle}))
+	running := startBuild(t, s.scheduler, &types.Context{BuildPath: busy})
+	defer s.scheduler.done(running)
+
+	res, err := s.DropCacheV2(context.Background(), &pb.DropCacheParams{
+		Scopes:    []pb.DropCacheParams_Scope{pb.DropCacheParams_BUILD_PATH},
+		BuildPath: idle.String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idle.Exist() {
+		t.Errorf("%s was not deleted", idle)
+	}
+	if len(res.Dropped) != 1 || res.Dropped[0].Description != idle.String() || res.FreedBytes != int64(len("{}12345")) {
+		t.Errorf("got %v, want %s dropped with %d bytes freed", res, idle, len("{}12345"))
+	}
+
+	res, err = s.DropCacheV2(context.Background(), &pb.DropCacheParams{
+		Scopes:    []pb.DropCacheParams_Scope{pb.DropCacheParams_BUILD_PATH},
+		BuildPath: busy.String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !busy.Exist() {
+		t.Errorf("%s was deleted while a job is using it", busy)
+	}
+	if len(res.Dropped) != 0 || len(res.Skipped) != 1 || res.Skipped[0].Description != busy.String() {
+		t.Errorf("got %v, want %s skipped", res, busy)
+	}
+}
+
+func TestDropCacheCores(t *testing.T) {
+	tmp, err := paths.MkTempDir("", "arduino-builder-dropcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.RemoveAll()
+
+	s := newDropCacheServer()
+	writeFiles(t, tmp, map[string]string{
+		"core_arduino_avr_uno_0123.a":  "1234",
+		"core_arduino_avr_mega_4567.a": "12",
+		"core_notes.txt":               "keep",
+		"libcore.a":                    "keep",
+		"sketches/core_x.a":            "keep",
+	})
+	if err := tmp.Join("core_folder.a").MkdirAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.DropCacheV2(context.Background(), &pb.DropCacheParams{
+		Scopes:         []pb.DropCacheParams_Scope{pb.DropCacheParams_CORES},
+		BuildCachePath: tmp.String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Dropped) != 2 || res.FreedBytes != 6 {
+		t.Errorf("got %v, want the 2 core archives dropped with 6 bytes freed", res)
+	}
+	for _, name := range []string{"core_arduino_avr_uno_0123.a", "core_arduino_avr_mega_4567.a"} {
+		if tmp.Join(name).Exist() {
+			t.Errorf("%s was not deleted", name)
+		}
+	}
+	for _, name := range []string{"core_notes.txt", "libcore.a", "sketches/core_x.a", "core_folder.a"} {
+		if !tmp.Join(name).Exist() {
+			t.Errorf("%s was deleted", name)
+		}
+	}
+
+	// the cores of a running job are left alone
+	writeFiles(t, tmp, map[string]string{"core_arduino_avr_uno_0123.a": "1234"})
+	running := startBuild(t, s.scheduler, &types.Context{BuildCachePath: tmp})
+	defer s.scheduler.done(running)
+	res, err = s.DropCacheV2(context.Background(), &pb.DropCacheParams{
+		Scopes:         []pb.DropCacheParams_Scope{pb.DropCacheParams_CORES},
+		BuildCachePath: tmp.String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Dropped) != 0 || len(res.Skipped) != 1 || !tmp.Join("core_arduino_avr_uno_0123.a").Exist() {
+		t.Errorf("got %v, want the cores skipped", res)
+	}
+}
@@ -0,0 +1,456 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// JSON-RPC error codes. -32800 comes from LSP, for the requests canceled
+// with $/cancelRequest.
+const (
+	jsonrpcParseError       = -32700
+	jsonrpcInvalidRequest   = -32600
+	jsonrpcMethodNotFound   = -32601
+	jsonrpcInvalidParams    = -32602
+	jsonrpcInternalError    = -32603
+	jsonrpcRequestCancelled = -32800
+)
+
+// maxJSONRPCMessageSize limits the size of the messages read from clients,
+// large enough for the overlays of a sketch
+const maxJSONRPCMessageSize = 64 << 20
+
+type jsonrpcRequest struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type jsonrpcResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *jsonrpcError    `json:"error,omitempty"`
+}
+
+type jsonrpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type jsonrpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *jsonrpcError) Error() string {
+	return e.Message
+}
+
+// buildEventParams are the params of the "build/event" notifications,
+// carrying an event of the build started by the request with the given id
+type buildEventParams struct {
+	ID    *json.RawMessage `json:"id"`
+	Event json.RawMessage  `json:"event"`
+}
+
+// notifyFunc sends a notification to the client, it is nil for the
+// transports that can't carry notifications
+type notifyFunc func(method string, params interface{})
+
+// jsonrpcHandler serves the Build, Autocomplete and DropCache calls of the
+// Builder service as JSON-RPC 2.0, over HTTP POST requests and WebSocket.
+// Params and results are the JSON mapping of the protobuf messages of the
+// corresponding BuildV2, AutocompleteV2 and DropCache calls, except for the
+// result of Build, which is the final BuildResult of the build. Over
+// WebSocket, the other events of a build are sent as "build/event"
+// notifications while the build runs.
+type jsonrpcHandler struct {
+	daemon *Daemon
+}
+
+func (h *jsonrpcHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.originAllowed(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && h.originListed(origin) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.Header().Set("Vary", "Origin")
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if h.daemon.auth != nil && !h.daemon.auth.valid(r.Header["Authorization"]) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "missing or invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		// the origin has been checked already
+		server := websocket.Server{
+			Handshake: func(*websocket.Config, *http.Request) error { return nil },
+			Handler:   h.serveWebSocket,
+		}
+		server.ServeHTTP(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST, OPTIONS")
+		http.Error(w, "JSON-RPC requests must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxJSONRPCMessageSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res := h.handleMessage(r.Context(), data, nil)
+	if res == nil {
+		// notifications only
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(res)
+}
+
+// originAllowed returns true if the request doesn't come from a web page, or
+// comes from an allowed one, so that random pages can't drive the daemon
+// through the browser. Pages served by the same host as the daemon are
+// allowed as long as the host is the daemon itself: a page whose name has
+// been rebound to the address of the daemon sends its own name as Host.
+func (h *jsonrpcHandler) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host && localHost(r) {
+		return true
+	}
+	return h.originListed(origin)
+}
+
+// originListed returns true if origin is one of the allowed origins
+func (h *jsonrpcHandler) originListed(origin string) bool {
+	for _, allowed := range h.daemon.opts.JSONRPCOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// localHost returns true if the Host of r names the daemon: a loopback
+// name or address, or the address the request has been received on
+func localHost(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(r.Host, "["), "]")
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+	switch addr := r.Context().Value(http.LocalAddrContextKey).(type) {
+	case *net.TCPAddr:
+		return ip != nil && ip.Equal(addr.IP)
+	case net.Addr:
+		// browsers can't reach unix sockets
+		return addr.Network() == "unix"
+	}
+	return false
+}
+
+// serveWebSocket serves the requests coming from a WebSocket connection,
+// concurrently. Requests are canceled with a "$/cancelRequest" notification
+// with the id of the request, or when the connection is closed.
+func (h *jsonrpcHandler) serveWebSocket(ws *websocket.Conn) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	go func() {
+		select {
+		case <-h.daemon.done:
+			ws.Close()
+		case <-ctx.Done():
+		}
+	}()
+
+	var writeMux sync.Mutex
+	send := func(data []byte) {
+		writeMux.Lock()
+		websocket.Message.Send(ws, string(data))
+		writeMux.Unlock()
+	}
+	notify := func(method string, params interface{}) {
+		if data, err := json.Marshal(&jsonrpcNotification{JSONRPC: "2.0", Method: method, Params: params}); err == nil {
+			send(data)
+		}
+	}
+
+	var callsMux sync.Mutex
+	calls := map[string]context.CancelFunc{}
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			return
+		}
+
+		key := ""
+		req := &jsonrpcRequest{}
+		if json.Unmarshal(data, req) == nil {
+			if req.Method == "$/cancelRequest" {
+				params := &struct {
+					ID json.RawMessage `json:"id"`
+				}{}
+				json.Unmarshal(req.Params, params)
+				callsMux.Lock()
+				if cancelCall, ok := calls[string(params.ID)]; ok {
+					cancelCall()
+				}
+				callsMux.Unlock()
+				continue
+			}
+			if req.ID != nil {
+				key = string(*req.ID)
+			}
+		}
+
+		callCtx, cancelCall := context.WithCancel(ctx)
+		if key != "" {
+			callsMux.Lock()
+			calls[key] = cancelCall
+			callsMux.Unlock()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := h.handleMessage(callCtx, data, notify)
+			cancelCall()
+			if key != "" {
+				callsMux.Lock()
+				delete(calls, key)
+				callsMux.Unlock()
+			}
+			if res != nil {
+				send(res)
+			}
+		}()
+	}
+}
+
+// handleMessage handles a request, a notification or a batch of them and
+// returns the encoded response, or nil if there is nothing to reply
+func (h *jsonrpcHandler) handleMessage(ctx context.Context, data []byte, notify notifyFunc) []byte {
+	data = bytes.TrimSpace(data)
+	var res interface{}
+	if len(data) > 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil {
+			res = &jsonrpcResponse{JSONRPC: "2.0", Error: &jsonrpcError{Code: jsonrpcParseError, Message: err.Error()}}
+		} else if len(batch) == 0 {
+			res = &jsonrpcResponse{JSONRPC: "2.0", Error: &jsonrpcError{Code: jsonrpcInvalidRequest, Message: "empty batch"}}
+		} else {
+			var responses []*jsonrpcResponse
+			for _, item := range batch {
+				if response := h.handleRequest(ctx, item, notify); response != nil {
+					responses = append(responses, response)
+				}
+			}
+			if len(responses) == 0 {
+				return nil
+			}
+			res = responses
+		}
+	} else {
+		response := h.handleRequest(ctx, data, notify)
+		if response == nil {
+			return nil
+		}
+		res = response
+	}
+
+	encoded, err := json.Marshal(res)
+	if err != nil {
+		encoded, _ = json.Marshal(&jsonrpcResponse{JSONRPC: "2.0", Error: &jsonrpcError{Code: jsonrpcInternalError, Message: err.Error()}})
+	}
+	return encoded
+}
+
+// handleRequest handles a single request, returning nil for notifications
+func (h *jsonrpcHandler) handleRequest(ctx context.Context, data []byte, notify notifyFunc) *jsonrpcResponse {
+	req := &jsonrpcRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		return &jsonrpcResponse{JSONRPC: "2.0", Error: &jsonrpcError{Code: jsonrpcParseError, Message: err.Error()}}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &jsonrpcResponse{JSONRPC: "2.0", ID: req.ID, Error: &jsonrpcError{Code: jsonrpcInvalidRequest, Message: "not a JSON-RPC 2.0 request"}}
+	}
+
+	result, err := h.call(ctx, req, notify)
+	if req.ID == nil {
+		return nil
+	}
+	res := &jsonrpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if err != nil {
+		res.Error = toJSONRPCError(err)
+	} else {
+		res.Result = result
+	}
+	return res
+}
+
+func (h *jsonrpcHandler) call(ctx context.Context, req *jsonrpcRequest, notify notifyFunc) (json.RawMessage, error) {
+	h.daemon.activity.begin()
+	defer h.daemon.activity.end()
+	builder := h.daemon.builder
+	ctx = context.WithValue(ctx, methodKey{}, "jsonrpc:"+req.Method)
+
+	switch req.Method {
+	case "Build":
+		params := &pb.BuildParamsV2{}
+		if err := unmarshalParams(req.Params, params); err != nil {
+			return nil, err
+		}
+		stream := &jsonrpcEventStream{id: req.ID, notify: notify, result: &pb.BuildResult{}}
+		if err := builder.buildWithEvents(ctx, params, stream); err != nil {
+			return nil, err
+		}
+		return marshalResult(stream.result)
+	case "Autocomplete":
+		params := &pb.BuildParamsV2{}
+		if err := unmarshalParams(req.Params, params); err != nil {
+			return nil, err
+		}
+		res, err := builder.AutocompleteV2(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return marshalResult(res)
+	case "DropCache":
+		params := &pb.VerboseParams{}
+		if err := unmarshalParams(req.Params, params); err != nil {
+			return nil, err
+		}
+		res, err := builder.DropCache(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return marshalResult(res)
+	}
+	return nil, &jsonrpcError{Code: jsonrpcMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// jsonrpcEventStream sends the events of a build as notifications, keeping
+// the final result aside as the result of the request
+type jsonrpcEventStream struct {
+	id     *json.RawMessage
+	notify notifyFunc
+	result *pb.BuildResult
+}
+
+func (s *jsonrpcEventStream) Send(event *pb.BuildEvent) error {
+	if result := event.GetResult(); result != nil {
+		s.result = result
+		return nil
+	}
+	if s.notify == nil {
+		return nil
+	}
+	data, err := marshalResult(event)
+	if err != nil {
+		return err
+	}
+	s.notify("build/event", &buildEventParams{ID: s.id, Event: data})
+	return nil
+}
+
+func unmarshalParams(params json.RawMessage, msg proto.Message) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	unmarshaler := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := unmarshaler.Unmarshal(bytes.NewReader(params), msg); err != nil {
+		return &jsonrpcError{Code: jsonrpcInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+func marshalResult(msg proto.Message) (json.RawMessage, error) {
+	marshaler := &jsonpb.Marshaler{EmitDefaults: true}
+	data, err := marshaler.MarshalToString(msg)
+	return json.RawMessage(data), err
+}
+
+// toJSONRPCError converts the errors of the gRPC calls, keeping their
+// status code in the data of the error
+func toJSONRPCError(err error) *jsonrpcError {
+	if jsonrpcErr, ok := err.(*jsonrpcError); ok {
+		return jsonrpcErr
+	}
+	st := status.Convert(err)
+	res := &jsonrpcError{Code: jsonrpcInternalError, Message: st.Message(), Data: map[string]string{"code": st.Code().String()}}
+	switch st.Code() {
+	case codes.InvalidArgument:
+		res.Code = jsonrpcInvalidParams
+	case codes.Canceled, codes.DeadlineExceeded:
+		res.Code = jsonrpcRequestCancelled
+	}
+	return res
+}
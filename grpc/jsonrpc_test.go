@@ -0,0 +1,74 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestJSONRPCOrigin(t *testing.T) {
+	h := &jsonrpcHandler{daemon: &Daemon{opts: Options{JSONRPCOrigins: []string{"https://ide.example.com"}}}}
+	local := &net.TCPAddr{IP: net.ParseIP("192.168.1.5"), Port: 7000}
+	tests := []struct {
+		host, origin string
+		allowed      bool
+		cors         bool
+	}{
+		{"localhost:7000", "", true, false},
+		{"localhost:7000", "http://localhost:7000", true, false},
+		{"127.0.0.1:7000", "http://127.0.0.1:7000", true, false},
+		{"[::1]:7000", "http://[::1]:7000", true, false},
+		{"192.168.1.5:7000", "http://192.168.1.5:7000", true, false},
+		// DNS rebinding: the page and the Host carry the name of the attacker
+		{"evil.com:7000", "http://evil.com:7000", false, false},
+		{"localhost:7000", "http://evil.com", false, false},
+		{"localhost:7000", "https://ide.example.com", true, true},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodOptions, "http://"+test.host+"/", nil)
+		r.Host = test.host
+		if test.origin != "" {
+			r.Header.Set("Origin", test.origin)
+		}
+		r = r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey, local))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if allowed := w.Code != http.StatusForbidden; allowed != test.allowed {
+			t.Errorf("Host %s, Origin %q: allowed = %v, want %v", test.host, test.origin, allowed, test.allowed)
+		}
+		if cors := w.Header().Get("Access-Control-Allow-Origin") != ""; cors != test.cors {
+			t.Errorf("Host %s, Origin %q: Access-Control-Allow-Origin set = %v, want %v", test.host, test.origin, cors, test.cors)
+		}
+	}
+}
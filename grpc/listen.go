@@ -0,0 +1,144 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errListenerClosed is returned by the Accept of a closed stdioListener
+var errListenerClosed = errors.New("listener closed")
+
+// Listen creates the listener for the daemon. The address can be:
+//   - "host:port" to listen on TCP, port 0 picks a free port
+//   - "unix:/path/to/socket" to listen on a Unix domain socket
+//   - "stdio" to serve a single client through stdin and stdout
+func Listen(address string) (net.Listener, error) {
+	if address == "stdio" {
+		return newStdioListener(), nil
+	}
+
+	if strings.HasPrefix(address, "unix:") {
+		socket := strings.TrimPrefix(address, "unix:")
+		if conn, err := net.Dial("unix", socket); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("can't listen on %s: another daemon is already using it", socket)
+		}
+		// remove the socket left behind by a daemon that didn't exit cleanly
+		os.Remove(socket)
+		lis, err := net.Listen("unix", socket)
+		if err != nil {
+			return nil, fmt.Errorf("can't start the daemon: %s", err)
+		}
+		if err := os.Chmod(socket, 0600); err != nil {
+			lis.Close()
+			return nil, fmt.Errorf("can't start the daemon: %s", err)
+		}
+		return lis, nil
+	}
+
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("can't start the daemon: %s", err)
+	}
+	return lis, nil
+}
+
+// stdioListener is a net.Listener accepting a single connection that reads
+// from stdin and writes to stdout. It is meant for editors that spawn the
+// daemon and talk to it through a pipe.
+type stdioListener struct {
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newStdioListener() *stdioListener {
+	lis := &stdioListener{
+		conns:  make(chan net.Conn, 1),
+		closed: make(chan struct{}),
+	}
+	lis.conns <- &stdioConn{listener: lis}
+	return lis
+}
+
+func (lis *stdioListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-lis.conns:
+		return conn, nil
+	case <-lis.closed:
+		return nil, errListenerClosed
+	}
+}
+
+func (lis *stdioListener) Close() error {
+	lis.closeOnce.Do(func() { close(lis.closed) })
+	return nil
+}
+
+func (lis *stdioListener) Addr() net.Addr {
+	return stdioAddr{}
+}
+
+type stdioConn struct {
+	listener *stdioListener
+}
+
+func (c *stdioConn) Read(b []byte) (int, error) {
+	return os.Stdin.Read(b)
+}
+
+func (c *stdioConn) Write(b []byte) (int, error) {
+	return os.Stdout.Write(b)
+}
+
+// Close closes the listener as well, since once the only client is gone
+// there is nothing left to serve.
+func (c *stdioConn) Close() error {
+	c.listener.Close()
+	os.Stdin.Close()
+	return os.Stdout.Close()
+}
+
+func (c *stdioConn) LocalAddr() net.Addr                { return stdioAddr{} }
+func (c *stdioConn) RemoteAddr() net.Addr               { return stdioAddr{} }
+func (c *stdioConn) SetDeadline(t time.Time) error      { return nil }
+func (c *stdioConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *stdioConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type stdioAddr struct{}
+
+func (stdioAddr) Network() string { return "stdio" }
+func (stdioAddr) String() string  { return "stdio" }
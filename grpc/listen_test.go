@@ -0,0 +1,131 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"net"
+	"runtime"
+	"testing"
+
+	"github.com/arduino/go-paths-helper"
+)
+
+func TestListenUnix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets have no permissions on Windows")
+	}
+	tmp, err := paths.MkTempDir("", "arduino-builder-listen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.RemoveAll()
+	socket := tmp.Join("daemon.sock")
+
+	// a socket left behind by a daemon that didn't exit cleanly
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: socket.String(), Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+	if !socket.Exist() {
+		t.Fatal("the stale socket was removed")
+	}
+
+	lis, err := Listen("unix:" + socket.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	info, err := socket.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("the socket has permissions %o, want 600", perm)
+	}
+
+	conn, err := net.Dial("unix", socket.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if accepted, err := lis.Accept(); err != nil {
+		t.Error(err)
+	} else {
+		accepted.Close()
+	}
+
+	if second, err := Listen("unix:" + socket.String()); err == nil {
+		second.Close()
+		t.Error("listened on the socket of a running daemon")
+	}
+}
+
+func TestListenTCP(t *testing.T) {
+	lis, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	if addr, ok := lis.Addr().(*net.TCPAddr); !ok || addr.Port == 0 {
+		t.Errorf("listening on %s, want a free TCP port", lis.Addr())
+	}
+
+	if _, err := Listen("127.0.0.1:-1"); err == nil {
+		t.Error("listened on an invalid port")
+	}
+}
+
+func TestStdioListener(t *testing.T) {
+	lis, err := Listen("stdio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lis.Addr().Network() != "stdio" {
+		t.Errorf("listening on %s, want stdio", lis.Addr().Network())
+	}
+	// the connection is not closed, which would close the stdio of the test
+	if _, err := lis.Accept(); err != nil {
+		t.Fatal(err)
+	}
+
+	// there is a single client, the next Accept waits for the listener to
+	// be closed
+	accepted := make(chan error)
+	go func() {
+		_, err := lis.Accept()
+		accepted <- err
+	}()
+	lis.Close()
+	lis.Close()
+	if err := <-accepted; err != errListenerClosed {
+		t.Errorf("Accept() = %v once closed, want %v", err, errListenerClosed)
+	}
+}
@@ -0,0 +1,289 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arduino/arduino-builder/events"
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/legacy/builder/i18n"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the buckets of the
+// duration histograms
+var durationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// metrics keeps the statistics of the jobs run by the daemon, exposed in the
+// Prometheus text format along with the state of the scheduler and of the
+// caches
+type metrics struct {
+	mux            sync.Mutex
+	jobsStarted    map[string]float64
+	jobsFailed     map[string]float64
+	jobsCanceled   map[string]float64
+	jobDurations   map[string]*histogram
+	waitDurations  map[string]*histogram
+	phaseDurations map[string]*histogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		jobsStarted:    map[string]float64{},
+		jobsFailed:     map[string]float64{},
+		jobsCanceled:   map[string]float64{},
+		jobDurations:   map[string]*histogram{},
+		waitDurations:  map[string]*histogram{},
+		phaseDurations: map[string]*histogram{},
+	}
+}
+
+// jobStarted records that j left the queue
+func (m *metrics) jobStarted(j *job) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.jobsStarted[j.method]++
+	observe(m.waitDurations, j.priority.String(), j.startedAt.Sub(j.queuedAt))
+}
+
+// jobEnded records that j ended with the given error
+func (m *metrics) jobEnded(j *job, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if isCanceled(err) {
+		m.jobsCanceled[j.method]++
+	} else if err != nil {
+		m.jobsFailed[j.method]++
+	}
+	observe(m.jobDurations, j.method, time.Since(j.startedAt))
+}
+
+func (m *metrics) phaseEnded(phase pb.BuildPhase, duration time.Duration) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	observe(m.phaseDurations, strings.ToLower(phase.String()), duration)
+}
+
+func (m *metrics) write(w io.Writer) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	writeCounters(w, "arduino_builder_jobs_started_total", "Jobs started, by RPC.", "method", m.jobsStarted)
+	writeCounters(w, "arduino_builder_jobs_failed_total", "Jobs ended with an error, by RPC.", "method", m.jobsFailed)
+	writeCounters(w, "arduino_builder_jobs_canceled_total", "Jobs canceled while running, by RPC.", "method", m.jobsCanceled)
+	writeHistograms(w, "arduino_builder_job_duration_seconds", "Time spent running jobs, by RPC.", "method", m.jobDurations)
+	writeHistograms(w, "arduino_builder_job_wait_seconds", "Time spent by jobs in the queue, by priority.", "priority", m.waitDurations)
+	writeHistograms(w, "arduino_builder_phase_duration_seconds", "Time spent in each phase of builds.", "phase", m.phaseDurations)
+}
+
+// serveMetrics writes the metrics of the daemon
+func (s *builderServer) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	s.metrics.write(w)
+
+	queued, running, compilerJobs := s.scheduler.stats()
+	writeGauge(w, "arduino_builder_jobs_queued", "Jobs waiting to start.", float64(queued))
+	writeGauge(w, "arduino_builder_jobs_running", "Jobs running.", float64(running))
+	writeGauge(w, "arduino_builder_compiler_jobs_allotted", "Compiler processes allotted to the running jobs.", float64(compilerJobs))
+	writeGauge(w, "arduino_builder_compiler_jobs_budget", "Compiler processes the running jobs can share.", float64(s.scheduler.jobBudget))
+	if processes, ok := childProcesses(); ok {
+		writeGauge(w, "arduino_builder_child_processes", "Processes run by the daemon, mostly compilers.", float64(processes))
+	}
+
+	hardware, libraries := s.hardware.stats(), s.libraries.stats()
+	requests := map[string]float64{
+		`cache="hardware",result="hit"`:   float64(hardware.hits),
+		`cache="hardware",result="miss"`:  float64(hardware.misses),
+		`cache="libraries",result="hit"`:  float64(libraries.hits),
+		`cache="libraries",result="miss"`: float64(libraries.misses),
+	}
+	writeMetricHeader(w, "arduino_builder_cache_requests_total", "Lookups of the hardware and libraries caches.", "counter")
+	for _, labels := range sortedKeys(requests) {
+		fmt.Fprintf(w, "arduino_builder_cache_requests_total{%s} %s\n", labels, formatValue(requests[labels]))
+	}
+	writeMetricHeader(w, "arduino_builder_cache_entries", "Sets of folders whose parsed data is cached.", "gauge")
+	fmt.Fprintf(w, "arduino_builder_cache_entries{cache=\"hardware\"} %d\n", hardware.entries)
+	fmt.Fprintf(w, "arduino_builder_cache_entries{cache=\"libraries\"} %d\n", libraries.entries)
+	writeMetricHeader(w, "arduino_builder_cache_evictions_total", "Cached data dropped to stay within the entries and memory limits.", "counter")
+	fmt.Fprintf(w, "arduino_builder_cache_evictions_total{cache=\"hardware\"} %d\n", hardware.evictions)
+	fmt.Fprintf(w, "arduino_builder_cache_evictions_total{cache=\"libraries\"} %d\n", libraries.evictions)
+}
+
+// phaseTimer is an i18n.Logger measuring how long each phase of the build
+// in ctx lasts, passing everything through to the wrapped logger. It tracks
+// the phases as the builder reports its progress, so it poses as the machine
+// logger and forwards the progress only to the wrapped logger that is one.
+type phaseTimer struct {
+	i18n.Logger
+	metrics *metrics
+	ctx     *types.Context
+
+	mux   sync.Mutex
+	phase pb.BuildPhase
+	since time.Time
+}
+
+// newPhaseTimer wraps the logger of ctx into a phaseTimer
+func newPhaseTimer(ctx *types.Context, m *metrics) *phaseTimer {
+	ctx.Progress.PrintEnabled = true
+	return &phaseTimer{Logger: ctx.GetLogger(), metrics: m, ctx: ctx}
+}
+
+func (t *phaseTimer) Fprintln(w io.Writer, level string, format string, a ...interface{}) {
+	if t.check(format, a) && t.Logger.Name() != "machine" {
+		return
+	}
+	t.Logger.Fprintln(w, level, format, a...)
+}
+
+func (t *phaseTimer) Println(level string, format string, a ...interface{}) {
+	if t.check(format, a) && t.Logger.Name() != "machine" {
+		return
+	}
+	t.Logger.Println(level, format, a...)
+}
+
+func (t *phaseTimer) Name() string {
+	return "machine"
+}
+
+// check tells if the message is a progress report, entering the phase the
+// build is in if it changed
+func (t *phaseTimer) check(format string, a []interface{}) bool {
+	if _, ok := events.ParseProgress(format, a); !ok {
+		return false
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if phase := events.BuildPhase(t.ctx); phase > t.phase {
+		t.enter(phase)
+	}
+	return true
+}
+
+// enter records the end of the current phase, if any, and the beginning of
+// the given one. It must be called with mux held.
+func (t *phaseTimer) enter(phase pb.BuildPhase) {
+	now := time.Now()
+	if t.phase != pb.BuildPhase_UNKNOWN_PHASE {
+		t.metrics.phaseEnded(t.phase, now.Sub(t.since))
+	}
+	t.phase = phase
+	t.since = now
+}
+
+// finish records the end of the last phase
+func (t *phaseTimer) finish() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.enter(pb.BuildPhase_UNKNOWN_PHASE)
+}
+
+type histogram struct {
+	// counts holds the observations of each bucket, plus the ones exceeding
+	// the last bucket
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func observe(histograms map[string]*histogram, label string, d time.Duration) {
+	h, ok := histograms[label]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(durationBuckets)+1)}
+		histograms[label] = h
+	}
+	seconds := d.Seconds()
+	i := sort.SearchFloat64s(durationBuckets, seconds)
+	h.counts[i]++
+	h.sum += seconds
+	h.count++
+}
+
+func writeMetricHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeGauge(w io.Writer, name, help string, value float64) {
+	writeMetricHeader(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", name, formatValue(value))
+}
+
+func writeCounters(w io.Writer, name, help, label string, values map[string]float64) {
+	writeMetricHeader(w, name, help, "counter")
+	for _, value := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s=%s} %s\n", name, label, quoteLabel(value), formatValue(values[value]))
+	}
+}
+
+func writeHistograms(w io.Writer, name, help, label string, histograms map[string]*histogram) {
+	writeMetricHeader(w, name, help, "histogram")
+	keys := make([]string, 0, len(histograms))
+	for key := range histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		h := histograms[key]
+		labels := label + "=" + quoteLabel(key)
+		cumulative := uint64(0)
+		for i, bound := range durationBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatValue(bound), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatValue(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func quoteLabel(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
@@ -0,0 +1,139 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/arduino/cores"
+	"github.com/arduino/go-paths-helper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// upgradeBuildParams converts the legacy BuildParams, where lists are packed
+// into comma separated strings, into a BuildParamsV2.
+func upgradeBuildParams(args *pb.BuildParams) (*pb.BuildParamsV2, error) {
+	fqbn, err := pb.ParseFQBN(args.FQBN)
+	if err != nil {
+		return nil, err
+	}
+
+	var customBuildProperties []*pb.BuildProperty
+	for _, prop := range splitList(args.CustomBuildProperties) {
+		kv := strings.SplitN(prop, "=", 2)
+		if len(kv) != 2 {
+			// older clients got away with these, don't fail their builds
+			log.Printf("ignoring invalid build property %q, should be 'key=value'", prop)
+			continue
+		}
+		customBuildProperties = append(customBuildProperties, &pb.BuildProperty{Key: kv[0], Value: kv[1]})
+	}
+
+	return &pb.BuildParamsV2{
+		HardwareFolders:         splitList(args.HardwareFolders),
+		ToolsFolders:            splitList(args.ToolsFolders),
+		BuiltInLibrariesFolders: splitList(args.BuiltInLibrariesFolders),
+		OtherLibrariesFolders:   splitList(args.OtherLibrariesFolders),
+		SketchLocation:          args.SketchLocation,
+		Fqbn:                    fqbn,
+		ArduinoAPIVersion:       args.ArduinoAPIVersion,
+		CustomBuildProperties:   customBuildProperties,
+		BuildCachePath:          args.BuildCachePath,
+		BuildPath:               args.BuildPath,
+		WarningsLevel:           args.WarningsLevel,
+		CodeCompleteAt:          args.CodeCompleteAt,
+		Verbose:                 args.Verbose,
+		Overlays:                args.Overlays,
+	}, nil
+}
+
+func splitList(list string) []string {
+	var res []string
+	for _, item := range strings.Split(list, ",") {
+		if item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// toCoresFQBN converts a pb.FQBN into a cores.FQBN, checking that it is valid
+func toCoresFQBN(fqbn *pb.FQBN) (*cores.FQBN, error) {
+	if fqbn == nil {
+		return nil, errors.New("parsing fqbn: fqbn is missing")
+	}
+	res, err := cores.ParseFQBN(pb.FormatFQBN(fqbn))
+	if err != nil {
+		return nil, fmt.Errorf("parsing fqbn: %s", err)
+	}
+	return res, nil
+}
+
+// sourceOverrides converts the overlays into the map of the sources the
+// builder must use in place of the ones on disk, keyed by their path
+// relative to the sketch folder
+func sourceOverrides(sketchLocation *paths.Path, overlays []*pb.FileOverlay) (map[string]string, error) {
+	if len(overlays) == 0 {
+		return nil, nil
+	}
+	if sketchLocation == nil {
+		return nil, status.Error(codes.InvalidArgument, "overlays require a sketch location")
+	}
+	sketchFolder := sketchLocation
+	if !sketchFolder.IsDir() {
+		sketchFolder = sketchFolder.Parent()
+	}
+	sketchFolder, err := sketchFolder.Abs()
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]string{}
+	for _, overlay := range overlays {
+		path := paths.New(overlay.Path)
+		if path == nil {
+			return nil, status.Error(codes.InvalidArgument, "overlay without a path")
+		}
+		if !path.IsAbs() {
+			path = sketchFolder.JoinPath(path)
+		}
+		rel, err := path.Clean().RelFrom(sketchFolder)
+		if err != nil || rel.String() == ".." || strings.HasPrefix(rel.String(), ".."+string(filepath.Separator)) {
+			return nil, status.Errorf(codes.InvalidArgument, "overlay %s is outside the sketch folder", overlay.Path)
+		}
+		res[rel.String()] = overlay.Contents
+	}
+	return res, nil
+}
@@ -0,0 +1,153 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"path/filepath"
+	"testing"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/go-paths-helper"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestUpgradeBuildParams(t *testing.T) {
+	overlay := &pb.FileOverlay{Path: "Blink.ino", Contents: "void setup() {}"}
+	got, err := upgradeBuildParams(&pb.BuildParams{
+		HardwareFolders:         "/hw1,/hw2",
+		ToolsFolders:            "/tools,",
+		BuiltInLibrariesFolders: "",
+		OtherLibrariesFolders:   ",/libs",
+		SketchLocation:          "/work/Blink",
+		FQBN:                    "arduino:avr:mega:cpu=atmega2560",
+		ArduinoAPIVersion:       "10810",
+		// legacy clients sent malformed properties, they are skipped
+		CustomBuildProperties: "build.extra_flags=-DFOO=1,nonsense,compiler.warning_flags=",
+		BuildCachePath:        "/cache",
+		BuildPath:             "/build",
+		WarningsLevel:         "all",
+		CodeCompleteAt:        "/work/Blink/Blink.ino:3:5",
+		Verbose:               true,
+		Overlays:              []*pb.FileOverlay{overlay},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &pb.BuildParamsV2{
+		HardwareFolders:       []string{"/hw1", "/hw2"},
+		ToolsFolders:          []string{"/tools"},
+		OtherLibrariesFolders: []string{"/libs"},
+		SketchLocation:        "/work/Blink",
+		Fqbn: &pb.FQBN{
+			Package:      "arduino",
+			Architecture: "avr",
+			BoardID:      "mega",
+			Options:      []*pb.BoardOption{{Name: "cpu", Value: "atmega2560"}},
+		},
+		ArduinoAPIVersion: "10810",
+		CustomBuildProperties: []*pb.BuildProperty{
+			{Key: "build.extra_flags", Value: "-DFOO=1"},
+			{Key: "compiler.warning_flags", Value: ""},
+		},
+		BuildCachePath: "/cache",
+		BuildPath:      "/build",
+		WarningsLevel:  "all",
+		CodeCompleteAt: "/work/Blink/Blink.ino:3:5",
+		Verbose:        true,
+		Overlays:       []*pb.FileOverlay{overlay},
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("upgradeBuildParams() = %v, want %v", got, want)
+	}
+}
+
+func TestUpgradeBuildParamsInvalidFQBN(t *testing.T) {
+	for _, fqbn := range []string{"", "arduino:avr", "arduino:avr:uno:cpu"} {
+		if _, err := upgradeBuildParams(&pb.BuildParams{FQBN: fqbn}); err == nil {
+			t.Errorf("upgradeBuildParams() accepted the fqbn %q", fqbn)
+		}
+	}
+}
+
+func TestSourceOverrides(t *testing.T) {
+	tmp, err := paths.MkTempDir("", "arduino-builder-overlays")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.RemoveAll()
+	sketchFolder := tmp.Join("Blink")
+	sketchFile := sketchFolder.Join("Blink.ino")
+	if err := sketchFolder.MkdirAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sketchFile.WriteFile([]byte("void setup() {}")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		// want is the key of the override, empty if the overlay is refused
+		want string
+	}{
+		{"sketch file", "Blink.ino", "Blink.ino"},
+		{"subfolder", filepath.Join("src", "util.h"), filepath.Join("src", "util.h")},
+		{"absolute", sketchFolder.Join("src", "util.h").String(), filepath.Join("src", "util.h")},
+		{"through the parent", filepath.Join("src", "..", "Blink.ino"), "Blink.ino"},
+		{"dots in the name", "..util.h", "..util.h"},
+		{"parent folder", "..", ""},
+		{"other sketch", filepath.Join("..", "Other", "Other.ino"), ""},
+		{"absolute outside", tmp.Join("Other", "Other.ino").String(), ""},
+		{"empty", "", ""},
+	}
+	for _, sketchLocation := range []*paths.Path{sketchFolder, sketchFile} {
+		for _, test := range tests {
+			overlays := []*pb.FileOverlay{{Path: test.path, Contents: "int x;"}}
+			got, err := sourceOverrides(sketchLocation, overlays)
+			if test.want == "" {
+				if err == nil {
+					t.Errorf("%s of %s: got %v, want an error", test.name, sketchLocation, got)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s of %s: %s", test.name, sketchLocation, err)
+			} else if len(got) != 1 || got[test.want] != "int x;" {
+				t.Errorf("%s of %s: got %v, want %s overridden", test.name, sketchLocation, got, test.want)
+			}
+		}
+	}
+
+	if got, err := sourceOverrides(nil, nil); got != nil || err != nil {
+		t.Errorf("got %v, %v without overlays, want nothing", got, err)
+	}
+	if _, err := sourceOverrides(nil, []*pb.FileOverlay{{Path: "Blink.ino"}}); err == nil {
+		t.Error("accepted overlays without a sketch location")
+	}
+}
@@ -5,12 +5,54 @@
 Package proto is a generated protocol buffer package.
 
 It is generated from these files:
+
 	builder.proto
 
 It has these top-level messages:
+
 	BuildParams
+	BuildParamsV2
+	FileOverlay
+	FQBN
+	BoardOption
+	BuildProperty
 	VerboseParams
 	Response
+	BuildEvent
+	LogRecord
+	CompilerDiagnostic
+	SourceLocation
+	DiagnosticOrigin
+	Progress
+	Artifact
+	BuildResult
+	FilesChanged
+	ExecutableSectionSize
+	CompletionList
+	Completion
+	PreprocessResult
+	LineMapping
+	BuildProperties
+	ShutdownParams
+	VersionParams
+	Version
+	CapabilitiesParams
+	Capabilities
+	ExperimentalFeature
+	ListJobsParams
+	JobList
+	Job
+	CancelJobParams
+	RemoteBuildRequest
+	RemoteBuildParams
+	DownloadArtifactParams
+	FileChunk
+	DeleteRemoteBuildParams
+	WarmupParams
+	WarmupEvent
+	DropCacheParams
+	DropCacheResult
+	DroppedCache
 */
 package proto
 
@@ -34,20 +76,285 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto1.ProtoPackageIsVersion2 // please upgrade the proto package
 
+// JobPriority decides the order queued jobs are started in. Interactive
+// jobs can also start when the daemon is already running as many builds as
+// it is allowed to, up to as many interactive jobs as builds.
+type JobPriority int32
+
+const (
+	JobPriority_DEFAULT_PRIORITY JobPriority = 0
+	JobPriority_INTERACTIVE      JobPriority = 1
+	JobPriority_VERIFY           JobPriority = 2
+	JobPriority_BATCH            JobPriority = 3
+)
+
+var JobPriority_name = map[int32]string{
+	0: "DEFAULT_PRIORITY",
+	1: "INTERACTIVE",
+	2: "VERIFY",
+	3: "BATCH",
+}
+var JobPriority_value = map[string]int32{
+	"DEFAULT_PRIORITY": 0,
+	"INTERACTIVE":      1,
+	"VERIFY":           2,
+	"BATCH":            3,
+}
+
+func (x JobPriority) String() string {
+	return proto1.EnumName(JobPriority_name, int32(x))
+}
+func (JobPriority) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+type BuildPhase int32
+
+const (
+	BuildPhase_UNKNOWN_PHASE         BuildPhase = 0
+	BuildPhase_DETECTING_LIBRARIES   BuildPhase = 1
+	BuildPhase_GENERATING_PROTOTYPES BuildPhase = 2
+	BuildPhase_COMPILING_SKETCH      BuildPhase = 3
+	BuildPhase_COMPILING_LIBRARIES   BuildPhase = 4
+	BuildPhase_COMPILING_CORE        BuildPhase = 5
+	BuildPhase_LINKING               BuildPhase = 6
+)
+
+var BuildPhase_name = map[int32]string{
+	0: "UNKNOWN_PHASE",
+	1: "DETECTING_LIBRARIES",
+	2: "GENERATING_PROTOTYPES",
+	3: "COMPILING_SKETCH",
+	4: "COMPILING_LIBRARIES",
+	5: "COMPILING_CORE",
+	6: "LINKING",
+}
+var BuildPhase_value = map[string]int32{
+	"UNKNOWN_PHASE":         0,
+	"DETECTING_LIBRARIES":   1,
+	"GENERATING_PROTOTYPES": 2,
+	"COMPILING_SKETCH":      3,
+	"COMPILING_LIBRARIES":   4,
+	"COMPILING_CORE":        5,
+	"LINKING":               6,
+}
+
+func (x BuildPhase) String() string {
+	return proto1.EnumName(BuildPhase_name, int32(x))
+}
+func (BuildPhase) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+type LogRecord_Level int32
+
+const (
+	LogRecord_INFO  LogRecord_Level = 0
+	LogRecord_DEBUG LogRecord_Level = 1
+	LogRecord_WARN  LogRecord_Level = 2
+	LogRecord_ERROR LogRecord_Level = 3
+)
+
+var LogRecord_Level_name = map[int32]string{
+	0: "INFO",
+	1: "DEBUG",
+	2: "WARN",
+	3: "ERROR",
+}
+var LogRecord_Level_value = map[string]int32{
+	"INFO":  0,
+	"DEBUG": 1,
+	"WARN":  2,
+	"ERROR": 3,
+}
+
+func (x LogRecord_Level) String() string {
+	return proto1.EnumName(LogRecord_Level_name, int32(x))
+}
+func (LogRecord_Level) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{9, 0} }
+
+type CompilerDiagnostic_Severity int32
+
+const (
+	CompilerDiagnostic_ERROR       CompilerDiagnostic_Severity = 0
+	CompilerDiagnostic_FATAL_ERROR CompilerDiagnostic_Severity = 1
+	CompilerDiagnostic_WARNING     CompilerDiagnostic_Severity = 2
+	CompilerDiagnostic_NOTE        CompilerDiagnostic_Severity = 3
+)
+
+var CompilerDiagnostic_Severity_name = map[int32]string{
+	0: "ERROR",
+	1: "FATAL_ERROR",
+	2: "WARNING",
+	3: "NOTE",
+}
+var CompilerDiagnostic_Severity_value = map[string]int32{
+	"ERROR":       0,
+	"FATAL_ERROR": 1,
+	"WARNING":     2,
+	"NOTE":        3,
+}
+
+func (x CompilerDiagnostic_Severity) String() string {
+	return proto1.EnumName(CompilerDiagnostic_Severity_name, int32(x))
+}
+func (CompilerDiagnostic_Severity) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{10, 0}
+}
+
+type Completion_Kind int32
+
+const (
+	Completion_TEXT     Completion_Kind = 0
+	Completion_FUNCTION Completion_Kind = 1
+	Completion_VARIABLE Completion_Kind = 2
+	Completion_MACRO    Completion_Kind = 3
+	// a code pattern, e.g. a for loop or a class declaration
+	Completion_PATTERN Completion_Kind = 4
+	// an overload of the function being called
+	Completion_OVERLOAD Completion_Kind = 5
+)
+
+var Completion_Kind_name = map[int32]string{
+	0: "TEXT",
+	1: "FUNCTION",
+	2: "VARIABLE",
+	3: "MACRO",
+	4: "PATTERN",
+	5: "OVERLOAD",
+}
+var Completion_Kind_value = map[string]int32{
+	"TEXT":     0,
+	"FUNCTION": 1,
+	"VARIABLE": 2,
+	"MACRO":    3,
+	"PATTERN":  4,
+	"OVERLOAD": 5,
+}
+
+func (x Completion_Kind) String() string {
+	return proto1.EnumName(Completion_Kind_name, int32(x))
+}
+func (Completion_Kind) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{19, 0} }
+
+type Job_State int32
+
+const (
+	Job_QUEUED  Job_State = 0
+	Job_RUNNING Job_State = 1
+)
+
+var Job_State_name = map[int32]string{
+	0: "QUEUED",
+	1: "RUNNING",
+}
+var Job_State_value = map[string]int32{
+	"QUEUED":  0,
+	"RUNNING": 1,
+}
+
+func (x Job_State) String() string {
+	return proto1.EnumName(Job_State_name, int32(x))
+}
+func (Job_State) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{31, 0} }
+
+type RemoteBuildParams_ArchiveFormat int32
+
+const (
+	RemoteBuildParams_ZIP    RemoteBuildParams_ArchiveFormat = 0
+	RemoteBuildParams_TAR    RemoteBuildParams_ArchiveFormat = 1
+	RemoteBuildParams_TAR_GZ RemoteBuildParams_ArchiveFormat = 2
+)
+
+var RemoteBuildParams_ArchiveFormat_name = map[int32]string{
+	0: "ZIP",
+	1: "TAR",
+	2: "TAR_GZ",
+}
+var RemoteBuildParams_ArchiveFormat_value = map[string]int32{
+	"ZIP":    0,
+	"TAR":    1,
+	"TAR_GZ": 2,
+}
+
+func (x RemoteBuildParams_ArchiveFormat) String() string {
+	return proto1.EnumName(RemoteBuildParams_ArchiveFormat_name, int32(x))
+}
+func (RemoteBuildParams_ArchiveFormat) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{34, 0}
+}
+
+type WarmupEvent_Step int32
+
+const (
+	WarmupEvent_LOADING_HARDWARE WarmupEvent_Step = 0
+	WarmupEvent_COMPILING_CORE   WarmupEvent_Step = 1
+	WarmupEvent_DONE             WarmupEvent_Step = 2
+)
+
+var WarmupEvent_Step_name = map[int32]string{
+	0: "LOADING_HARDWARE",
+	1: "COMPILING_CORE",
+	2: "DONE",
+}
+var WarmupEvent_Step_value = map[string]int32{
+	"LOADING_HARDWARE": 0,
+	"COMPILING_CORE":   1,
+	"DONE":             2,
+}
+
+func (x WarmupEvent_Step) String() string {
+	return proto1.EnumName(WarmupEvent_Step_name, int32(x))
+}
+func (WarmupEvent_Step) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{39, 0} }
+
+type DropCacheParams_Scope int32
+
+const (
+	// the hardware and the tools parsed from their folders, which are
+	// loaded together
+	DropCacheParams_HARDWARE DropCacheParams_Scope = 0
+	// the libraries indexes
+	DropCacheParams_LIBRARIES DropCacheParams_Scope = 1
+	// the core archives compiled into buildCachePath
+	DropCacheParams_CORES DropCacheParams_Scope = 2
+	// the whole buildPath, which must contain a build.options.json and
+	// have been used by a build of the daemon
+	DropCacheParams_BUILD_PATH DropCacheParams_Scope = 3
+)
+
+var DropCacheParams_Scope_name = map[int32]string{
+	0: "HARDWARE",
+	1: "LIBRARIES",
+	2: "CORES",
+	3: "BUILD_PATH",
+}
+var DropCacheParams_Scope_value = map[string]int32{
+	"HARDWARE":   0,
+	"LIBRARIES":  1,
+	"CORES":      2,
+	"BUILD_PATH": 3,
+}
+
+func (x DropCacheParams_Scope) String() string {
+	return proto1.EnumName(DropCacheParams_Scope_name, int32(x))
+}
+func (DropCacheParams_Scope) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{40, 0} }
+
+// BuildParams packs folder lists and custom build properties into comma
+// separated strings, so it can't carry values containing a comma.
+// New clients should use BuildParamsV2 instead.
 type BuildParams struct {
-	HardwareFolders         string `protobuf:"bytes,1,opt,name=hardwareFolders" json:"hardwareFolders,omitempty"`
-	ToolsFolders            string `protobuf:"bytes,2,opt,name=toolsFolders" json:"toolsFolders,omitempty"`
-	BuiltInLibrariesFolders string `protobuf:"bytes,3,opt,name=builtInLibrariesFolders" json:"builtInLibrariesFolders,omitempty"`
-	OtherLibrariesFolders   string `protobuf:"bytes,4,opt,name=otherLibrariesFolders" json:"otherLibrariesFolders,omitempty"`
-	SketchLocation          string `protobuf:"bytes,5,opt,name=sketchLocation" json:"sketchLocation,omitempty"`
-	FQBN                    string `protobuf:"bytes,6,opt,name=fQBN" json:"fQBN,omitempty"`
-	ArduinoAPIVersion       string `protobuf:"bytes,7,opt,name=arduinoAPIVersion" json:"arduinoAPIVersion,omitempty"`
-	CustomBuildProperties   string `protobuf:"bytes,8,opt,name=customBuildProperties" json:"customBuildProperties,omitempty"`
-	BuildCachePath          string `protobuf:"bytes,9,opt,name=buildCachePath" json:"buildCachePath,omitempty"`
-	BuildPath               string `protobuf:"bytes,10,opt,name=buildPath" json:"buildPath,omitempty"`
-	WarningsLevel           string `protobuf:"bytes,11,opt,name=warningsLevel" json:"warningsLevel,omitempty"`
-	CodeCompleteAt          string `protobuf:"bytes,12,opt,name=codeCompleteAt" json:"codeCompleteAt,omitempty"`
-	Verbose                 bool   `protobuf:"varint,13,opt,name=verbose" json:"verbose,omitempty"`
+	HardwareFolders         string         `protobuf:"bytes,1,opt,name=hardwareFolders" json:"hardwareFolders,omitempty"`
+	ToolsFolders            string         `protobuf:"bytes,2,opt,name=toolsFolders" json:"toolsFolders,omitempty"`
+	BuiltInLibrariesFolders string         `protobuf:"bytes,3,opt,name=builtInLibrariesFolders" json:"builtInLibrariesFolders,omitempty"`
+	OtherLibrariesFolders   string         `protobuf:"bytes,4,opt,name=otherLibrariesFolders" json:"otherLibrariesFolders,omitempty"`
+	SketchLocation          string         `protobuf:"bytes,5,opt,name=sketchLocation" json:"sketchLocation,omitempty"`
+	FQBN                    string         `protobuf:"bytes,6,opt,name=fQBN" json:"fQBN,omitempty"`
+	ArduinoAPIVersion       string         `protobuf:"bytes,7,opt,name=arduinoAPIVersion" json:"arduinoAPIVersion,omitempty"`
+	CustomBuildProperties   string         `protobuf:"bytes,8,opt,name=customBuildProperties" json:"customBuildProperties,omitempty"`
+	BuildCachePath          string         `protobuf:"bytes,9,opt,name=buildCachePath" json:"buildCachePath,omitempty"`
+	BuildPath               string         `protobuf:"bytes,10,opt,name=buildPath" json:"buildPath,omitempty"`
+	WarningsLevel           string         `protobuf:"bytes,11,opt,name=warningsLevel" json:"warningsLevel,omitempty"`
+	CodeCompleteAt          string         `protobuf:"bytes,12,opt,name=codeCompleteAt" json:"codeCompleteAt,omitempty"`
+	Verbose                 bool           `protobuf:"varint,13,opt,name=verbose" json:"verbose,omitempty"`
+	Overlays                []*FileOverlay `protobuf:"bytes,14,rep,name=overlays" json:"overlays,omitempty"`
 }
 
 func (m *BuildParams) Reset()                    { *m = BuildParams{} }
@@ -146,167 +453,2318 @@ func (m *BuildParams) GetVerbose() bool {
 	return false
 }
 
-type VerboseParams struct {
-	Verbose bool `protobuf:"varint,1,opt,name=verbose" json:"verbose,omitempty"`
+func (m *BuildParams) GetOverlays() []*FileOverlay {
+	if m != nil {
+		return m.Overlays
+	}
+	return nil
 }
 
-func (m *VerboseParams) Reset()                    { *m = VerboseParams{} }
-func (m *VerboseParams) String() string            { return proto1.CompactTextString(m) }
-func (*VerboseParams) ProtoMessage()               {}
-func (*VerboseParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+type BuildParamsV2 struct {
+	HardwareFolders         []string `protobuf:"bytes,1,rep,name=hardwareFolders" json:"hardwareFolders,omitempty"`
+	ToolsFolders            []string `protobuf:"bytes,2,rep,name=toolsFolders" json:"toolsFolders,omitempty"`
+	BuiltInLibrariesFolders []string `protobuf:"bytes,3,rep,name=builtInLibrariesFolders" json:"builtInLibrariesFolders,omitempty"`
+	OtherLibrariesFolders   []string `protobuf:"bytes,4,rep,name=otherLibrariesFolders" json:"otherLibrariesFolders,omitempty"`
+	SketchLocation          string   `protobuf:"bytes,5,opt,name=sketchLocation" json:"sketchLocation,omitempty"`
+	Fqbn                    *FQBN    `protobuf:"bytes,6,opt,name=fqbn" json:"fqbn,omitempty"`
+	ArduinoAPIVersion       string   `protobuf:"bytes,7,opt,name=arduinoAPIVersion" json:"arduinoAPIVersion,omitempty"`
+	// kept in order, since later properties may refer to earlier ones
+	CustomBuildProperties []*BuildProperty `protobuf:"bytes,8,rep,name=customBuildProperties" json:"customBuildProperties,omitempty"`
+	BuildCachePath        string           `protobuf:"bytes,9,opt,name=buildCachePath" json:"buildCachePath,omitempty"`
+	BuildPath             string           `protobuf:"bytes,10,opt,name=buildPath" json:"buildPath,omitempty"`
+	WarningsLevel         string           `protobuf:"bytes,11,opt,name=warningsLevel" json:"warningsLevel,omitempty"`
+	CodeCompleteAt        string           `protobuf:"bytes,12,opt,name=codeCompleteAt" json:"codeCompleteAt,omitempty"`
+	Verbose               bool             `protobuf:"varint,13,opt,name=verbose" json:"verbose,omitempty"`
+	// contents to use in place of the ones on disk, e.g. for the unsaved
+	// buffers of an editor
+	Overlays []*FileOverlay `protobuf:"bytes,14,rep,name=overlays" json:"overlays,omitempty"`
+	// defaults to INTERACTIVE for completions, preprocessings and properties
+	// dumps, to VERIFY for builds, which can't run as INTERACTIVE
+	Priority JobPriority `protobuf:"varint,15,opt,name=priority,enum=proto.JobPriority" json:"priority,omitempty"`
+	// selects the vid/pid specific build properties of the board, as defined
+	// in boards.txt
+	VidPid string `protobuf:"bytes,16,opt,name=vidPid" json:"vidPid,omitempty"`
+}
 
-func (m *VerboseParams) GetVerbose() bool {
+func (m *BuildParamsV2) Reset()                    { *m = BuildParamsV2{} }
+func (m *BuildParamsV2) String() string            { return proto1.CompactTextString(m) }
+func (*BuildParamsV2) ProtoMessage()               {}
+func (*BuildParamsV2) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *BuildParamsV2) GetHardwareFolders() []string {
 	if m != nil {
-		return m.Verbose
+		return m.HardwareFolders
 	}
-	return false
+	return nil
 }
 
-type Response struct {
-	Line string `protobuf:"bytes,1,opt,name=line" json:"line,omitempty"`
+func (m *BuildParamsV2) GetToolsFolders() []string {
+	if m != nil {
+		return m.ToolsFolders
+	}
+	return nil
 }
 
-func (m *Response) Reset()                    { *m = Response{} }
-func (m *Response) String() string            { return proto1.CompactTextString(m) }
-func (*Response) ProtoMessage()               {}
-func (*Response) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (m *BuildParamsV2) GetBuiltInLibrariesFolders() []string {
+	if m != nil {
+		return m.BuiltInLibrariesFolders
+	}
+	return nil
+}
 
-func (m *Response) GetLine() string {
+func (m *BuildParamsV2) GetOtherLibrariesFolders() []string {
 	if m != nil {
-		return m.Line
+		return m.OtherLibrariesFolders
+	}
+	return nil
+}
+
+func (m *BuildParamsV2) GetSketchLocation() string {
+	if m != nil {
+		return m.SketchLocation
 	}
 	return ""
 }
 
-func init() {
-	proto1.RegisterType((*BuildParams)(nil), "proto.BuildParams")
-	proto1.RegisterType((*VerboseParams)(nil), "proto.VerboseParams")
-	proto1.RegisterType((*Response)(nil), "proto.Response")
+func (m *BuildParamsV2) GetFqbn() *FQBN {
+	if m != nil {
+		return m.Fqbn
+	}
+	return nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (m *BuildParamsV2) GetArduinoAPIVersion() string {
+	if m != nil {
+		return m.ArduinoAPIVersion
+	}
+	return ""
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *BuildParamsV2) GetCustomBuildProperties() []*BuildProperty {
+	if m != nil {
+		return m.CustomBuildProperties
+	}
+	return nil
+}
 
-// Client API for Builder service
+func (m *BuildParamsV2) GetBuildCachePath() string {
+	if m != nil {
+		return m.BuildCachePath
+	}
+	return ""
+}
 
-type BuilderClient interface {
-	// A server-to-client streaming RPC.
-	//
-	// Obtains the Features available within the given Rectangle.  Results are
-	// streamed rather than returned at once (e.g. in a response message with a
-	// repeated field), as the rectangle may cover a large area and contain a
-	// huge number of features.
-	Build(ctx context.Context, in *BuildParams, opts ...grpc.CallOption) (Builder_BuildClient, error)
-	Autocomplete(ctx context.Context, in *BuildParams, opts ...grpc.CallOption) (*Response, error)
-	DropCache(ctx context.Context, in *VerboseParams, opts ...grpc.CallOption) (*Response, error)
+func (m *BuildParamsV2) GetBuildPath() string {
+	if m != nil {
+		return m.BuildPath
+	}
+	return ""
 }
 
-type builderClient struct {
-	cc *grpc.ClientConn
+func (m *BuildParamsV2) GetWarningsLevel() string {
+	if m != nil {
+		return m.WarningsLevel
+	}
+	return ""
 }
 
-func NewBuilderClient(cc *grpc.ClientConn) BuilderClient {
-	return &builderClient{cc}
+func (m *BuildParamsV2) GetCodeCompleteAt() string {
+	if m != nil {
+		return m.CodeCompleteAt
+	}
+	return ""
 }
 
-func (c *builderClient) Build(ctx context.Context, in *BuildParams, opts ...grpc.CallOption) (Builder_BuildClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_Builder_serviceDesc.Streams[0], c.cc, "/proto.Builder/Build", opts...)
-	if err != nil {
-		return nil, err
+func (m *BuildParamsV2) GetVerbose() bool {
+	if m != nil {
+		return m.Verbose
 	}
-	x := &builderBuildClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	return false
+}
+
+func (m *BuildParamsV2) GetOverlays() []*FileOverlay {
+	if m != nil {
+		return m.Overlays
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	return nil
+}
+
+func (m *BuildParamsV2) GetPriority() JobPriority {
+	if m != nil {
+		return m.Priority
 	}
-	return x, nil
+	return JobPriority_DEFAULT_PRIORITY
 }
 
-type Builder_BuildClient interface {
-	Recv() (*Response, error)
-	grpc.ClientStream
+func (m *BuildParamsV2) GetVidPid() string {
+	if m != nil {
+		return m.VidPid
+	}
+	return ""
 }
 
-type builderBuildClient struct {
-	grpc.ClientStream
+// FileOverlay replaces the contents of a sketch file during a build, without
+// modifying the file itself
+type FileOverlay struct {
+	// absolute, or relative to the sketch folder. Only files inside the
+	// sketch folder can be overlaid.
+	Path     string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	Contents string `protobuf:"bytes,2,opt,name=contents" json:"contents,omitempty"`
 }
 
-func (x *builderBuildClient) Recv() (*Response, error) {
-	m := new(Response)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *FileOverlay) Reset()                    { *m = FileOverlay{} }
+func (m *FileOverlay) String() string            { return proto1.CompactTextString(m) }
+func (*FileOverlay) ProtoMessage()               {}
+func (*FileOverlay) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *FileOverlay) GetPath() string {
+	if m != nil {
+		return m.Path
 	}
-	return m, nil
+	return ""
 }
 
-func (c *builderClient) Autocomplete(ctx context.Context, in *BuildParams, opts ...grpc.CallOption) (*Response, error) {
-	out := new(Response)
-	err := grpc.Invoke(ctx, "/proto.Builder/Autocomplete", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *FileOverlay) GetContents() string {
+	if m != nil {
+		return m.Contents
 	}
-	return out, nil
+	return ""
 }
 
-func (c *builderClient) DropCache(ctx context.Context, in *VerboseParams, opts ...grpc.CallOption) (*Response, error) {
-	out := new(Response)
-	err := grpc.Invoke(ctx, "/proto.Builder/DropCache", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+// FQBN is a fully qualified board name, e.g. arduino:avr:mega:cpu=atmega2560
+type FQBN struct {
+	Package      string         `protobuf:"bytes,1,opt,name=package" json:"package,omitempty"`
+	Architecture string         `protobuf:"bytes,2,opt,name=architecture" json:"architecture,omitempty"`
+	BoardID      string         `protobuf:"bytes,3,opt,name=boardID" json:"boardID,omitempty"`
+	Options      []*BoardOption `protobuf:"bytes,4,rep,name=options" json:"options,omitempty"`
 }
 
-// Server API for Builder service
+func (m *FQBN) Reset()                    { *m = FQBN{} }
+func (m *FQBN) String() string            { return proto1.CompactTextString(m) }
+func (*FQBN) ProtoMessage()               {}
+func (*FQBN) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
-type BuilderServer interface {
-	// A server-to-client streaming RPC.
-	//
-	// Obtains the Features available within the given Rectangle.  Results are
-	// streamed rather than returned at once (e.g. in a response message with a
-	// repeated field), as the rectangle may cover a large area and contain a
-	// huge number of features.
-	Build(*BuildParams, Builder_BuildServer) error
-	Autocomplete(context.Context, *BuildParams) (*Response, error)
-	DropCache(context.Context, *VerboseParams) (*Response, error)
+func (m *FQBN) GetPackage() string {
+	if m != nil {
+		return m.Package
+	}
+	return ""
 }
 
-func RegisterBuilderServer(s *grpc.Server, srv BuilderServer) {
-	s.RegisterService(&_Builder_serviceDesc, srv)
+func (m *FQBN) GetArchitecture() string {
+	if m != nil {
+		return m.Architecture
+	}
+	return ""
 }
 
-func _Builder_Build_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(BuildParams)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func (m *FQBN) GetBoardID() string {
+	if m != nil {
+		return m.BoardID
 	}
-	return srv.(BuilderServer).Build(m, &builderBuildServer{stream})
+	return ""
 }
 
-type Builder_BuildServer interface {
-	Send(*Response) error
-	grpc.ServerStream
+func (m *FQBN) GetOptions() []*BoardOption {
+	if m != nil {
+		return m.Options
+	}
+	return nil
 }
 
-type builderBuildServer struct {
-	grpc.ServerStream
+type BoardOption struct {
+	Name  string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
 }
 
-func (x *builderBuildServer) Send(m *Response) error {
-	return x.ServerStream.SendMsg(m)
+func (m *BoardOption) Reset()                    { *m = BoardOption{} }
+func (m *BoardOption) String() string            { return proto1.CompactTextString(m) }
+func (*BoardOption) ProtoMessage()               {}
+func (*BoardOption) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *BoardOption) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
 }
 
-func _Builder_Autocomplete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(BuildParams)
-	if err := dec(in); err != nil {
-		return nil, err
+func (m *BoardOption) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type BuildProperty struct {
+	Key   string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *BuildProperty) Reset()                    { *m = BuildProperty{} }
+func (m *BuildProperty) String() string            { return proto1.CompactTextString(m) }
+func (*BuildProperty) ProtoMessage()               {}
+func (*BuildProperty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *BuildProperty) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *BuildProperty) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type VerboseParams struct {
+	Verbose bool `protobuf:"varint,1,opt,name=verbose" json:"verbose,omitempty"`
+}
+
+func (m *VerboseParams) Reset()                    { *m = VerboseParams{} }
+func (m *VerboseParams) String() string            { return proto1.CompactTextString(m) }
+func (*VerboseParams) ProtoMessage()               {}
+func (*VerboseParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *VerboseParams) GetVerbose() bool {
+	if m != nil {
+		return m.Verbose
+	}
+	return false
+}
+
+type Response struct {
+	Line string `protobuf:"bytes,1,opt,name=line" json:"line,omitempty"`
+}
+
+func (m *Response) Reset()                    { *m = Response{} }
+func (m *Response) String() string            { return proto1.CompactTextString(m) }
+func (*Response) ProtoMessage()               {}
+func (*Response) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *Response) GetLine() string {
+	if m != nil {
+		return m.Line
+	}
+	return ""
+}
+
+type BuildEvent struct {
+	// Types that are valid to be assigned to Event:
+	//	*BuildEvent_Log
+	//	*BuildEvent_Diagnostic
+	//	*BuildEvent_Progress
+	//	*BuildEvent_Artifact
+	//	*BuildEvent_Result
+	//	*BuildEvent_Changed
+	Event isBuildEvent_Event `protobuf_oneof:"event"`
+}
+
+func (m *BuildEvent) Reset()                    { *m = BuildEvent{} }
+func (m *BuildEvent) String() string            { return proto1.CompactTextString(m) }
+func (*BuildEvent) ProtoMessage()               {}
+func (*BuildEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+type isBuildEvent_Event interface{ isBuildEvent_Event() }
+
+type BuildEvent_Log struct {
+	Log *LogRecord `protobuf:"bytes,1,opt,name=log,oneof"`
+}
+type BuildEvent_Diagnostic struct {
+	Diagnostic *CompilerDiagnostic `protobuf:"bytes,2,opt,name=diagnostic,oneof"`
+}
+type BuildEvent_Progress struct {
+	Progress *Progress `protobuf:"bytes,3,opt,name=progress,oneof"`
+}
+type BuildEvent_Artifact struct {
+	Artifact *Artifact `protobuf:"bytes,4,opt,name=artifact,oneof"`
+}
+type BuildEvent_Result struct {
+	Result *BuildResult `protobuf:"bytes,5,opt,name=result,oneof"`
+}
+type BuildEvent_Changed struct {
+	Changed *FilesChanged `protobuf:"bytes,6,opt,name=changed,oneof"`
+}
+
+func (*BuildEvent_Log) isBuildEvent_Event()        {}
+func (*BuildEvent_Diagnostic) isBuildEvent_Event() {}
+func (*BuildEvent_Progress) isBuildEvent_Event()   {}
+func (*BuildEvent_Artifact) isBuildEvent_Event()   {}
+func (*BuildEvent_Result) isBuildEvent_Event()     {}
+func (*BuildEvent_Changed) isBuildEvent_Event()    {}
+
+func (m *BuildEvent) GetEvent() isBuildEvent_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (m *BuildEvent) GetLog() *LogRecord {
+	if x, ok := m.GetEvent().(*BuildEvent_Log); ok {
+		return x.Log
+	}
+	return nil
+}
+
+func (m *BuildEvent) GetDiagnostic() *CompilerDiagnostic {
+	if x, ok := m.GetEvent().(*BuildEvent_Diagnostic); ok {
+		return x.Diagnostic
+	}
+	return nil
+}
+
+func (m *BuildEvent) GetProgress() *Progress {
+	if x, ok := m.GetEvent().(*BuildEvent_Progress); ok {
+		return x.Progress
+	}
+	return nil
+}
+
+func (m *BuildEvent) GetArtifact() *Artifact {
+	if x, ok := m.GetEvent().(*BuildEvent_Artifact); ok {
+		return x.Artifact
+	}
+	return nil
+}
+
+func (m *BuildEvent) GetResult() *BuildResult {
+	if x, ok := m.GetEvent().(*BuildEvent_Result); ok {
+		return x.Result
+	}
+	return nil
+}
+
+func (m *BuildEvent) GetChanged() *FilesChanged {
+	if x, ok := m.GetEvent().(*BuildEvent_Changed); ok {
+		return x.Changed
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*BuildEvent) XXX_OneofFuncs() (func(msg proto1.Message, b *proto1.Buffer) error, func(msg proto1.Message, tag, wire int, b *proto1.Buffer) (bool, error), func(msg proto1.Message) (n int), []interface{}) {
+	return _BuildEvent_OneofMarshaler, _BuildEvent_OneofUnmarshaler, _BuildEvent_OneofSizer, []interface{}{
+		(*BuildEvent_Log)(nil),
+		(*BuildEvent_Diagnostic)(nil),
+		(*BuildEvent_Progress)(nil),
+		(*BuildEvent_Artifact)(nil),
+		(*BuildEvent_Result)(nil),
+		(*BuildEvent_Changed)(nil),
+	}
+}
+
+func _BuildEvent_OneofMarshaler(msg proto1.Message, b *proto1.Buffer) error {
+	m := msg.(*BuildEvent)
+	// event
+	switch x := m.Event.(type) {
+	case *BuildEvent_Log:
+		b.EncodeVarint(1<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.Log); err != nil {
+			return err
+		}
+	case *BuildEvent_Diagnostic:
+		b.EncodeVarint(2<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.Diagnostic); err != nil {
+			return err
+		}
+	case *BuildEvent_Progress:
+		b.EncodeVarint(3<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.Progress); err != nil {
+			return err
+		}
+	case *BuildEvent_Artifact:
+		b.EncodeVarint(4<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.Artifact); err != nil {
+			return err
+		}
+	case *BuildEvent_Result:
+		b.EncodeVarint(5<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.Result); err != nil {
+			return err
+		}
+	case *BuildEvent_Changed:
+		b.EncodeVarint(6<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.Changed); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("BuildEvent.Event has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _BuildEvent_OneofUnmarshaler(msg proto1.Message, tag, wire int, b *proto1.Buffer) (bool, error) {
+	m := msg.(*BuildEvent)
+	switch tag {
+	case 1: // event.log
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(LogRecord)
+		err := b.DecodeMessage(msg)
+		m.Event = &BuildEvent_Log{msg}
+		return true, err
+	case 2: // event.diagnostic
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(CompilerDiagnostic)
+		err := b.DecodeMessage(msg)
+		m.Event = &BuildEvent_Diagnostic{msg}
+		return true, err
+	case 3: // event.progress
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(Progress)
+		err := b.DecodeMessage(msg)
+		m.Event = &BuildEvent_Progress{msg}
+		return true, err
+	case 4: // event.artifact
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(Artifact)
+		err := b.DecodeMessage(msg)
+		m.Event = &BuildEvent_Artifact{msg}
+		return true, err
+	case 5: // event.result
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(BuildResult)
+		err := b.DecodeMessage(msg)
+		m.Event = &BuildEvent_Result{msg}
+		return true, err
+	case 6: // event.changed
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(FilesChanged)
+		err := b.DecodeMessage(msg)
+		m.Event = &BuildEvent_Changed{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _BuildEvent_OneofSizer(msg proto1.Message) (n int) {
+	m := msg.(*BuildEvent)
+	// event
+	switch x := m.Event.(type) {
+	case *BuildEvent_Log:
+		s := proto1.Size(x.Log)
+		n += proto1.SizeVarint(1<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *BuildEvent_Diagnostic:
+		s := proto1.Size(x.Diagnostic)
+		n += proto1.SizeVarint(2<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *BuildEvent_Progress:
+		s := proto1.Size(x.Progress)
+		n += proto1.SizeVarint(3<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *BuildEvent_Artifact:
+		s := proto1.Size(x.Artifact)
+		n += proto1.SizeVarint(4<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *BuildEvent_Result:
+		s := proto1.Size(x.Result)
+		n += proto1.SizeVarint(5<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *BuildEvent_Changed:
+		s := proto1.Size(x.Changed)
+		n += proto1.SizeVarint(6<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type LogRecord struct {
+	Level LogRecord_Level `protobuf:"varint,1,opt,name=level,enum=proto.LogRecord_Level" json:"level,omitempty"`
+	// the untranslated format of the message, empty for compiler output
+	MessageID string   `protobuf:"bytes,2,opt,name=messageID" json:"messageID,omitempty"`
+	Message   string   `protobuf:"bytes,3,opt,name=message" json:"message,omitempty"`
+	Arguments []string `protobuf:"bytes,4,rep,name=arguments" json:"arguments,omitempty"`
+}
+
+func (m *LogRecord) Reset()                    { *m = LogRecord{} }
+func (m *LogRecord) String() string            { return proto1.CompactTextString(m) }
+func (*LogRecord) ProtoMessage()               {}
+func (*LogRecord) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *LogRecord) GetLevel() LogRecord_Level {
+	if m != nil {
+		return m.Level
+	}
+	return LogRecord_INFO
+}
+
+func (m *LogRecord) GetMessageID() string {
+	if m != nil {
+		return m.MessageID
+	}
+	return ""
+}
+
+func (m *LogRecord) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *LogRecord) GetArguments() []string {
+	if m != nil {
+		return m.Arguments
+	}
+	return nil
+}
+
+type CompilerDiagnostic struct {
+	// the file and line are mapped back to the files of the sketch, rather
+	// than its copy in the build path
+	File string `protobuf:"bytes,1,opt,name=file" json:"file,omitempty"`
+	Line int32  `protobuf:"varint,2,opt,name=line" json:"line,omitempty"`
+	// 0 when the compiler didn't report it
+	Column   int32                       `protobuf:"varint,3,opt,name=column" json:"column,omitempty"`
+	Severity CompilerDiagnostic_Severity `protobuf:"varint,4,opt,name=severity,enum=proto.CompilerDiagnostic_Severity" json:"severity,omitempty"`
+	Message  string                      `protobuf:"bytes,5,opt,name=message" json:"message,omitempty"`
+	// the command line option enabling the warning, like "-Wunused-variable"
+	Option string `protobuf:"bytes,6,opt,name=option" json:"option,omitempty"`
+	// the function or scope the diagnostic is in, like
+	// "In function 'void setup()'"
+	Context string `protobuf:"bytes,7,opt,name=context" json:"context,omitempty"`
+	// the chain of #include leading to file, innermost first
+	IncludedFrom []*SourceLocation `protobuf:"bytes,8,rep,name=includedFrom" json:"includedFrom,omitempty"`
+	// the notes the compiler printed after the diagnostic
+	Notes  []*CompilerDiagnostic `protobuf:"bytes,9,rep,name=notes" json:"notes,omitempty"`
+	Origin *DiagnosticOrigin     `protobuf:"bytes,10,opt,name=origin" json:"origin,omitempty"`
+}
+
+func (m *CompilerDiagnostic) Reset()                    { *m = CompilerDiagnostic{} }
+func (m *CompilerDiagnostic) String() string            { return proto1.CompactTextString(m) }
+func (*CompilerDiagnostic) ProtoMessage()               {}
+func (*CompilerDiagnostic) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *CompilerDiagnostic) GetFile() string {
+	if m != nil {
+		return m.File
+	}
+	return ""
+}
+
+func (m *CompilerDiagnostic) GetLine() int32 {
+	if m != nil {
+		return m.Line
+	}
+	return 0
+}
+
+func (m *CompilerDiagnostic) GetColumn() int32 {
+	if m != nil {
+		return m.Column
+	}
+	return 0
+}
+
+func (m *CompilerDiagnostic) GetSeverity() CompilerDiagnostic_Severity {
+	if m != nil {
+		return m.Severity
+	}
+	return CompilerDiagnostic_ERROR
+}
+
+func (m *CompilerDiagnostic) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *CompilerDiagnostic) GetOption() string {
+	if m != nil {
+		return m.Option
+	}
+	return ""
+}
+
+func (m *CompilerDiagnostic) GetContext() string {
+	if m != nil {
+		return m.Context
+	}
+	return ""
+}
+
+func (m *CompilerDiagnostic) GetIncludedFrom() []*SourceLocation {
+	if m != nil {
+		return m.IncludedFrom
+	}
+	return nil
+}
+
+func (m *CompilerDiagnostic) GetNotes() []*CompilerDiagnostic {
+	if m != nil {
+		return m.Notes
+	}
+	return nil
+}
+
+func (m *CompilerDiagnostic) GetOrigin() *DiagnosticOrigin {
+	if m != nil {
+		return m.Origin
+	}
+	return nil
+}
+
+type SourceLocation struct {
+	File   string `protobuf:"bytes,1,opt,name=file" json:"file,omitempty"`
+	Line   int32  `protobuf:"varint,2,opt,name=line" json:"line,omitempty"`
+	Column int32  `protobuf:"varint,3,opt,name=column" json:"column,omitempty"`
+}
+
+func (m *SourceLocation) Reset()                    { *m = SourceLocation{} }
+func (m *SourceLocation) String() string            { return proto1.CompactTextString(m) }
+func (*SourceLocation) ProtoMessage()               {}
+func (*SourceLocation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *SourceLocation) GetFile() string {
+	if m != nil {
+		return m.File
+	}
+	return ""
+}
+
+func (m *SourceLocation) GetLine() int32 {
+	if m != nil {
+		return m.Line
+	}
+	return 0
+}
+
+func (m *SourceLocation) GetColumn() int32 {
+	if m != nil {
+		return m.Column
+	}
+	return 0
+}
+
+// DiagnosticOrigin tells which part of the build the file of a diagnostic
+// belongs to
+type DiagnosticOrigin struct {
+	// one of "sketch", "library", "core", "variant", "platform" or "other"
+	Kind string `protobuf:"bytes,1,opt,name=kind" json:"kind,omitempty"`
+	// the name of the library, core or variant
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *DiagnosticOrigin) Reset()                    { *m = DiagnosticOrigin{} }
+func (m *DiagnosticOrigin) String() string            { return proto1.CompactTextString(m) }
+func (*DiagnosticOrigin) ProtoMessage()               {}
+func (*DiagnosticOrigin) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *DiagnosticOrigin) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *DiagnosticOrigin) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type Progress struct {
+	Phase   BuildPhase `protobuf:"varint,1,opt,name=phase,enum=proto.BuildPhase" json:"phase,omitempty"`
+	Percent float32    `protobuf:"fixed32,2,opt,name=percent" json:"percent,omitempty"`
+}
+
+func (m *Progress) Reset()                    { *m = Progress{} }
+func (m *Progress) String() string            { return proto1.CompactTextString(m) }
+func (*Progress) ProtoMessage()               {}
+func (*Progress) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *Progress) GetPhase() BuildPhase {
+	if m != nil {
+		return m.Phase
+	}
+	return BuildPhase_UNKNOWN_PHASE
+}
+
+func (m *Progress) GetPercent() float32 {
+	if m != nil {
+		return m.Percent
+	}
+	return 0
+}
+
+type Artifact struct {
+	Path string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	Size int64  `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *Artifact) Reset()                    { *m = Artifact{} }
+func (m *Artifact) String() string            { return proto1.CompactTextString(m) }
+func (*Artifact) ProtoMessage()               {}
+func (*Artifact) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *Artifact) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *Artifact) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type BuildResult struct {
+	Success  bool                     `protobuf:"varint,1,opt,name=success" json:"success,omitempty"`
+	ExitCode int32                    `protobuf:"varint,2,opt,name=exitCode" json:"exitCode,omitempty"`
+	Error    string                   `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+	Sizes    []*ExecutableSectionSize `protobuf:"bytes,4,rep,name=sizes" json:"sizes,omitempty"`
+	// set for remote builds, the id to download the artifacts with
+	BuildID string `protobuf:"bytes,5,opt,name=buildID" json:"buildID,omitempty"`
+}
+
+func (m *BuildResult) Reset()                    { *m = BuildResult{} }
+func (m *BuildResult) String() string            { return proto1.CompactTextString(m) }
+func (*BuildResult) ProtoMessage()               {}
+func (*BuildResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *BuildResult) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *BuildResult) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+func (m *BuildResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *BuildResult) GetSizes() []*ExecutableSectionSize {
+	if m != nil {
+		return m.Sizes
+	}
+	return nil
+}
+
+func (m *BuildResult) GetBuildID() string {
+	if m != nil {
+		return m.BuildID
+	}
+	return ""
+}
+
+type FilesChanged struct {
+	Paths []string `protobuf:"bytes,1,rep,name=paths" json:"paths,omitempty"`
+}
+
+func (m *FilesChanged) Reset()                    { *m = FilesChanged{} }
+func (m *FilesChanged) String() string            { return proto1.CompactTextString(m) }
+func (*FilesChanged) ProtoMessage()               {}
+func (*FilesChanged) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *FilesChanged) GetPaths() []string {
+	if m != nil {
+		return m.Paths
+	}
+	return nil
+}
+
+type ExecutableSectionSize struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Size int64  `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
+	// 0 if the board doesn't define a maximum
+	MaxSize int64 `protobuf:"varint,3,opt,name=maxSize" json:"maxSize,omitempty"`
+}
+
+func (m *ExecutableSectionSize) Reset()                    { *m = ExecutableSectionSize{} }
+func (m *ExecutableSectionSize) String() string            { return proto1.CompactTextString(m) }
+func (*ExecutableSectionSize) ProtoMessage()               {}
+func (*ExecutableSectionSize) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *ExecutableSectionSize) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ExecutableSectionSize) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *ExecutableSectionSize) GetMaxSize() int64 {
+	if m != nil {
+		return m.MaxSize
+	}
+	return 0
+}
+
+type CompletionList struct {
+	Completions []*Completion `protobuf:"bytes,1,rep,name=completions" json:"completions,omitempty"`
+	// the diagnostics reported while preprocessing the sketch
+	Diagnostics []*CompilerDiagnostic `protobuf:"bytes,2,rep,name=diagnostics" json:"diagnostics,omitempty"`
+	// set if the sketch could not be preprocessed
+	Error string `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+	// the completions as printed by the completion tool, which is what the
+	// command line prints with -code-complete-at
+	Output string `protobuf:"bytes,4,opt,name=output" json:"output,omitempty"`
+}
+
+func (m *CompletionList) Reset()                    { *m = CompletionList{} }
+func (m *CompletionList) String() string            { return proto1.CompactTextString(m) }
+func (*CompletionList) ProtoMessage()               {}
+func (*CompletionList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *CompletionList) GetCompletions() []*Completion {
+	if m != nil {
+		return m.Completions
+	}
+	return nil
+}
+
+func (m *CompletionList) GetDiagnostics() []*CompilerDiagnostic {
+	if m != nil {
+		return m.Diagnostics
+	}
+	return nil
+}
+
+func (m *CompletionList) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *CompletionList) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
+type Completion struct {
+	Label string          `protobuf:"bytes,1,opt,name=label" json:"label,omitempty"`
+	Kind  Completion_Kind `protobuf:"varint,2,opt,name=kind,enum=proto.Completion_Kind" json:"kind,omitempty"`
+	// e.g. "digitalWrite(uint8_t pin, uint8_t val)"
+	Signature     string `protobuf:"bytes,3,opt,name=signature" json:"signature,omitempty"`
+	ReturnType    string `protobuf:"bytes,4,opt,name=returnType" json:"returnType,omitempty"`
+	Documentation string `protobuf:"bytes,5,opt,name=documentation" json:"documentation,omitempty"`
+	// the text to insert, with placeholders in the ${1:name} snippet syntax
+	InsertText string `protobuf:"bytes,6,opt,name=insertText" json:"insertText,omitempty"`
+	// lower values come first, completions hidden by others have the highest
+	Priority int32 `protobuf:"varint,7,opt,name=priority" json:"priority,omitempty"`
+}
+
+func (m *Completion) Reset()                    { *m = Completion{} }
+func (m *Completion) String() string            { return proto1.CompactTextString(m) }
+func (*Completion) ProtoMessage()               {}
+func (*Completion) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *Completion) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *Completion) GetKind() Completion_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return Completion_TEXT
+}
+
+func (m *Completion) GetSignature() string {
+	if m != nil {
+		return m.Signature
+	}
+	return ""
+}
+
+func (m *Completion) GetReturnType() string {
+	if m != nil {
+		return m.ReturnType
+	}
+	return ""
+}
+
+func (m *Completion) GetDocumentation() string {
+	if m != nil {
+		return m.Documentation
+	}
+	return ""
+}
+
+func (m *Completion) GetInsertText() string {
+	if m != nil {
+		return m.InsertText
+	}
+	return ""
+}
+
+func (m *Completion) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+type PreprocessResult struct {
+	Source string `protobuf:"bytes,1,opt,name=source" json:"source,omitempty"`
+	// maps the lines of source back to the sketch files
+	LineMap []*LineMapping `protobuf:"bytes,2,rep,name=lineMap" json:"lineMap,omitempty"`
+}
+
+func (m *PreprocessResult) Reset()                    { *m = PreprocessResult{} }
+func (m *PreprocessResult) String() string            { return proto1.CompactTextString(m) }
+func (*PreprocessResult) ProtoMessage()               {}
+func (*PreprocessResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *PreprocessResult) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *PreprocessResult) GetLineMap() []*LineMapping {
+	if m != nil {
+		return m.LineMap
+	}
+	return nil
+}
+
+// LineMapping states that `lines` lines of a generated source, starting
+// from generatedLine, come from file, starting from originalLine. Lines are
+// numbered from 1.
+type LineMapping struct {
+	GeneratedLine int32  `protobuf:"varint,1,opt,name=generatedLine" json:"generatedLine,omitempty"`
+	Lines         int32  `protobuf:"varint,2,opt,name=lines" json:"lines,omitempty"`
+	File          string `protobuf:"bytes,3,opt,name=file" json:"file,omitempty"`
+	OriginalLine  int32  `protobuf:"varint,4,opt,name=originalLine" json:"originalLine,omitempty"`
+}
+
+func (m *LineMapping) Reset()                    { *m = LineMapping{} }
+func (m *LineMapping) String() string            { return proto1.CompactTextString(m) }
+func (*LineMapping) ProtoMessage()               {}
+func (*LineMapping) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *LineMapping) GetGeneratedLine() int32 {
+	if m != nil {
+		return m.GeneratedLine
+	}
+	return 0
+}
+
+func (m *LineMapping) GetLines() int32 {
+	if m != nil {
+		return m.Lines
+	}
+	return 0
+}
+
+func (m *LineMapping) GetFile() string {
+	if m != nil {
+		return m.File
+	}
+	return ""
+}
+
+func (m *LineMapping) GetOriginalLine() int32 {
+	if m != nil {
+		return m.OriginalLine
+	}
+	return 0
+}
+
+type BuildProperties struct {
+	// sorted by key
+	Properties []*BuildProperty `protobuf:"bytes,1,rep,name=properties" json:"properties,omitempty"`
+}
+
+func (m *BuildProperties) Reset()                    { *m = BuildProperties{} }
+func (m *BuildProperties) String() string            { return proto1.CompactTextString(m) }
+func (*BuildProperties) ProtoMessage()               {}
+func (*BuildProperties) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *BuildProperties) GetProperties() []*BuildProperty {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+type ShutdownParams struct {
+}
+
+func (m *ShutdownParams) Reset()                    { *m = ShutdownParams{} }
+func (m *ShutdownParams) String() string            { return proto1.CompactTextString(m) }
+func (*ShutdownParams) ProtoMessage()               {}
+func (*ShutdownParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+type VersionParams struct {
+}
+
+func (m *VersionParams) Reset()                    { *m = VersionParams{} }
+func (m *VersionParams) String() string            { return proto1.CompactTextString(m) }
+func (*VersionParams) ProtoMessage()               {}
+func (*VersionParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+type Version struct {
+	Version string `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *Version) Reset()                    { *m = Version{} }
+func (m *Version) String() string            { return proto1.CompactTextString(m) }
+func (*Version) ProtoMessage()               {}
+func (*Version) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *Version) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+type CapabilitiesParams struct {
+}
+
+func (m *CapabilitiesParams) Reset()                    { *m = CapabilitiesParams{} }
+func (m *CapabilitiesParams) String() string            { return proto1.CompactTextString(m) }
+func (*CapabilitiesParams) ProtoMessage()               {}
+func (*CapabilitiesParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+type Capabilities struct {
+	// full names of the supported RPCs, e.g. "/proto.Builder/Build"
+	Rpcs []string `protobuf:"bytes,1,rep,name=rpcs" json:"rpcs,omitempty"`
+	// loggers accepted by the command line -logger flag
+	Loggers              []string               `protobuf:"bytes,2,rep,name=loggers" json:"loggers,omitempty"`
+	ExperimentalFeatures []*ExperimentalFeature `protobuf:"bytes,3,rep,name=experimentalFeatures" json:"experimentalFeatures,omitempty"`
+}
+
+func (m *Capabilities) Reset()                    { *m = Capabilities{} }
+func (m *Capabilities) String() string            { return proto1.CompactTextString(m) }
+func (*Capabilities) ProtoMessage()               {}
+func (*Capabilities) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *Capabilities) GetRpcs() []string {
+	if m != nil {
+		return m.Rpcs
+	}
+	return nil
+}
+
+func (m *Capabilities) GetLoggers() []string {
+	if m != nil {
+		return m.Loggers
+	}
+	return nil
+}
+
+func (m *Capabilities) GetExperimentalFeatures() []*ExperimentalFeature {
+	if m != nil {
+		return m.ExperimentalFeatures
+	}
+	return nil
+}
+
+type ExperimentalFeature struct {
+	Name    string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Enabled bool   `protobuf:"varint,2,opt,name=enabled" json:"enabled,omitempty"`
+}
+
+func (m *ExperimentalFeature) Reset()                    { *m = ExperimentalFeature{} }
+func (m *ExperimentalFeature) String() string            { return proto1.CompactTextString(m) }
+func (*ExperimentalFeature) ProtoMessage()               {}
+func (*ExperimentalFeature) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *ExperimentalFeature) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ExperimentalFeature) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
+	}
+	return false
+}
+
+type ListJobsParams struct {
+}
+
+func (m *ListJobsParams) Reset()                    { *m = ListJobsParams{} }
+func (m *ListJobsParams) String() string            { return proto1.CompactTextString(m) }
+func (*ListJobsParams) ProtoMessage()               {}
+func (*ListJobsParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+type JobList struct {
+	// running jobs first, then the queued ones in the order they will start
+	Jobs []*Job `protobuf:"bytes,1,rep,name=jobs" json:"jobs,omitempty"`
+}
+
+func (m *JobList) Reset()                    { *m = JobList{} }
+func (m *JobList) String() string            { return proto1.CompactTextString(m) }
+func (*JobList) ProtoMessage()               {}
+func (*JobList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *JobList) GetJobs() []*Job {
+	if m != nil {
+		return m.Jobs
+	}
+	return nil
+}
+
+type Job struct {
+	Id int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	// full name of the RPC that started the job
+	Method         string      `protobuf:"bytes,2,opt,name=method" json:"method,omitempty"`
+	SketchLocation string      `protobuf:"bytes,3,opt,name=sketchLocation" json:"sketchLocation,omitempty"`
+	Fqbn           string      `protobuf:"bytes,4,opt,name=fqbn" json:"fqbn,omitempty"`
+	Priority       JobPriority `protobuf:"varint,5,opt,name=priority,enum=proto.JobPriority" json:"priority,omitempty"`
+	State          Job_State   `protobuf:"varint,6,opt,name=state,enum=proto.Job_State" json:"state,omitempty"`
+	// unix times in milliseconds, startedAt is 0 for queued jobs
+	QueuedAt  int64 `protobuf:"varint,7,opt,name=queuedAt" json:"queuedAt,omitempty"`
+	StartedAt int64 `protobuf:"varint,8,opt,name=startedAt" json:"startedAt,omitempty"`
+	// number of compiler processes the job may run at the same time
+	CompilerJobs int32 `protobuf:"varint,9,opt,name=compilerJobs" json:"compilerJobs,omitempty"`
+}
+
+func (m *Job) Reset()                    { *m = Job{} }
+func (m *Job) String() string            { return proto1.CompactTextString(m) }
+func (*Job) ProtoMessage()               {}
+func (*Job) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *Job) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Job) GetMethod() string {
+	if m != nil {
+		return m.Method
+	}
+	return ""
+}
+
+func (m *Job) GetSketchLocation() string {
+	if m != nil {
+		return m.SketchLocation
+	}
+	return ""
+}
+
+func (m *Job) GetFqbn() string {
+	if m != nil {
+		return m.Fqbn
+	}
+	return ""
+}
+
+func (m *Job) GetPriority() JobPriority {
+	if m != nil {
+		return m.Priority
+	}
+	return JobPriority_DEFAULT_PRIORITY
+}
+
+func (m *Job) GetState() Job_State {
+	if m != nil {
+		return m.State
+	}
+	return Job_QUEUED
+}
+
+func (m *Job) GetQueuedAt() int64 {
+	if m != nil {
+		return m.QueuedAt
+	}
+	return 0
+}
+
+func (m *Job) GetStartedAt() int64 {
+	if m != nil {
+		return m.StartedAt
+	}
+	return 0
+}
+
+func (m *Job) GetCompilerJobs() int32 {
+	if m != nil {
+		return m.CompilerJobs
+	}
+	return 0
+}
+
+type CancelJobParams struct {
+	Id int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *CancelJobParams) Reset()                    { *m = CancelJobParams{} }
+func (m *CancelJobParams) String() string            { return proto1.CompactTextString(m) }
+func (*CancelJobParams) ProtoMessage()               {}
+func (*CancelJobParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *CancelJobParams) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type RemoteBuildRequest struct {
+	// Types that are valid to be assigned to Request:
+	//	*RemoteBuildRequest_Params
+	//	*RemoteBuildRequest_ArchiveChunk
+	Request isRemoteBuildRequest_Request `protobuf_oneof:"request"`
+}
+
+func (m *RemoteBuildRequest) Reset()                    { *m = RemoteBuildRequest{} }
+func (m *RemoteBuildRequest) String() string            { return proto1.CompactTextString(m) }
+func (*RemoteBuildRequest) ProtoMessage()               {}
+func (*RemoteBuildRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+type isRemoteBuildRequest_Request interface{ isRemoteBuildRequest_Request() }
+
+type RemoteBuildRequest_Params struct {
+	Params *RemoteBuildParams `protobuf:"bytes,1,opt,name=params,oneof"`
+}
+type RemoteBuildRequest_ArchiveChunk struct {
+	ArchiveChunk []byte `protobuf:"bytes,2,opt,name=archiveChunk,proto3,oneof"`
+}
+
+func (*RemoteBuildRequest_Params) isRemoteBuildRequest_Request()       {}
+func (*RemoteBuildRequest_ArchiveChunk) isRemoteBuildRequest_Request() {}
+
+func (m *RemoteBuildRequest) GetRequest() isRemoteBuildRequest_Request {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *RemoteBuildRequest) GetParams() *RemoteBuildParams {
+	if x, ok := m.GetRequest().(*RemoteBuildRequest_Params); ok {
+		return x.Params
+	}
+	return nil
+}
+
+func (m *RemoteBuildRequest) GetArchiveChunk() []byte {
+	if x, ok := m.GetRequest().(*RemoteBuildRequest_ArchiveChunk); ok {
+		return x.ArchiveChunk
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*RemoteBuildRequest) XXX_OneofFuncs() (func(msg proto1.Message, b *proto1.Buffer) error, func(msg proto1.Message, tag, wire int, b *proto1.Buffer) (bool, error), func(msg proto1.Message) (n int), []interface{}) {
+	return _RemoteBuildRequest_OneofMarshaler, _RemoteBuildRequest_OneofUnmarshaler, _RemoteBuildRequest_OneofSizer, []interface{}{
+		(*RemoteBuildRequest_Params)(nil),
+		(*RemoteBuildRequest_ArchiveChunk)(nil),
+	}
+}
+
+func _RemoteBuildRequest_OneofMarshaler(msg proto1.Message, b *proto1.Buffer) error {
+	m := msg.(*RemoteBuildRequest)
+	// request
+	switch x := m.Request.(type) {
+	case *RemoteBuildRequest_Params:
+		b.EncodeVarint(1<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.Params); err != nil {
+			return err
+		}
+	case *RemoteBuildRequest_ArchiveChunk:
+		b.EncodeVarint(2<<3 | proto1.WireBytes)
+		b.EncodeRawBytes(x.ArchiveChunk)
+	case nil:
+	default:
+		return fmt.Errorf("RemoteBuildRequest.Request has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _RemoteBuildRequest_OneofUnmarshaler(msg proto1.Message, tag, wire int, b *proto1.Buffer) (bool, error) {
+	m := msg.(*RemoteBuildRequest)
+	switch tag {
+	case 1: // request.params
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(RemoteBuildParams)
+		err := b.DecodeMessage(msg)
+		m.Request = &RemoteBuildRequest_Params{msg}
+		return true, err
+	case 2: // request.archiveChunk
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		x, err := b.DecodeRawBytes(true)
+		m.Request = &RemoteBuildRequest_ArchiveChunk{x}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _RemoteBuildRequest_OneofSizer(msg proto1.Message) (n int) {
+	m := msg.(*RemoteBuildRequest)
+	// request
+	switch x := m.Request.(type) {
+	case *RemoteBuildRequest_Params:
+		s := proto1.Size(x.Params)
+		n += proto1.SizeVarint(1<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *RemoteBuildRequest_ArchiveChunk:
+		n += proto1.SizeVarint(2<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(len(x.ArchiveChunk)))
+		n += len(x.ArchiveChunk)
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+// Remote builds can't set custom build properties, which could run any
+// command on the daemon host.
+type RemoteBuildParams struct {
+	Format RemoteBuildParams_ArchiveFormat `protobuf:"varint,1,opt,name=format,enum=proto.RemoteBuildParams_ArchiveFormat" json:"format,omitempty"`
+	// the sketch folder inside the archive, defaults to the only folder at the
+	// root of the archive, or to the root itself
+	SketchPath    string `protobuf:"bytes,2,opt,name=sketchPath" json:"sketchPath,omitempty"`
+	Fqbn          *FQBN  `protobuf:"bytes,3,opt,name=fqbn" json:"fqbn,omitempty"`
+	WarningsLevel string `protobuf:"bytes,4,opt,name=warningsLevel" json:"warningsLevel,omitempty"`
+	Verbose       bool   `protobuf:"varint,5,opt,name=verbose" json:"verbose,omitempty"`
+	// defaults to VERIFY, INTERACTIVE is not allowed
+	Priority JobPriority `protobuf:"varint,6,opt,name=priority,enum=proto.JobPriority" json:"priority,omitempty"`
+}
+
+func (m *RemoteBuildParams) Reset()                    { *m = RemoteBuildParams{} }
+func (m *RemoteBuildParams) String() string            { return proto1.CompactTextString(m) }
+func (*RemoteBuildParams) ProtoMessage()               {}
+func (*RemoteBuildParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *RemoteBuildParams) GetFormat() RemoteBuildParams_ArchiveFormat {
+	if m != nil {
+		return m.Format
+	}
+	return RemoteBuildParams_ZIP
+}
+
+func (m *RemoteBuildParams) GetSketchPath() string {
+	if m != nil {
+		return m.SketchPath
+	}
+	return ""
+}
+
+func (m *RemoteBuildParams) GetFqbn() *FQBN {
+	if m != nil {
+		return m.Fqbn
+	}
+	return nil
+}
+
+func (m *RemoteBuildParams) GetWarningsLevel() string {
+	if m != nil {
+		return m.WarningsLevel
+	}
+	return ""
+}
+
+func (m *RemoteBuildParams) GetVerbose() bool {
+	if m != nil {
+		return m.Verbose
+	}
+	return false
+}
+
+func (m *RemoteBuildParams) GetPriority() JobPriority {
+	if m != nil {
+		return m.Priority
+	}
+	return JobPriority_DEFAULT_PRIORITY
+}
+
+type DownloadArtifactParams struct {
+	BuildID string `protobuf:"bytes,1,opt,name=buildID" json:"buildID,omitempty"`
+	// the path of the Artifact event, e.g. "Blink.ino.hex"
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *DownloadArtifactParams) Reset()                    { *m = DownloadArtifactParams{} }
+func (m *DownloadArtifactParams) String() string            { return proto1.CompactTextString(m) }
+func (*DownloadArtifactParams) ProtoMessage()               {}
+func (*DownloadArtifactParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *DownloadArtifactParams) GetBuildID() string {
+	if m != nil {
+		return m.BuildID
+	}
+	return ""
+}
+
+func (m *DownloadArtifactParams) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type FileChunk struct {
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *FileChunk) Reset()                    { *m = FileChunk{} }
+func (m *FileChunk) String() string            { return proto1.CompactTextString(m) }
+func (*FileChunk) ProtoMessage()               {}
+func (*FileChunk) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *FileChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type DeleteRemoteBuildParams struct {
+	BuildID string `protobuf:"bytes,1,opt,name=buildID" json:"buildID,omitempty"`
+}
+
+func (m *DeleteRemoteBuildParams) Reset()                    { *m = DeleteRemoteBuildParams{} }
+func (m *DeleteRemoteBuildParams) String() string            { return proto1.CompactTextString(m) }
+func (*DeleteRemoteBuildParams) ProtoMessage()               {}
+func (*DeleteRemoteBuildParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *DeleteRemoteBuildParams) GetBuildID() string {
+	if m != nil {
+		return m.BuildID
+	}
+	return ""
+}
+
+type WarmupParams struct {
+	HardwareFolders         []string `protobuf:"bytes,1,rep,name=hardwareFolders" json:"hardwareFolders,omitempty"`
+	ToolsFolders            []string `protobuf:"bytes,2,rep,name=toolsFolders" json:"toolsFolders,omitempty"`
+	BuiltInLibrariesFolders []string `protobuf:"bytes,3,rep,name=builtInLibrariesFolders" json:"builtInLibrariesFolders,omitempty"`
+	OtherLibrariesFolders   []string `protobuf:"bytes,4,rep,name=otherLibrariesFolders" json:"otherLibrariesFolders,omitempty"`
+	ArduinoAPIVersion       string   `protobuf:"bytes,5,opt,name=arduinoAPIVersion" json:"arduinoAPIVersion,omitempty"`
+	// the boards whose core is compiled, and whose libraries are indexed
+	Fqbns []*FQBN `protobuf:"bytes,6,rep,name=fqbns" json:"fqbns,omitempty"`
+	// must match the ones of the builds for the compiled cores to be reused
+	CustomBuildProperties []*BuildProperty `protobuf:"bytes,7,rep,name=customBuildProperties" json:"customBuildProperties,omitempty"`
+	// the cores are not compiled if empty
+	BuildCachePath string `protobuf:"bytes,8,opt,name=buildCachePath" json:"buildCachePath,omitempty"`
+	// defaults to BATCH
+	Priority JobPriority `protobuf:"varint,9,opt,name=priority,enum=proto.JobPriority" json:"priority,omitempty"`
+}
+
+func (m *WarmupParams) Reset()                    { *m = WarmupParams{} }
+func (m *WarmupParams) String() string            { return proto1.CompactTextString(m) }
+func (*WarmupParams) ProtoMessage()               {}
+func (*WarmupParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *WarmupParams) GetHardwareFolders() []string {
+	if m != nil {
+		return m.HardwareFolders
+	}
+	return nil
+}
+
+func (m *WarmupParams) GetToolsFolders() []string {
+	if m != nil {
+		return m.ToolsFolders
+	}
+	return nil
+}
+
+func (m *WarmupParams) GetBuiltInLibrariesFolders() []string {
+	if m != nil {
+		return m.BuiltInLibrariesFolders
+	}
+	return nil
+}
+
+func (m *WarmupParams) GetOtherLibrariesFolders() []string {
+	if m != nil {
+		return m.OtherLibrariesFolders
+	}
+	return nil
+}
+
+func (m *WarmupParams) GetArduinoAPIVersion() string {
+	if m != nil {
+		return m.ArduinoAPIVersion
+	}
+	return ""
+}
+
+func (m *WarmupParams) GetFqbns() []*FQBN {
+	if m != nil {
+		return m.Fqbns
+	}
+	return nil
+}
+
+func (m *WarmupParams) GetCustomBuildProperties() []*BuildProperty {
+	if m != nil {
+		return m.CustomBuildProperties
+	}
+	return nil
+}
+
+func (m *WarmupParams) GetBuildCachePath() string {
+	if m != nil {
+		return m.BuildCachePath
+	}
+	return ""
+}
+
+func (m *WarmupParams) GetPriority() JobPriority {
+	if m != nil {
+		return m.Priority
+	}
+	return JobPriority_DEFAULT_PRIORITY
+}
+
+type WarmupEvent struct {
+	Step WarmupEvent_Step `protobuf:"varint,1,opt,name=step,enum=proto.WarmupEvent_Step" json:"step,omitempty"`
+	// the board being warmed up, with its 1-based index among the boards
+	Fqbn   string `protobuf:"bytes,2,opt,name=fqbn" json:"fqbn,omitempty"`
+	Board  int32  `protobuf:"varint,3,opt,name=board" json:"board,omitempty"`
+	Boards int32  `protobuf:"varint,4,opt,name=boards" json:"boards,omitempty"`
+	// an event of the build of the board's core, whose failure doesn't stop
+	// the warmup of the following boards
+	BuildEvent *BuildEvent `protobuf:"bytes,5,opt,name=buildEvent" json:"buildEvent,omitempty"`
+}
+
+func (m *WarmupEvent) Reset()                    { *m = WarmupEvent{} }
+func (m *WarmupEvent) String() string            { return proto1.CompactTextString(m) }
+func (*WarmupEvent) ProtoMessage()               {}
+func (*WarmupEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *WarmupEvent) GetStep() WarmupEvent_Step {
+	if m != nil {
+		return m.Step
+	}
+	return WarmupEvent_LOADING_HARDWARE
+}
+
+func (m *WarmupEvent) GetFqbn() string {
+	if m != nil {
+		return m.Fqbn
+	}
+	return ""
+}
+
+func (m *WarmupEvent) GetBoard() int32 {
+	if m != nil {
+		return m.Board
+	}
+	return 0
+}
+
+func (m *WarmupEvent) GetBoards() int32 {
+	if m != nil {
+		return m.Boards
+	}
+	return 0
+}
+
+func (m *WarmupEvent) GetBuildEvent() *BuildEvent {
+	if m != nil {
+		return m.BuildEvent
+	}
+	return nil
+}
+
+type DropCacheParams struct {
+	// defaults to HARDWARE and LIBRARIES, like DropCache
+	Scopes []DropCacheParams_Scope `protobuf:"varint,1,rep,packed,name=scopes,enum=proto.DropCacheParams_Scope" json:"scopes,omitempty"`
+	// limits HARDWARE and LIBRARIES to the data loaded from these folders,
+	// from folders inside them or from folders containing them
+	Folders        []string `protobuf:"bytes,2,rep,name=folders" json:"folders,omitempty"`
+	BuildCachePath string   `protobuf:"bytes,3,opt,name=buildCachePath" json:"buildCachePath,omitempty"`
+	BuildPath      string   `protobuf:"bytes,4,opt,name=buildPath" json:"buildPath,omitempty"`
+}
+
+func (m *DropCacheParams) Reset()                    { *m = DropCacheParams{} }
+func (m *DropCacheParams) String() string            { return proto1.CompactTextString(m) }
+func (*DropCacheParams) ProtoMessage()               {}
+func (*DropCacheParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *DropCacheParams) GetScopes() []DropCacheParams_Scope {
+	if m != nil {
+		return m.Scopes
+	}
+	return nil
+}
+
+func (m *DropCacheParams) GetFolders() []string {
+	if m != nil {
+		return m.Folders
+	}
+	return nil
+}
+
+func (m *DropCacheParams) GetBuildCachePath() string {
+	if m != nil {
+		return m.BuildCachePath
+	}
+	return ""
+}
+
+func (m *DropCacheParams) GetBuildPath() string {
+	if m != nil {
+		return m.BuildPath
+	}
+	return ""
+}
+
+type DropCacheResult struct {
+	Dropped []*DroppedCache `protobuf:"bytes,1,rep,name=dropped" json:"dropped,omitempty"`
+	// bytes freed on disk, in total
+	FreedBytes int64 `protobuf:"varint,2,opt,name=freedBytes" json:"freedBytes,omitempty"`
+	// the cores and build paths left alone because a running job uses them
+	Skipped []*DroppedCache `protobuf:"bytes,3,rep,name=skipped" json:"skipped,omitempty"`
+}
+
+func (m *DropCacheResult) Reset()                    { *m = DropCacheResult{} }
+func (m *DropCacheResult) String() string            { return proto1.CompactTextString(m) }
+func (*DropCacheResult) ProtoMessage()               {}
+func (*DropCacheResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+func (m *DropCacheResult) GetDropped() []*DroppedCache {
+	if m != nil {
+		return m.Dropped
+	}
+	return nil
+}
+
+func (m *DropCacheResult) GetFreedBytes() int64 {
+	if m != nil {
+		return m.FreedBytes
+	}
+	return 0
+}
+
+func (m *DropCacheResult) GetSkipped() []*DroppedCache {
+	if m != nil {
+		return m.Skipped
+	}
+	return nil
+}
+
+type DroppedCache struct {
+	Scope DropCacheParams_Scope `protobuf:"varint,1,opt,name=scope,enum=proto.DropCacheParams_Scope" json:"scope,omitempty"`
+	// the folders the hardware or libraries were loaded from, or the file or
+	// folder deleted
+	Description string `protobuf:"bytes,2,opt,name=description" json:"description,omitempty"`
+	// bytes freed on disk, 0 for the data kept in memory
+	FreedBytes int64 `protobuf:"varint,3,opt,name=freedBytes" json:"freedBytes,omitempty"`
+}
+
+func (m *DroppedCache) Reset()                    { *m = DroppedCache{} }
+func (m *DroppedCache) String() string            { return proto1.CompactTextString(m) }
+func (*DroppedCache) ProtoMessage()               {}
+func (*DroppedCache) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+func (m *DroppedCache) GetScope() DropCacheParams_Scope {
+	if m != nil {
+		return m.Scope
+	}
+	return DropCacheParams_HARDWARE
+}
+
+func (m *DroppedCache) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *DroppedCache) GetFreedBytes() int64 {
+	if m != nil {
+		return m.FreedBytes
+	}
+	return 0
+}
+
+func init() {
+	proto1.RegisterType((*BuildParams)(nil), "proto.BuildParams")
+	proto1.RegisterType((*BuildParamsV2)(nil), "proto.BuildParamsV2")
+	proto1.RegisterType((*FileOverlay)(nil), "proto.FileOverlay")
+	proto1.RegisterType((*FQBN)(nil), "proto.FQBN")
+	proto1.RegisterType((*BoardOption)(nil), "proto.BoardOption")
+	proto1.RegisterType((*BuildProperty)(nil), "proto.BuildProperty")
+	proto1.RegisterType((*VerboseParams)(nil), "proto.VerboseParams")
+	proto1.RegisterType((*Response)(nil), "proto.Response")
+	proto1.RegisterType((*BuildEvent)(nil), "proto.BuildEvent")
+	proto1.RegisterType((*LogRecord)(nil), "proto.LogRecord")
+	proto1.RegisterType((*CompilerDiagnostic)(nil), "proto.CompilerDiagnostic")
+	proto1.RegisterType((*SourceLocation)(nil), "proto.SourceLocation")
+	proto1.RegisterType((*DiagnosticOrigin)(nil), "proto.DiagnosticOrigin")
+	proto1.RegisterType((*Progress)(nil), "proto.Progress")
+	proto1.RegisterType((*Artifact)(nil), "proto.Artifact")
+	proto1.RegisterType((*BuildResult)(nil), "proto.BuildResult")
+	proto1.RegisterType((*FilesChanged)(nil), "proto.FilesChanged")
+	proto1.RegisterType((*ExecutableSectionSize)(nil), "proto.ExecutableSectionSize")
+	proto1.RegisterType((*CompletionList)(nil), "proto.CompletionList")
+	proto1.RegisterType((*Completion)(nil), "proto.Completion")
+	proto1.RegisterType((*PreprocessResult)(nil), "proto.PreprocessResult")
+	proto1.RegisterType((*LineMapping)(nil), "proto.LineMapping")
+	proto1.RegisterType((*BuildProperties)(nil), "proto.BuildProperties")
+	proto1.RegisterType((*ShutdownParams)(nil), "proto.ShutdownParams")
+	proto1.RegisterType((*VersionParams)(nil), "proto.VersionParams")
+	proto1.RegisterType((*Version)(nil), "proto.Version")
+	proto1.RegisterType((*CapabilitiesParams)(nil), "proto.CapabilitiesParams")
+	proto1.RegisterType((*Capabilities)(nil), "proto.Capabilities")
+	proto1.RegisterType((*ExperimentalFeature)(nil), "proto.ExperimentalFeature")
+	proto1.RegisterType((*ListJobsParams)(nil), "proto.ListJobsParams")
+	proto1.RegisterType((*JobList)(nil), "proto.JobList")
+	proto1.RegisterType((*Job)(nil), "proto.Job")
+	proto1.RegisterType((*CancelJobParams)(nil), "proto.CancelJobParams")
+	proto1.RegisterType((*RemoteBuildRequest)(nil), "proto.RemoteBuildRequest")
+	proto1.RegisterType((*RemoteBuildParams)(nil), "proto.RemoteBuildParams")
+	proto1.RegisterType((*DownloadArtifactParams)(nil), "proto.DownloadArtifactParams")
+	proto1.RegisterType((*FileChunk)(nil), "proto.FileChunk")
+	proto1.RegisterType((*DeleteRemoteBuildParams)(nil), "proto.DeleteRemoteBuildParams")
+	proto1.RegisterType((*WarmupParams)(nil), "proto.WarmupParams")
+	proto1.RegisterType((*WarmupEvent)(nil), "proto.WarmupEvent")
+	proto1.RegisterType((*DropCacheParams)(nil), "proto.DropCacheParams")
+	proto1.RegisterType((*DropCacheResult)(nil), "proto.DropCacheResult")
+	proto1.RegisterType((*DroppedCache)(nil), "proto.DroppedCache")
+	proto1.RegisterEnum("proto.JobPriority", JobPriority_name, JobPriority_value)
+	proto1.RegisterEnum("proto.BuildPhase", BuildPhase_name, BuildPhase_value)
+	proto1.RegisterEnum("proto.LogRecord_Level", LogRecord_Level_name, LogRecord_Level_value)
+	proto1.RegisterEnum("proto.CompilerDiagnostic_Severity", CompilerDiagnostic_Severity_name, CompilerDiagnostic_Severity_value)
+	proto1.RegisterEnum("proto.Completion_Kind", Completion_Kind_name, Completion_Kind_value)
+	proto1.RegisterEnum("proto.Job_State", Job_State_name, Job_State_value)
+	proto1.RegisterEnum("proto.RemoteBuildParams_ArchiveFormat", RemoteBuildParams_ArchiveFormat_name, RemoteBuildParams_ArchiveFormat_value)
+	proto1.RegisterEnum("proto.WarmupEvent_Step", WarmupEvent_Step_name, WarmupEvent_Step_value)
+	proto1.RegisterEnum("proto.DropCacheParams_Scope", DropCacheParams_Scope_name, DropCacheParams_Scope_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Builder service
+
+type BuilderClient interface {
+	// A server-to-client streaming RPC.
+	//
+	// Obtains the Features available within the given Rectangle.  Results are
+	// streamed rather than returned at once (e.g. in a response message with a
+	// repeated field), as the rectangle may cover a large area and contain a
+	// huge number of features.
+	Build(ctx context.Context, in *BuildParams, opts ...grpc.CallOption) (Builder_BuildClient, error)
+	Autocomplete(ctx context.Context, in *BuildParams, opts ...grpc.CallOption) (*Response, error)
+	DropCache(ctx context.Context, in *VerboseParams, opts ...grpc.CallOption) (*Response, error)
+	// Drops the cached data in the given scopes, reporting what was dropped
+	// and how much disk was freed.
+	DropCacheV2(ctx context.Context, in *DropCacheParams, opts ...grpc.CallOption) (*DropCacheResult, error)
+	// Same as Build, but takes its parameters as a BuildParamsV2 and streams
+	// structured events instead of bare text lines.
+	BuildV2(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (Builder_BuildV2Client, error)
+	// Same as Autocomplete, but takes its parameters as a BuildParamsV2 and
+	// returns the parsed completions.
+	AutocompleteV2(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (*CompletionList, error)
+	// Builds the sketch, then watches the sketch folder and the hardware and
+	// libraries folders: whenever they change, a FilesChanged event is sent,
+	// followed by the events of an incremental rebuild. It goes on until the
+	// client cancels the call.
+	Watch(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (Builder_WatchClient, error)
+	// Preprocesses the sketch, like the -preprocess command line flag,
+	// returning the generated source.
+	Preprocess(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (*PreprocessResult, error)
+	// Returns the build properties that would be used to build the sketch,
+	// like the -dump-prefs command line flag.
+	DumpProperties(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (*BuildProperties, error)
+	// Returns the version of the builder serving the calls.
+	GetVersion(ctx context.Context, in *VersionParams, opts ...grpc.CallOption) (*Version, error)
+	// Returns the features supported by the builder serving the calls, so
+	// that clients can adapt to older versions.
+	GetCapabilities(ctx context.Context, in *CapabilitiesParams, opts ...grpc.CallOption) (*Capabilities, error)
+	// Stops the daemon once the builds in progress are completed. Watch calls
+	// are ended as soon as they are not rebuilding.
+	Shutdown(ctx context.Context, in *ShutdownParams, opts ...grpc.CallOption) (*Response, error)
+	// Lists the builds, preprocessings and completions queued or running in
+	// the daemon.
+	ListJobs(ctx context.Context, in *ListJobsParams, opts ...grpc.CallOption) (*JobList, error)
+	// Cancels a queued or running job. The call that started the job fails
+	// with a CANCELLED status.
+	CancelJob(ctx context.Context, in *CancelJobParams, opts ...grpc.CallOption) (*Response, error)
+	// Builds a sketch uploaded as an archive, against the hardware, tools and
+	// libraries installed on the daemon, for clients without a toolchain. The
+	// first message carries the parameters, the following ones the archive.
+	// Once the client closes its side of the stream, the events of the build
+	// are sent; paths are relative to the build folder and the final
+	// BuildResult carries the id to download the artifacts with.
+	RemoteBuild(ctx context.Context, opts ...grpc.CallOption) (Builder_RemoteBuildClient, error)
+	// Downloads an artifact of a remote build, by the name of its Artifact
+	// event.
+	DownloadArtifact(ctx context.Context, in *DownloadArtifactParams, opts ...grpc.CallOption) (Builder_DownloadArtifactClient, error)
+	// Deletes the files of a remote build. They are deleted anyway an hour
+	// after the build or the last download.
+	DeleteRemoteBuild(ctx context.Context, in *DeleteRemoteBuildParams, opts ...grpc.CallOption) (*Response, error)
+	// Loads the hardware, tools and libraries in the given folders and
+	// compiles the core of each board into the build cache, so that the
+	// first build of the user doesn't pay for it.
+	Warmup(ctx context.Context, in *WarmupParams, opts ...grpc.CallOption) (Builder_WarmupClient, error)
+}
+
+type builderClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewBuilderClient(cc *grpc.ClientConn) BuilderClient {
+	return &builderClient{cc}
+}
+
+func (c *builderClient) Build(ctx context.Context, in *BuildParams, opts ...grpc.CallOption) (Builder_BuildClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Builder_serviceDesc.Streams[0], c.cc, "/proto.Builder/Build", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &builderBuildClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Builder_BuildClient interface {
+	Recv() (*Response, error)
+	grpc.ClientStream
+}
+
+type builderBuildClient struct {
+	grpc.ClientStream
+}
+
+func (x *builderBuildClient) Recv() (*Response, error) {
+	m := new(Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *builderClient) Autocomplete(ctx context.Context, in *BuildParams, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := grpc.Invoke(ctx, "/proto.Builder/Autocomplete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) DropCache(ctx context.Context, in *VerboseParams, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := grpc.Invoke(ctx, "/proto.Builder/DropCache", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) DropCacheV2(ctx context.Context, in *DropCacheParams, opts ...grpc.CallOption) (*DropCacheResult, error) {
+	out := new(DropCacheResult)
+	err := grpc.Invoke(ctx, "/proto.Builder/DropCacheV2", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) BuildV2(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (Builder_BuildV2Client, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Builder_serviceDesc.Streams[1], c.cc, "/proto.Builder/BuildV2", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &builderBuildV2Client{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Builder_BuildV2Client interface {
+	Recv() (*BuildEvent, error)
+	grpc.ClientStream
+}
+
+type builderBuildV2Client struct {
+	grpc.ClientStream
+}
+
+func (x *builderBuildV2Client) Recv() (*BuildEvent, error) {
+	m := new(BuildEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *builderClient) AutocompleteV2(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (*CompletionList, error) {
+	out := new(CompletionList)
+	err := grpc.Invoke(ctx, "/proto.Builder/AutocompleteV2", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) Watch(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (Builder_WatchClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Builder_serviceDesc.Streams[2], c.cc, "/proto.Builder/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &builderWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Builder_WatchClient interface {
+	Recv() (*BuildEvent, error)
+	grpc.ClientStream
+}
+
+type builderWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *builderWatchClient) Recv() (*BuildEvent, error) {
+	m := new(BuildEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *builderClient) Preprocess(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (*PreprocessResult, error) {
+	out := new(PreprocessResult)
+	err := grpc.Invoke(ctx, "/proto.Builder/Preprocess", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) DumpProperties(ctx context.Context, in *BuildParamsV2, opts ...grpc.CallOption) (*BuildProperties, error) {
+	out := new(BuildProperties)
+	err := grpc.Invoke(ctx, "/proto.Builder/DumpProperties", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) GetVersion(ctx context.Context, in *VersionParams, opts ...grpc.CallOption) (*Version, error) {
+	out := new(Version)
+	err := grpc.Invoke(ctx, "/proto.Builder/GetVersion", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) GetCapabilities(ctx context.Context, in *CapabilitiesParams, opts ...grpc.CallOption) (*Capabilities, error) {
+	out := new(Capabilities)
+	err := grpc.Invoke(ctx, "/proto.Builder/GetCapabilities", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) Shutdown(ctx context.Context, in *ShutdownParams, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := grpc.Invoke(ctx, "/proto.Builder/Shutdown", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) ListJobs(ctx context.Context, in *ListJobsParams, opts ...grpc.CallOption) (*JobList, error) {
+	out := new(JobList)
+	err := grpc.Invoke(ctx, "/proto.Builder/ListJobs", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) CancelJob(ctx context.Context, in *CancelJobParams, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := grpc.Invoke(ctx, "/proto.Builder/CancelJob", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) RemoteBuild(ctx context.Context, opts ...grpc.CallOption) (Builder_RemoteBuildClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Builder_serviceDesc.Streams[3], c.cc, "/proto.Builder/RemoteBuild", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &builderRemoteBuildClient{stream}
+	return x, nil
+}
+
+type Builder_RemoteBuildClient interface {
+	Send(*RemoteBuildRequest) error
+	Recv() (*BuildEvent, error)
+	grpc.ClientStream
+}
+
+type builderRemoteBuildClient struct {
+	grpc.ClientStream
+}
+
+func (x *builderRemoteBuildClient) Send(m *RemoteBuildRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *builderRemoteBuildClient) Recv() (*BuildEvent, error) {
+	m := new(BuildEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *builderClient) DownloadArtifact(ctx context.Context, in *DownloadArtifactParams, opts ...grpc.CallOption) (Builder_DownloadArtifactClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Builder_serviceDesc.Streams[4], c.cc, "/proto.Builder/DownloadArtifact", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &builderDownloadArtifactClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Builder_DownloadArtifactClient interface {
+	Recv() (*FileChunk, error)
+	grpc.ClientStream
+}
+
+type builderDownloadArtifactClient struct {
+	grpc.ClientStream
+}
+
+func (x *builderDownloadArtifactClient) Recv() (*FileChunk, error) {
+	m := new(FileChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *builderClient) DeleteRemoteBuild(ctx context.Context, in *DeleteRemoteBuildParams, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := grpc.Invoke(ctx, "/proto.Builder/DeleteRemoteBuild", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *builderClient) Warmup(ctx context.Context, in *WarmupParams, opts ...grpc.CallOption) (Builder_WarmupClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Builder_serviceDesc.Streams[5], c.cc, "/proto.Builder/Warmup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &builderWarmupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Builder_WarmupClient interface {
+	Recv() (*WarmupEvent, error)
+	grpc.ClientStream
+}
+
+type builderWarmupClient struct {
+	grpc.ClientStream
+}
+
+func (x *builderWarmupClient) Recv() (*WarmupEvent, error) {
+	m := new(WarmupEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for Builder service
+
+type BuilderServer interface {
+	// A server-to-client streaming RPC.
+	//
+	// Obtains the Features available within the given Rectangle.  Results are
+	// streamed rather than returned at once (e.g. in a response message with a
+	// repeated field), as the rectangle may cover a large area and contain a
+	// huge number of features.
+	Build(*BuildParams, Builder_BuildServer) error
+	Autocomplete(context.Context, *BuildParams) (*Response, error)
+	DropCache(context.Context, *VerboseParams) (*Response, error)
+	// Drops the cached data in the given scopes, reporting what was dropped
+	// and how much disk was freed.
+	DropCacheV2(context.Context, *DropCacheParams) (*DropCacheResult, error)
+	// Same as Build, but takes its parameters as a BuildParamsV2 and streams
+	// structured events instead of bare text lines.
+	BuildV2(*BuildParamsV2, Builder_BuildV2Server) error
+	// Same as Autocomplete, but takes its parameters as a BuildParamsV2 and
+	// returns the parsed completions.
+	AutocompleteV2(context.Context, *BuildParamsV2) (*CompletionList, error)
+	// Builds the sketch, then watches the sketch folder and the hardware and
+	// libraries folders: whenever they change, a FilesChanged event is sent,
+	// followed by the events of an incremental rebuild. It goes on until the
+	// client cancels the call.
+	Watch(*BuildParamsV2, Builder_WatchServer) error
+	// Preprocesses the sketch, like the -preprocess command line flag,
+	// returning the generated source.
+	Preprocess(context.Context, *BuildParamsV2) (*PreprocessResult, error)
+	// Returns the build properties that would be used to build the sketch,
+	// like the -dump-prefs command line flag.
+	DumpProperties(context.Context, *BuildParamsV2) (*BuildProperties, error)
+	// Returns the version of the builder serving the calls.
+	GetVersion(context.Context, *VersionParams) (*Version, error)
+	// Returns the features supported by the builder serving the calls, so
+	// that clients can adapt to older versions.
+	GetCapabilities(context.Context, *CapabilitiesParams) (*Capabilities, error)
+	// Stops the daemon once the builds in progress are completed. Watch calls
+	// are ended as soon as they are not rebuilding.
+	Shutdown(context.Context, *ShutdownParams) (*Response, error)
+	// Lists the builds, preprocessings and completions queued or running in
+	// the daemon.
+	ListJobs(context.Context, *ListJobsParams) (*JobList, error)
+	// Cancels a queued or running job. The call that started the job fails
+	// with a CANCELLED status.
+	CancelJob(context.Context, *CancelJobParams) (*Response, error)
+	// Builds a sketch uploaded as an archive, against the hardware, tools and
+	// libraries installed on the daemon, for clients without a toolchain. The
+	// first message carries the parameters, the following ones the archive.
+	// Once the client closes its side of the stream, the events of the build
+	// are sent; paths are relative to the build folder and the final
+	// BuildResult carries the id to download the artifacts with.
+	RemoteBuild(Builder_RemoteBuildServer) error
+	// Downloads an artifact of a remote build, by the name of its Artifact
+	// event.
+	DownloadArtifact(*DownloadArtifactParams, Builder_DownloadArtifactServer) error
+	// Deletes the files of a remote build. They are deleted anyway an hour
+	// after the build or the last download.
+	DeleteRemoteBuild(context.Context, *DeleteRemoteBuildParams) (*Response, error)
+	// Loads the hardware, tools and libraries in the given folders and
+	// compiles the core of each board into the build cache, so that the
+	// first build of the user doesn't pay for it.
+	Warmup(*WarmupParams, Builder_WarmupServer) error
+}
+
+func RegisterBuilderServer(s *grpc.Server, srv BuilderServer) {
+	s.RegisterService(&_Builder_serviceDesc, srv)
+}
+
+func _Builder_Build_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BuildParams)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuilderServer).Build(m, &builderBuildServer{stream})
+}
+
+type Builder_BuildServer interface {
+	Send(*Response) error
+	grpc.ServerStream
+}
+
+type builderBuildServer struct {
+	grpc.ServerStream
+}
+
+func (x *builderBuildServer) Send(m *Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Builder_Autocomplete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildParams)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
 	if interceptor == nil {
 		return srv.(BuilderServer).Autocomplete(ctx, in)
@@ -339,6 +2797,296 @@ func _Builder_DropCache_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Builder_DropCacheV2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DropCacheParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).DropCacheV2(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/DropCacheV2",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).DropCacheV2(ctx, req.(*DropCacheParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_BuildV2_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BuildParamsV2)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuilderServer).BuildV2(m, &builderBuildV2Server{stream})
+}
+
+type Builder_BuildV2Server interface {
+	Send(*BuildEvent) error
+	grpc.ServerStream
+}
+
+type builderBuildV2Server struct {
+	grpc.ServerStream
+}
+
+func (x *builderBuildV2Server) Send(m *BuildEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Builder_AutocompleteV2_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildParamsV2)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).AutocompleteV2(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/AutocompleteV2",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).AutocompleteV2(ctx, req.(*BuildParamsV2))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BuildParamsV2)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuilderServer).Watch(m, &builderWatchServer{stream})
+}
+
+type Builder_WatchServer interface {
+	Send(*BuildEvent) error
+	grpc.ServerStream
+}
+
+type builderWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *builderWatchServer) Send(m *BuildEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Builder_Preprocess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildParamsV2)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).Preprocess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/Preprocess",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).Preprocess(ctx, req.(*BuildParamsV2))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_DumpProperties_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildParamsV2)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).DumpProperties(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/DumpProperties",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).DumpProperties(ctx, req.(*BuildParamsV2))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/GetVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).GetVersion(ctx, req.(*VersionParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CapabilitiesParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/GetCapabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).GetCapabilities(ctx, req.(*CapabilitiesParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_Shutdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShutdownParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).Shutdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/Shutdown",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).Shutdown(ctx, req.(*ShutdownParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/ListJobs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).ListJobs(ctx, req.(*ListJobsParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/CancelJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).CancelJob(ctx, req.(*CancelJobParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_RemoteBuild_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BuilderServer).RemoteBuild(&builderRemoteBuildServer{stream})
+}
+
+type Builder_RemoteBuildServer interface {
+	Send(*BuildEvent) error
+	Recv() (*RemoteBuildRequest, error)
+	grpc.ServerStream
+}
+
+type builderRemoteBuildServer struct {
+	grpc.ServerStream
+}
+
+func (x *builderRemoteBuildServer) Send(m *BuildEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *builderRemoteBuildServer) Recv() (*RemoteBuildRequest, error) {
+	m := new(RemoteBuildRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Builder_DownloadArtifact_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadArtifactParams)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuilderServer).DownloadArtifact(m, &builderDownloadArtifactServer{stream})
+}
+
+type Builder_DownloadArtifactServer interface {
+	Send(*FileChunk) error
+	grpc.ServerStream
+}
+
+type builderDownloadArtifactServer struct {
+	grpc.ServerStream
+}
+
+func (x *builderDownloadArtifactServer) Send(m *FileChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Builder_DeleteRemoteBuild_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRemoteBuildParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServer).DeleteRemoteBuild(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Builder/DeleteRemoteBuild",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServer).DeleteRemoteBuild(ctx, req.(*DeleteRemoteBuildParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Builder_Warmup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WarmupParams)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuilderServer).Warmup(m, &builderWarmupServer{stream})
+}
+
+type Builder_WarmupServer interface {
+	Send(*WarmupEvent) error
+	grpc.ServerStream
+}
+
+type builderWarmupServer struct {
+	grpc.ServerStream
+}
+
+func (x *builderWarmupServer) Send(m *WarmupEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Builder_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.Builder",
 	HandlerType: (*BuilderServer)(nil),
@@ -351,6 +3099,46 @@ var _Builder_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DropCache",
 			Handler:    _Builder_DropCache_Handler,
 		},
+		{
+			MethodName: "DropCacheV2",
+			Handler:    _Builder_DropCacheV2_Handler,
+		},
+		{
+			MethodName: "AutocompleteV2",
+			Handler:    _Builder_AutocompleteV2_Handler,
+		},
+		{
+			MethodName: "Preprocess",
+			Handler:    _Builder_Preprocess_Handler,
+		},
+		{
+			MethodName: "DumpProperties",
+			Handler:    _Builder_DumpProperties_Handler,
+		},
+		{
+			MethodName: "GetVersion",
+			Handler:    _Builder_GetVersion_Handler,
+		},
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _Builder_GetCapabilities_Handler,
+		},
+		{
+			MethodName: "Shutdown",
+			Handler:    _Builder_Shutdown_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _Builder_ListJobs_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _Builder_CancelJob_Handler,
+		},
+		{
+			MethodName: "DeleteRemoteBuild",
+			Handler:    _Builder_DeleteRemoteBuild_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -358,6 +3146,32 @@ var _Builder_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Builder_Build_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "BuildV2",
+			Handler:       _Builder_BuildV2_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Builder_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RemoteBuild",
+			Handler:       _Builder_RemoteBuild_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadArtifact",
+			Handler:       _Builder_DownloadArtifact_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Warmup",
+			Handler:       _Builder_Warmup_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "builder.proto",
 }
@@ -365,32 +3179,183 @@ var _Builder_serviceDesc = grpc.ServiceDesc{
 func init() { proto1.RegisterFile("builder.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 420 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x93, 0x6f, 0x6b, 0x13, 0x41,
-	0x10, 0xc6, 0x3d, 0x4d, 0x9a, 0x64, 0x9a, 0x58, 0x1c, 0x14, 0x17, 0x11, 0x29, 0xa1, 0x48, 0x04,
-	0x09, 0x45, 0x2b, 0xf8, 0x36, 0x57, 0x11, 0x0a, 0x41, 0xce, 0xbc, 0xe8, 0xfb, 0xbd, 0xcd, 0xe8,
-	0x2d, 0x5e, 0x6e, 0x8e, 0xdd, 0x4d, 0xfb, 0x59, 0xfc, 0x06, 0x7e, 0x4c, 0xd9, 0x3f, 0xd1, 0x5e,
-	0x13, 0xc1, 0x57, 0x99, 0x3c, 0xcf, 0x6f, 0x6e, 0x9f, 0xdb, 0x99, 0x83, 0x49, 0xb9, 0xd5, 0xf5,
-	0x9a, 0xcc, 0xbc, 0x35, 0xec, 0x18, 0xfb, 0xe1, 0x67, 0xfa, 0xb3, 0x07, 0xc7, 0xb9, 0x37, 0x0a,
-	0x69, 0xe4, 0xc6, 0xe2, 0x0c, 0x4e, 0x2a, 0x69, 0xd6, 0xb7, 0xd2, 0xd0, 0x67, 0xf6, 0xb8, 0x15,
-	0xd9, 0x69, 0x36, 0x1b, 0xad, 0xee, 0xcb, 0x38, 0x85, 0xb1, 0x63, 0xae, 0xed, 0x0e, 0x7b, 0x18,
-	0xb0, 0x8e, 0x86, 0x1f, 0xe1, 0xb9, 0x3f, 0xd5, 0x5d, 0x35, 0x4b, 0x5d, 0x1a, 0x69, 0x34, 0xfd,
-	0xc1, 0x1f, 0x05, 0xfc, 0x5f, 0x36, 0x5e, 0xc0, 0x33, 0x76, 0x15, 0x99, 0xbd, 0xbe, 0x5e, 0xe8,
-	0x3b, 0x6c, 0xe2, 0x6b, 0x78, 0x6c, 0x7f, 0x90, 0x53, 0xd5, 0x92, 0x95, 0x74, 0x9a, 0x1b, 0xd1,
-	0x0f, 0xf8, 0x3d, 0x15, 0x11, 0x7a, 0xdf, 0xbe, 0xe6, 0x5f, 0xc4, 0x51, 0x70, 0x43, 0x8d, 0x6f,
-	0xe1, 0x89, 0x34, 0xeb, 0xad, 0x6e, 0x78, 0x51, 0x5c, 0x5d, 0x93, 0xb1, 0xbe, 0x7d, 0x10, 0x80,
-	0x7d, 0xc3, 0xe7, 0x53, 0x5b, 0xeb, 0x78, 0x13, 0x2f, 0xcf, 0x70, 0x4b, 0xc6, 0x69, 0xb2, 0x62,
-	0x18, 0xf3, 0x1d, 0x34, 0x7d, 0xbe, 0x30, 0x85, 0x4b, 0xa9, 0x2a, 0x2a, 0xa4, 0xab, 0xc4, 0x28,
-	0xe6, 0xeb, 0xaa, 0xf8, 0x12, 0x46, 0x65, 0x1c, 0x8a, 0xab, 0x04, 0x04, 0xe4, 0xaf, 0x80, 0x67,
-	0x30, 0xb9, 0x95, 0xa6, 0xd1, 0xcd, 0x77, 0xbb, 0xa4, 0x1b, 0xaa, 0xc5, 0x71, 0x20, 0xba, 0xa2,
-	0x3f, 0x4b, 0xf1, 0x9a, 0x2e, 0x79, 0xd3, 0xd6, 0xe4, 0x68, 0xe1, 0xc4, 0x38, 0x9e, 0xd5, 0x55,
-	0x51, 0xc0, 0xe0, 0x86, 0x4c, 0xc9, 0x96, 0xc4, 0xe4, 0x34, 0x9b, 0x0d, 0x57, 0xbb, 0xbf, 0xd3,
-	0x37, 0x30, 0xb9, 0x8e, 0x65, 0x5a, 0x8e, 0x3b, 0x68, 0xd6, 0x45, 0x5f, 0xc1, 0x70, 0x45, 0xb6,
-	0xe5, 0xc6, 0x92, 0xbf, 0xdc, 0x5a, 0x37, 0x94, 0xf6, 0x26, 0xd4, 0xef, 0x7e, 0x65, 0x30, 0xc8,
-	0xe3, 0xfe, 0xe1, 0x39, 0xf4, 0x43, 0x89, 0x18, 0x57, 0x71, 0x7e, 0x67, 0xff, 0x5e, 0x9c, 0x24,
-	0x6d, 0xf7, 0xb4, 0xe9, 0x83, 0xf3, 0x0c, 0x3f, 0xc0, 0x78, 0xb1, 0x75, 0xac, 0x52, 0xe8, 0xff,
-	0x6c, 0xc4, 0x0b, 0x18, 0x7d, 0x32, 0xdc, 0x86, 0x6b, 0xc5, 0xa7, 0xc9, 0xef, 0xbc, 0xd1, 0x81,
-	0xae, 0xfc, 0x0c, 0x50, 0xa9, 0x79, 0x9a, 0xf8, 0x3c, 0x7d, 0x34, 0xf9, 0x38, 0xa5, 0x2f, 0x3c,
-	0x5e, 0x64, 0xe5, 0x51, 0xe8, 0x7b, 0xff, 0x3b, 0x00, 0x00, 0xff, 0xff, 0xb2, 0x39, 0x66, 0x51,
-	0x56, 0x03, 0x00, 0x00,
+	// 2835 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe4, 0x59, 0xcf, 0x73, 0xdb, 0xc6,
+	0xf5, 0x27, 0x08, 0x82, 0xa4, 0x1e, 0x25, 0x0a, 0x5e, 0xcb, 0x36, 0xa3, 0xc9, 0xd7, 0x51, 0xf0,
+	0xf5, 0xa4, 0x4e, 0xea, 0x28, 0x09, 0xed, 0x34, 0x6d, 0x32, 0x49, 0xca, 0x5f, 0x92, 0x68, 0xd3,
+	0x24, 0xb3, 0xa2, 0xe4, 0x38, 0x17, 0x0f, 0x08, 0xac, 0x29, 0xd4, 0x14, 0x80, 0x00, 0xa0, 0x6c,
+	0xe5, 0xd6, 0x99, 0x1e, 0x33, 0xd3, 0x3f, 0xa2, 0x87, 0x5e, 0x3b, 0xd3, 0x69, 0x4f, 0xbd, 0x24,
+	0xb7, 0xfe, 0x27, 0x9d, 0xe9, 0x3f, 0xd1, 0x79, 0xbb, 0x0b, 0x10, 0x10, 0x21, 0xc7, 0x69, 0x7a,
+	0xe9, 0xf4, 0x44, 0xbc, 0xb7, 0x9f, 0xb7, 0xbf, 0xde, 0x67, 0xdf, 0xbe, 0xb7, 0x84, 0x8d, 0xe9,
+	0xc2, 0x99, 0xdb, 0x2c, 0xd8, 0xf5, 0x03, 0x2f, 0xf2, 0x88, 0xc6, 0x7f, 0x8c, 0xbf, 0x97, 0xa0,
+	0xd6, 0xc6, 0x86, 0xb1, 0x19, 0x98, 0xa7, 0x21, 0xb9, 0x0d, 0x9b, 0x27, 0x66, 0x60, 0x3f, 0x37,
+	0x03, 0xb6, 0xe7, 0x21, 0x3c, 0x6c, 0x28, 0x3b, 0xca, 0xed, 0x35, 0x7a, 0x51, 0x4d, 0x0c, 0x58,
+	0x8f, 0x3c, 0x6f, 0x1e, 0xc6, 0xb0, 0x22, 0x87, 0x65, 0x74, 0xe4, 0x97, 0x70, 0x03, 0x47, 0x8d,
+	0xfa, 0xee, 0xc0, 0x99, 0x06, 0x66, 0xe0, 0xb0, 0x04, 0xae, 0x72, 0xf8, 0x65, 0xcd, 0xe4, 0x1e,
+	0x5c, 0xf3, 0xa2, 0x13, 0x16, 0xac, 0xd8, 0x95, 0xb8, 0x5d, 0x7e, 0x23, 0x79, 0x0b, 0xea, 0xe1,
+	0x33, 0x16, 0x59, 0x27, 0x03, 0xcf, 0x32, 0x23, 0xc7, 0x73, 0x1b, 0x1a, 0x87, 0x5f, 0xd0, 0x12,
+	0x02, 0xa5, 0xa7, 0x5f, 0xb4, 0x87, 0x8d, 0x32, 0x6f, 0xe5, 0xdf, 0xe4, 0x0e, 0x5c, 0x31, 0x03,
+	0x7b, 0xe1, 0xb8, 0x5e, 0x6b, 0xdc, 0x3f, 0x66, 0x41, 0x88, 0xe6, 0x15, 0x0e, 0x58, 0x6d, 0xc0,
+	0xf9, 0x59, 0x8b, 0x30, 0xf2, 0x4e, 0xc5, 0xe6, 0x05, 0x9e, 0xcf, 0x82, 0xc8, 0x61, 0x61, 0xa3,
+	0x2a, 0xe6, 0x97, 0xdb, 0x88, 0xf3, 0xe3, 0x5e, 0xe8, 0x98, 0xd6, 0x09, 0x1b, 0x9b, 0xd1, 0x49,
+	0x63, 0x4d, 0xcc, 0x2f, 0xab, 0x25, 0xaf, 0xc3, 0xda, 0x54, 0x38, 0x25, 0x3a, 0x69, 0x00, 0x87,
+	0x2c, 0x15, 0xe4, 0x16, 0x6c, 0x3c, 0x37, 0x03, 0xd7, 0x71, 0x67, 0xe1, 0x80, 0x9d, 0xb1, 0x79,
+	0xa3, 0xc6, 0x11, 0x59, 0x25, 0x8e, 0x65, 0x79, 0x36, 0xeb, 0x78, 0xa7, 0xfe, 0x9c, 0x45, 0xac,
+	0x15, 0x35, 0xd6, 0xc5, 0x58, 0x59, 0x2d, 0x69, 0x40, 0xe5, 0x8c, 0x05, 0x53, 0x2f, 0x64, 0x8d,
+	0x8d, 0x1d, 0xe5, 0x76, 0x95, 0xc6, 0x22, 0xd9, 0x85, 0xaa, 0x77, 0xc6, 0x82, 0xb9, 0x79, 0x1e,
+	0x36, 0xea, 0x3b, 0xea, 0xed, 0x5a, 0x93, 0x08, 0xf2, 0xec, 0xee, 0x39, 0x73, 0x36, 0x12, 0x4d,
+	0x34, 0xc1, 0x18, 0x7f, 0xd5, 0x60, 0x23, 0xc5, 0xa5, 0xe3, 0x66, 0x3e, 0x9b, 0xd4, 0x57, 0x63,
+	0x93, 0xfa, 0xe3, 0xd8, 0xa4, 0xfe, 0x9b, 0x6c, 0x52, 0x7f, 0x3a, 0x9b, 0xde, 0x80, 0xd2, 0xd3,
+	0xaf, 0xa7, 0x2e, 0x67, 0x53, 0xad, 0x59, 0x8b, 0xf7, 0xe8, 0x8b, 0xf6, 0x90, 0xf2, 0x86, 0x1f,
+	0x49, 0xad, 0xfb, 0x97, 0x53, 0x0b, 0x7d, 0xb0, 0x25, 0xfb, 0x4f, 0xb7, 0x9e, 0xff, 0x4f, 0x12,
+	0x0e, 0xf1, 0x7e, 0xe0, 0x78, 0x81, 0x13, 0x9d, 0x37, 0x36, 0x77, 0x94, 0xdb, 0xf5, 0x04, 0x7f,
+	0xdf, 0x9b, 0x8e, 0x65, 0x0b, 0x4d, 0x30, 0xe4, 0x3a, 0x94, 0xcf, 0x1c, 0x7b, 0xec, 0xd8, 0x0d,
+	0x9d, 0xcf, 0x4c, 0x4a, 0xc6, 0xa7, 0x50, 0x4b, 0x0d, 0x80, 0xd1, 0xc1, 0xc7, 0x7d, 0x10, 0x81,
+	0x8f, 0x7f, 0x93, 0x6d, 0xa8, 0x5a, 0x9e, 0x1b, 0x31, 0x37, 0x8a, 0x23, 0x5d, 0x22, 0x1b, 0xdf,
+	0x2a, 0x50, 0x42, 0x6f, 0xe3, 0xca, 0x7c, 0xd3, 0x7a, 0x66, 0xce, 0x98, 0xb4, 0x8d, 0x45, 0xa4,
+	0xb7, 0x19, 0x58, 0x27, 0x4e, 0xc4, 0xac, 0x68, 0x11, 0xb0, 0x38, 0x58, 0xa6, 0x75, 0x68, 0x3d,
+	0xf5, 0xcc, 0xc0, 0xee, 0x77, 0x65, 0x70, 0x8c, 0x45, 0x72, 0x07, 0x2a, 0x9e, 0x8f, 0x54, 0x13,
+	0x84, 0x5d, 0x6e, 0x4b, 0x1b, 0x01, 0x23, 0xde, 0x44, 0x63, 0x88, 0xf1, 0x11, 0xd4, 0x52, 0x7a,
+	0x5c, 0x8d, 0x6b, 0x9e, 0xc6, 0x33, 0xe2, 0xdf, 0x64, 0x0b, 0xb4, 0x33, 0x73, 0xbe, 0x88, 0xe7,
+	0x21, 0x04, 0xe3, 0x23, 0xd8, 0x48, 0xf3, 0xe7, 0x9c, 0xe8, 0xa0, 0x3e, 0x63, 0xe7, 0xd2, 0x12,
+	0x3f, 0x2f, 0x31, 0x7c, 0x1b, 0x36, 0x8e, 0x85, 0x0b, 0xe5, 0x2d, 0x92, 0x72, 0xb1, 0x92, 0x71,
+	0xb1, 0x71, 0x13, 0xaa, 0x94, 0x85, 0xbe, 0xe7, 0x86, 0x0c, 0x67, 0x36, 0x77, 0xdc, 0x64, 0x66,
+	0xf8, 0x6d, 0xfc, 0xa5, 0x08, 0xc0, 0x27, 0xd1, 0x3b, 0x63, 0x6e, 0x44, 0x6e, 0x81, 0x3a, 0xf7,
+	0x66, 0x1c, 0x51, 0x6b, 0xea, 0x72, 0xd5, 0x03, 0x6f, 0x46, 0x99, 0xe5, 0x05, 0xf6, 0x41, 0x81,
+	0x62, 0x33, 0xf9, 0x04, 0xc0, 0x76, 0xcc, 0x99, 0xeb, 0x85, 0x91, 0x63, 0xf1, 0xa9, 0xd5, 0x9a,
+	0xaf, 0x49, 0x30, 0x12, 0xcf, 0x99, 0xb3, 0xa0, 0x9b, 0x00, 0x0e, 0x0a, 0x34, 0x05, 0x27, 0xef,
+	0x22, 0x89, 0xbc, 0x59, 0xc0, 0x42, 0x71, 0x29, 0xd5, 0x9a, 0x9b, 0xd2, 0x74, 0x2c, 0xd5, 0x07,
+	0x05, 0x9a, 0x40, 0x10, 0x6e, 0x06, 0x91, 0xf3, 0xd4, 0xb4, 0xa2, 0x46, 0x29, 0x03, 0x6f, 0x49,
+	0x35, 0xc2, 0x63, 0x08, 0xb9, 0x03, 0xe5, 0x80, 0x85, 0x8b, 0x79, 0xc4, 0x63, 0x47, 0xca, 0x73,
+	0xb8, 0x46, 0xca, 0x5b, 0x0e, 0x0a, 0x54, 0x62, 0xc8, 0x7b, 0x50, 0xb1, 0x4e, 0x4c, 0x77, 0xc6,
+	0x6c, 0x19, 0x4c, 0xae, 0xa6, 0xf8, 0x1f, 0x76, 0x44, 0xd3, 0x41, 0x81, 0xc6, 0xa8, 0x76, 0x05,
+	0x34, 0x86, 0x1b, 0x65, 0x7c, 0xaf, 0xc0, 0x5a, 0xb2, 0x2f, 0xe4, 0x0e, 0x68, 0x73, 0x7e, 0x50,
+	0x15, 0x7e, 0x2a, 0xae, 0x5f, 0xdc, 0xb8, 0x5d, 0x7e, 0x62, 0xa9, 0x00, 0xe1, 0xe1, 0x3f, 0x65,
+	0x61, 0x68, 0xce, 0x58, 0xbf, 0x2b, 0x1d, 0xbb, 0x54, 0xa0, 0x2f, 0xa5, 0x10, 0xd3, 0x52, 0x8a,
+	0x68, 0x67, 0x06, 0xb3, 0xc5, 0x29, 0x3f, 0x14, 0x22, 0x92, 0x2e, 0x15, 0xc6, 0x07, 0xa0, 0x89,
+	0xb8, 0x50, 0x85, 0x52, 0x7f, 0xb8, 0x37, 0xd2, 0x0b, 0x64, 0x0d, 0xb4, 0x6e, 0xaf, 0x7d, 0xb4,
+	0xaf, 0x2b, 0xa8, 0x7c, 0xd4, 0xa2, 0x43, 0xbd, 0x88, 0xca, 0x1e, 0xa5, 0x23, 0xaa, 0xab, 0xc6,
+	0xdf, 0x54, 0x20, 0xab, 0xfe, 0xe2, 0xb7, 0xb5, 0x33, 0x4f, 0x78, 0x82, 0xdf, 0x09, 0x77, 0x70,
+	0xba, 0x9a, 0xe0, 0x0e, 0x1e, 0x6f, 0xcb, 0x9b, 0x2f, 0x4e, 0x5d, 0x3e, 0x51, 0x8d, 0x4a, 0x89,
+	0x7c, 0x06, 0xd5, 0x90, 0x9d, 0x31, 0x1e, 0x26, 0x4a, 0x7c, 0x43, 0x8c, 0x4b, 0xc9, 0xb1, 0x7b,
+	0x28, 0x91, 0x34, 0xb1, 0x49, 0xef, 0x80, 0x96, 0xdd, 0x81, 0xeb, 0x50, 0x16, 0xa7, 0x4e, 0x66,
+	0x12, 0x52, 0x42, 0x0b, 0x1e, 0x1d, 0x5e, 0x44, 0x32, 0xcc, 0xc7, 0x22, 0xf9, 0x15, 0xac, 0x3b,
+	0xae, 0x35, 0x5f, 0xd8, 0xcc, 0xde, 0x0b, 0xbc, 0x53, 0x19, 0xd3, 0xaf, 0xc9, 0xf9, 0x1c, 0x7a,
+	0x8b, 0xc0, 0x62, 0xf1, 0xc5, 0x42, 0x33, 0x50, 0xf2, 0x1e, 0x68, 0xae, 0x17, 0xb1, 0xb0, 0xb1,
+	0xb6, 0xa3, 0xbe, 0x94, 0xe0, 0x54, 0xe0, 0xc8, 0x7b, 0x50, 0xf6, 0x02, 0x67, 0xe6, 0xb8, 0x3c,
+	0xa2, 0xd7, 0x9a, 0x37, 0xa4, 0xc5, 0x12, 0x39, 0xe2, 0xcd, 0x54, 0xc2, 0x8c, 0x4f, 0xa1, 0x1a,
+	0x2f, 0x7f, 0xe9, 0x96, 0x02, 0xd9, 0x84, 0xda, 0x5e, 0x6b, 0xd2, 0x1a, 0x3c, 0x11, 0x0a, 0x85,
+	0xd4, 0xa0, 0x82, 0xce, 0xeb, 0x0f, 0xf7, 0xf5, 0x22, 0x7a, 0x72, 0x38, 0x9a, 0xf4, 0x74, 0xd5,
+	0x18, 0x43, 0x3d, 0xbb, 0x80, 0x9f, 0xea, 0x39, 0xe3, 0x63, 0xd0, 0x2f, 0x4e, 0x16, 0xed, 0x9f,
+	0x39, 0xae, 0x1d, 0xf7, 0x89, 0xdf, 0x49, 0x8c, 0x2b, 0x2e, 0x63, 0x9c, 0xf1, 0x10, 0xaa, 0xf1,
+	0x01, 0x26, 0x3f, 0x03, 0xcd, 0x3f, 0x31, 0x65, 0x34, 0xaa, 0x37, 0xaf, 0x64, 0xae, 0x50, 0x6c,
+	0xa0, 0xa2, 0x9d, 0x47, 0x70, 0x16, 0x58, 0xcc, 0x8d, 0x78, 0x5f, 0x45, 0x1a, 0x8b, 0x46, 0x13,
+	0xaa, 0xf1, 0x01, 0xcf, 0xbd, 0x20, 0x08, 0x94, 0x42, 0xe7, 0x1b, 0x31, 0x05, 0x95, 0xf2, 0x6f,
+	0xe3, 0x8f, 0x0a, 0xd4, 0x52, 0x07, 0x1d, 0x7b, 0x0f, 0x17, 0x96, 0x85, 0x91, 0x46, 0x86, 0x45,
+	0x29, 0xe2, 0xf5, 0xc2, 0x5e, 0x38, 0x51, 0xc7, 0xb3, 0xe3, 0x8d, 0x49, 0x64, 0x8c, 0xb9, 0x2c,
+	0x08, 0xbc, 0x40, 0x1e, 0x3f, 0x21, 0x90, 0x26, 0x68, 0x38, 0x46, 0x7c, 0x23, 0xbc, 0x2e, 0x97,
+	0xd4, 0x7b, 0xc1, 0xac, 0x45, 0x64, 0x4e, 0xe7, 0xec, 0x90, 0x59, 0xe8, 0x83, 0x43, 0xe7, 0x1b,
+	0x46, 0x05, 0x94, 0xdf, 0x30, 0x38, 0x9d, 0x7e, 0x37, 0x26, 0xb2, 0x14, 0x8d, 0x5b, 0xb0, 0x9e,
+	0x0e, 0x31, 0x38, 0x26, 0xae, 0x2a, 0x4e, 0xd7, 0x84, 0x60, 0x3c, 0x86, 0x6b, 0xb9, 0xfd, 0xe7,
+	0xde, 0x31, 0x39, 0x1b, 0xc2, 0x4f, 0x92, 0xf9, 0x02, 0x4d, 0xf8, 0x62, 0x54, 0x1a, 0x8b, 0xc6,
+	0x9f, 0x14, 0xa8, 0xcb, 0x1c, 0xc1, 0xf1, 0xdc, 0x81, 0x13, 0x46, 0xe4, 0x2e, 0xd4, 0xac, 0x44,
+	0x23, 0x66, 0x52, 0x4b, 0x5c, 0xb7, 0xc4, 0xd2, 0x34, 0x8a, 0x7c, 0x02, 0xb5, 0x65, 0x6c, 0x17,
+	0x69, 0xe4, 0x4b, 0x8f, 0x4a, 0x1a, 0x7d, 0xc9, 0x4e, 0xe3, 0x21, 0x5f, 0x44, 0xfe, 0x22, 0x92,
+	0xb5, 0x87, 0x94, 0x8c, 0xef, 0x8b, 0x00, 0xcb, 0x69, 0xa0, 0xf1, 0xdc, 0x9c, 0xca, 0x98, 0xbb,
+	0x46, 0x85, 0x40, 0xde, 0x91, 0x6c, 0x2d, 0x66, 0x02, 0xf1, 0xd2, 0x6c, 0xf7, 0x81, 0xe3, 0xda,
+	0x92, 0xc5, 0xaf, 0xc3, 0x5a, 0xe8, 0xcc, 0x5c, 0x93, 0x67, 0x08, 0x62, 0x0a, 0x4b, 0x05, 0xb9,
+	0x09, 0x10, 0xb0, 0x68, 0x11, 0xb8, 0x93, 0x73, 0x9f, 0xc9, 0xa9, 0xa4, 0x34, 0x98, 0xa4, 0xd9,
+	0x9e, 0xc5, 0x83, 0x6f, 0x3a, 0x59, 0xcd, 0x2a, 0xb1, 0x17, 0xc7, 0x0d, 0x59, 0x10, 0x4d, 0x30,
+	0x38, 0x89, 0xa8, 0x95, 0xd2, 0x20, 0x11, 0x93, 0x94, 0xaa, 0x22, 0x88, 0x18, 0xcb, 0x06, 0x85,
+	0x12, 0xce, 0x16, 0x4f, 0xfc, 0xa4, 0xf7, 0xe5, 0x44, 0x2f, 0x90, 0x75, 0xa8, 0xee, 0x1d, 0x0d,
+	0x3b, 0x93, 0xfe, 0x68, 0xa8, 0x2b, 0x28, 0x1d, 0xb7, 0x68, 0xbf, 0xd5, 0x1e, 0xf4, 0x44, 0x5c,
+	0x7f, 0xd8, 0xea, 0xd0, 0x91, 0xae, 0x62, 0xbc, 0x18, 0xb7, 0x26, 0x93, 0x1e, 0x1d, 0xea, 0x25,
+	0x44, 0x8d, 0x8e, 0x7b, 0x74, 0x30, 0x6a, 0x75, 0x75, 0xcd, 0xf8, 0x12, 0xf4, 0x71, 0xc0, 0xfc,
+	0xc0, 0xc3, 0x63, 0x20, 0x8f, 0xc9, 0x75, 0x28, 0x87, 0x3c, 0x8e, 0xc8, 0xad, 0x94, 0x12, 0xa6,
+	0x41, 0x18, 0x2d, 0x1e, 0x9a, 0xbe, 0xf4, 0x6b, 0x7c, 0x99, 0x0e, 0x84, 0xd6, 0x77, 0xdc, 0x19,
+	0x8d, 0x21, 0xc6, 0x6f, 0x15, 0xa8, 0xa5, 0x1a, 0x70, 0x7f, 0x66, 0xcc, 0x65, 0x81, 0x19, 0x31,
+	0x7b, 0x10, 0xa7, 0x1d, 0x1a, 0xcd, 0x2a, 0xb9, 0x17, 0x1d, 0x97, 0x85, 0xf2, 0x14, 0x0a, 0x21,
+	0x89, 0x63, 0x6a, 0x2a, 0x8e, 0x19, 0xb0, 0x2e, 0xc2, 0xa6, 0x39, 0xe7, 0xdd, 0x95, 0xb8, 0x41,
+	0x46, 0x67, 0xec, 0xc3, 0xe6, 0xc5, 0x8c, 0xfc, 0x1e, 0x80, 0x9f, 0x48, 0x0d, 0xe5, 0x25, 0x29,
+	0x7d, 0x0a, 0x67, 0xe8, 0x50, 0x3f, 0x3c, 0x59, 0x44, 0xb6, 0xf7, 0xdc, 0x15, 0x29, 0x96, 0xb1,
+	0x09, 0x1b, 0xb2, 0x60, 0x90, 0x8a, 0xff, 0x87, 0x8a, 0x54, 0xc8, 0xf4, 0x0b, 0x3f, 0xe3, 0x3c,
+	0x54, 0x8a, 0xc6, 0x16, 0x90, 0x8e, 0xe9, 0x9b, 0x53, 0x67, 0xee, 0x60, 0xbf, 0xd2, 0xf4, 0x5b,
+	0x05, 0xd6, 0xd3, 0x6a, 0x5c, 0x6f, 0xe0, 0x5b, 0xf1, 0xe9, 0xe7, 0xdf, 0xd8, 0xe9, 0xdc, 0x9b,
+	0xcd, 0x96, 0xc5, 0x59, 0x2c, 0x92, 0x21, 0x6c, 0xb1, 0x17, 0x3e, 0x0b, 0x1c, 0x4e, 0xb3, 0xf9,
+	0x1e, 0xe3, 0x84, 0x15, 0x45, 0x59, 0xad, 0xb9, 0x9d, 0x44, 0xa6, 0x15, 0x08, 0xcd, 0xb5, 0x33,
+	0x3a, 0x70, 0x35, 0x07, 0x9c, 0x1b, 0x64, 0x1a, 0x50, 0x61, 0x2e, 0x46, 0x23, 0x71, 0xc2, 0xaa,
+	0x34, 0x16, 0x71, 0xc7, 0x30, 0x8a, 0xdc, 0xf7, 0xa6, 0xf1, 0x2a, 0xdf, 0x86, 0xca, 0x7d, 0x6f,
+	0x8a, 0x4a, 0x72, 0x13, 0x4a, 0xbf, 0xf1, 0xa6, 0xf1, 0xf6, 0xc3, 0xb2, 0x68, 0xa0, 0x5c, 0x6f,
+	0x7c, 0x57, 0x04, 0xf5, 0xbe, 0x37, 0x25, 0x75, 0x28, 0x3a, 0xe2, 0xa6, 0x51, 0x69, 0xd1, 0xb1,
+	0x91, 0x99, 0xa7, 0x2c, 0x3a, 0xf1, 0x6c, 0x79, 0xd3, 0x48, 0x29, 0xa7, 0x52, 0x54, 0x2f, 0x7d,
+	0x77, 0xc0, 0x4a, 0xb1, 0x24, 0x79, 0x84, 0xc5, 0x61, 0xba, 0x88, 0xd1, 0x5e, 0xa1, 0x88, 0x79,
+	0x0b, 0xb4, 0x30, 0x32, 0x23, 0xc6, 0x0f, 0x6f, 0x3d, 0x49, 0x8a, 0xef, 0x7b, 0xd3, 0xdd, 0x43,
+	0xd4, 0x53, 0xd1, 0x8c, 0x27, 0xf9, 0xeb, 0x05, 0x5b, 0x30, 0xbb, 0x25, 0x92, 0x10, 0x95, 0x26,
+	0x32, 0x8f, 0x34, 0x91, 0x19, 0x44, 0xbc, 0xb1, 0xca, 0x1b, 0x97, 0x0a, 0x64, 0xb6, 0x25, 0x23,
+	0x25, 0x6e, 0x1f, 0x2f, 0x19, 0x35, 0x9a, 0xd1, 0x19, 0x3b, 0xa0, 0xf1, 0xd1, 0x08, 0x40, 0xf9,
+	0x8b, 0xa3, 0xde, 0x51, 0xaf, 0xab, 0x17, 0xf0, 0x9c, 0xd3, 0xa3, 0x21, 0xcf, 0x0b, 0x14, 0xe3,
+	0x4d, 0xd8, 0xec, 0x98, 0xae, 0xc5, 0xe6, 0xb8, 0x0c, 0x51, 0x16, 0x5c, 0xd8, 0x4e, 0xe3, 0x1c,
+	0x08, 0x65, 0xa7, 0x5e, 0xc4, 0xe4, 0x25, 0xf9, 0xf5, 0x82, 0x85, 0x11, 0x69, 0x42, 0xd9, 0xe7,
+	0x78, 0x99, 0xf6, 0x37, 0xe4, 0x0a, 0x53, 0x50, 0xd1, 0x1f, 0x26, 0xce, 0x02, 0x49, 0x6e, 0xc9,
+	0xfa, 0xea, 0x8c, 0x75, 0x4e, 0x16, 0xee, 0x33, 0xee, 0x9e, 0xf5, 0x83, 0x02, 0xcd, 0x68, 0xdb,
+	0x6b, 0x50, 0x09, 0xc4, 0x20, 0xc6, 0x9f, 0x8b, 0x70, 0x65, 0xa5, 0x43, 0xf2, 0x19, 0x94, 0x9f,
+	0x7a, 0xc1, 0xa9, 0x19, 0xc9, 0x44, 0xe1, 0xad, 0xcb, 0x86, 0xde, 0x6d, 0x89, 0x7e, 0xf7, 0x38,
+	0x9a, 0x4a, 0x2b, 0x8c, 0xae, 0xc2, 0xe3, 0xbc, 0x8e, 0x16, 0x1c, 0x49, 0x69, 0x92, 0x97, 0x02,
+	0xf5, 0xb2, 0x97, 0x82, 0x95, 0x4a, 0xbb, 0x94, 0x57, 0x69, 0xa7, 0xca, 0x2b, 0x6d, 0xa5, 0x82,
+	0x4e, 0xc8, 0x54, 0xfe, 0x61, 0x32, 0x19, 0xef, 0xc2, 0x46, 0x66, 0x25, 0xa4, 0x02, 0xea, 0x57,
+	0xfd, 0xb1, 0x5e, 0xc0, 0x8f, 0x49, 0x0b, 0x93, 0x3d, 0x80, 0xf2, 0xa4, 0x45, 0x9f, 0xec, 0x7f,
+	0xa5, 0x17, 0x8d, 0x3d, 0xb8, 0xde, 0xf5, 0x9e, 0xbb, 0x73, 0xcf, 0xb4, 0xe3, 0x64, 0x68, 0x59,
+	0xf1, 0xc5, 0xa9, 0x85, 0x92, 0x49, 0x2d, 0x72, 0x73, 0xb3, 0x37, 0x60, 0x0d, 0xd3, 0x0d, 0xee,
+	0x15, 0x04, 0xd8, 0x66, 0x64, 0x72, 0xbb, 0x75, 0xca, 0xbf, 0x8d, 0xbb, 0x70, 0xa3, 0xcb, 0xe6,
+	0x2c, 0x62, 0xab, 0x3e, 0xba, 0x74, 0x24, 0xe3, 0x3b, 0x15, 0xd6, 0x1f, 0x99, 0xc1, 0xe9, 0xc2,
+	0x7f, 0xd9, 0x63, 0xe6, 0x7f, 0xcd, 0xf3, 0x53, 0xee, 0xab, 0x91, 0x76, 0xd9, 0xab, 0xd1, 0x9b,
+	0xa0, 0x21, 0x83, 0xc2, 0x46, 0x79, 0x47, 0xbd, 0xc8, 0x2d, 0xd1, 0x72, 0xf9, 0xc3, 0x52, 0xe5,
+	0x3f, 0xf1, 0xb0, 0x54, 0xcd, 0x7d, 0x58, 0x4a, 0x13, 0x72, 0xed, 0x15, 0x08, 0xf9, 0x4f, 0x05,
+	0x6a, 0xc2, 0x87, 0xe2, 0x01, 0xe0, 0xe7, 0x50, 0x0a, 0x23, 0xe6, 0xcb, 0xf3, 0x18, 0x57, 0x30,
+	0x29, 0xc4, 0xee, 0x61, 0xc4, 0x7c, 0xca, 0x41, 0x49, 0x78, 0x2d, 0xa6, 0xc2, 0xeb, 0x16, 0x68,
+	0xfc, 0x19, 0x45, 0x56, 0x16, 0x42, 0xc0, 0x40, 0xce, 0x3f, 0x42, 0x79, 0x6d, 0x4b, 0x89, 0x7c,
+	0x00, 0x30, 0x4d, 0x5e, 0x1f, 0x64, 0xc9, 0x9e, 0xa9, 0x16, 0x78, 0x03, 0x4d, 0x81, 0x8c, 0x8f,
+	0xa1, 0x84, 0x53, 0x20, 0x5b, 0xa0, 0x63, 0x4e, 0xd3, 0x1f, 0xee, 0x3f, 0x39, 0x68, 0xd1, 0xee,
+	0xa3, 0x16, 0xed, 0xe9, 0x05, 0x42, 0xa0, 0xde, 0x19, 0x3d, 0x1c, 0xf7, 0x07, 0xa8, 0xef, 0x8c,
+	0x68, 0x4f, 0xd4, 0xbe, 0xdd, 0xd1, 0xb0, 0xa7, 0x17, 0x8d, 0x7f, 0x28, 0xb0, 0xd9, 0x0d, 0x3c,
+	0x5f, 0xee, 0x17, 0x27, 0xed, 0x3d, 0x28, 0x87, 0x96, 0xe7, 0xcb, 0xe4, 0xa0, 0x9e, 0x64, 0xf6,
+	0x17, 0x70, 0xbb, 0x87, 0x08, 0xa2, 0x12, 0x8b, 0xa7, 0xe2, 0x69, 0x86, 0xbb, 0xb1, 0x98, 0xe3,
+	0x29, 0xf5, 0x87, 0x9f, 0x00, 0x4b, 0x17, 0x9e, 0x00, 0x8d, 0xcf, 0x41, 0xe3, 0x03, 0x62, 0xfa,
+	0x96, 0x5a, 0xde, 0x06, 0xac, 0x0d, 0xfa, 0x6d, 0xda, 0xa2, 0xfd, 0xde, 0xa1, 0xae, 0x60, 0xce,
+	0x87, 0x6b, 0x3c, 0xd4, 0x8b, 0xa4, 0x0e, 0xd0, 0x3e, 0xea, 0x0f, 0xba, 0x4f, 0xc6, 0xad, 0xc9,
+	0x81, 0xae, 0x1a, 0xbf, 0x4f, 0x2f, 0x55, 0x26, 0x7a, 0xef, 0x42, 0xc5, 0x0e, 0x3c, 0xdf, 0x67,
+	0xb6, 0xbc, 0x89, 0xaf, 0xa6, 0xd6, 0xea, 0x33, 0x31, 0x39, 0x1a, 0x63, 0x30, 0xba, 0x3e, 0x0d,
+	0x18, 0xb3, 0xdb, 0xe7, 0x91, 0x4c, 0xd0, 0x54, 0x9a, 0xd2, 0x60, 0x77, 0xe1, 0x33, 0x87, 0x77,
+	0xa7, 0xbe, 0xa4, 0x3b, 0x89, 0x31, 0x7e, 0xa7, 0xc0, 0x7a, 0xba, 0x85, 0x97, 0x54, 0xb8, 0x46,
+	0x49, 0xb6, 0x97, 0x6f, 0xbc, 0x80, 0x92, 0x1d, 0xa8, 0xd9, 0x2c, 0xb4, 0x02, 0x47, 0x3c, 0x03,
+	0x08, 0xe6, 0xa5, 0x55, 0x17, 0x66, 0xad, 0x5e, 0x9c, 0xf5, 0x3b, 0x0f, 0xa0, 0x96, 0x3a, 0x0a,
+	0x48, 0xa3, 0x6e, 0x6f, 0xaf, 0x75, 0x34, 0x98, 0x3c, 0x19, 0xd3, 0xfe, 0x88, 0xf6, 0x27, 0x8f,
+	0x45, 0x09, 0xde, 0x1f, 0x4e, 0x7a, 0xb4, 0xd5, 0x99, 0xf4, 0x8f, 0x7b, 0x22, 0x2a, 0x1f, 0xf7,
+	0x68, 0x7f, 0xef, 0xb1, 0xc8, 0xb4, 0xdb, 0xad, 0x49, 0xe7, 0x40, 0x57, 0xdf, 0xf9, 0x83, 0x02,
+	0xb0, 0xac, 0x6a, 0xc9, 0x15, 0xd8, 0x38, 0x1a, 0x3e, 0x18, 0x8e, 0x1e, 0x0d, 0x9f, 0x8c, 0x0f,
+	0x5a, 0x87, 0xe8, 0xb1, 0x1b, 0x70, 0xb5, 0xdb, 0x9b, 0xf4, 0x3a, 0x13, 0x24, 0x64, 0xda, 0x77,
+	0xaf, 0xc1, 0xb5, 0xfd, 0xde, 0xb0, 0x47, 0x5b, 0xbc, 0x65, 0x4c, 0x47, 0x93, 0xd1, 0xe4, 0xf1,
+	0x98, 0xfb, 0x72, 0x0b, 0xf4, 0x25, 0x89, 0x0f, 0x1f, 0xf4, 0xf8, 0x58, 0xd8, 0xd3, 0x52, 0xbb,
+	0xec, 0xa9, 0x94, 0xc3, 0x79, 0x0d, 0x53, 0x83, 0x41, 0x7f, 0xf8, 0x00, 0x53, 0x83, 0x72, 0xf3,
+	0xfb, 0x2a, 0x54, 0xda, 0xe2, 0xdf, 0x28, 0xf2, 0x3e, 0x68, 0xfc, 0x93, 0x64, 0x5e, 0xc6, 0xc4,
+	0x56, 0x6f, 0x6f, 0x26, 0xf7, 0xaf, 0x78, 0x32, 0x34, 0x0a, 0xef, 0x2b, 0xe4, 0x43, 0x58, 0x6f,
+	0x2d, 0x22, 0x4f, 0x56, 0x7d, 0xec, 0x15, 0x0d, 0xc9, 0x3d, 0x58, 0x4b, 0x3c, 0x49, 0xe2, 0x58,
+	0x97, 0x79, 0xb6, 0xcc, 0xb3, 0xfa, 0x1c, 0x6a, 0x89, 0xd5, 0x71, 0x93, 0x5c, 0xcf, 0xe7, 0xc4,
+	0xf6, 0x8a, 0x5e, 0x30, 0xdc, 0x28, 0x90, 0x5f, 0xc8, 0xa5, 0x1e, 0x37, 0xc9, 0xd6, 0xea, 0x44,
+	0x8f, 0x9b, 0xdb, 0xab, 0xe1, 0x85, 0xaf, 0xf2, 0x73, 0xa8, 0xa7, 0x57, 0x79, 0xa9, 0xf9, 0xb5,
+	0x95, 0x92, 0x12, 0x33, 0x5c, 0xbe, 0x5e, 0xed, 0x91, 0x19, 0x59, 0x27, 0x3f, 0x6e, 0xd8, 0x4f,
+	0x01, 0x96, 0xf5, 0xd8, 0x25, 0xa6, 0x37, 0x92, 0xf7, 0xd1, 0x6c, 0xe1, 0x66, 0x14, 0xc8, 0xaf,
+	0xa1, 0xde, 0x5d, 0x9c, 0xfa, 0xa9, 0x8b, 0x22, 0xbf, 0x8b, 0xeb, 0x39, 0x77, 0x0d, 0xd6, 0x39,
+	0x38, 0x6d, 0xd8, 0x67, 0x51, 0x7c, 0xab, 0xa5, 0xfc, 0xb4, 0x2c, 0x75, 0xb6, 0xeb, 0x59, 0xad,
+	0x51, 0x20, 0x1d, 0xd8, 0xdc, 0x67, 0x51, 0xa6, 0x86, 0x49, 0x8a, 0xfe, 0x95, 0x7a, 0x67, 0xfb,
+	0x6a, 0x4e, 0x13, 0x1f, 0xba, 0x1a, 0x17, 0x59, 0x24, 0x79, 0x91, 0xcb, 0x54, 0x5d, 0x79, 0x0c,
+	0xb9, 0x0b, 0xd5, 0xb8, 0xd0, 0x48, 0xac, 0xb2, 0x95, 0x47, 0x32, 0x5f, 0x59, 0x7e, 0x70, 0x56,
+	0xac, 0x25, 0xc9, 0x71, 0x42, 0xaa, 0x0b, 0xe9, 0x72, 0xde, 0x60, 0x2d, 0xa8, 0xa5, 0x32, 0xa2,
+	0x64, 0x8d, 0xab, 0x59, 0x74, 0xae, 0x7f, 0x6f, 0x2b, 0xef, 0x2b, 0x64, 0x1f, 0xf4, 0x8b, 0x39,
+	0x1c, 0xf9, 0xbf, 0x98, 0xbe, 0xb9, 0xc9, 0xdd, 0xb6, 0x9e, 0x7a, 0x85, 0xe6, 0x39, 0x1b, 0xa7,
+	0xca, 0x1e, 0x5c, 0x59, 0xc9, 0xd1, 0xc8, 0xcd, 0xb8, 0xa7, 0xfc, 0xec, 0x2d, 0x6f, 0x4d, 0x1f,
+	0x42, 0x59, 0xdc, 0xe7, 0xe4, 0x6a, 0xe6, 0x7a, 0x97, 0x16, 0x64, 0xf5, 0xce, 0xc7, 0xe1, 0xdb,
+	0xb7, 0x80, 0x58, 0xd6, 0xae, 0x4c, 0x84, 0x76, 0xe5, 0x9f, 0xdb, 0xed, 0x75, 0x19, 0x57, 0xc6,
+	0x68, 0x33, 0x56, 0xa6, 0x65, 0x6e, 0x7c, 0xf7, 0x5f, 0x03, 0x00, 0xcd, 0xf9, 0xb1, 0x43, 0xfe,
+	0x1e, 0x00, 0x00,
 }
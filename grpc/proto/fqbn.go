@@ -0,0 +1,69 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package proto
+
+import (
+	"fmt"
+
+	"github.com/arduino/arduino-cli/arduino/cores"
+)
+
+// ParseFQBN converts a FQBN in its string form, e.g.
+// "arduino:avr:mega:cpu=atmega2560", into a FQBN. It is shared by the
+// daemon and its clients, so that both accept the same FQBNs.
+func ParseFQBN(fqbnIn string) (*FQBN, error) {
+	fqbn, err := cores.ParseFQBN(fqbnIn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing fqbn: %s", err)
+	}
+	res := &FQBN{
+		Package:      fqbn.Package,
+		Architecture: fqbn.PlatformArch,
+		BoardID:      fqbn.BoardID,
+	}
+	for _, name := range fqbn.Configs.Keys() {
+		res.Options = append(res.Options, &BoardOption{Name: name, Value: fqbn.Configs.Get(name)})
+	}
+	return res, nil
+}
+
+// FormatFQBN converts a FQBN into its string form
+func FormatFQBN(fqbn *FQBN) string {
+	res := fqbn.Package + ":" + fqbn.Architecture + ":" + fqbn.BoardID
+	for i, option := range fqbn.Options {
+		if i == 0 {
+			res += ":"
+		} else {
+			res += ","
+		}
+		res += option.Name + "=" + option.Value
+	}
+	return res
+}
@@ -0,0 +1,374 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arduino/arduino-builder/events"
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/go-paths-helper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// remoteBuildExpiry is how long the files of a remote build are kept after
+// the build or the last download
+const remoteBuildExpiry = time.Hour
+
+// RemoteBuildOptions are the settings of the remote builds, which are run
+// against the hardware, tools and libraries installed on the daemon host
+type RemoteBuildOptions struct {
+	HardwareFolders         []string
+	ToolsFolders            []string
+	BuiltInLibrariesFolders []string
+	OtherLibrariesFolders   []string
+	ArduinoAPIVersion       string
+	// Folder holds the sketches and the build outputs of the remote builds,
+	// defaults to a temporary folder deleted when the daemon exits
+	Folder string
+}
+
+// remoteBuilds keeps the files of the remote builds until they expire
+type remoteBuilds struct {
+	opts *RemoteBuildOptions
+	root *paths.Path
+	// temporary is true if root is deleted when the daemon exits
+	temporary bool
+	// cachePath holds the cores shared by all remote builds
+	cachePath *paths.Path
+
+	mux    sync.Mutex
+	builds map[string]*remoteBuild
+}
+
+// remoteBuild is a remote build whose artifacts can be downloaded
+type remoteBuild struct {
+	id  string
+	dir *paths.Path
+	// artifacts maps the names of the artifacts to their paths
+	artifacts map[string]*paths.Path
+	expires   time.Time
+}
+
+func newRemoteBuilds(opts *RemoteBuildOptions) (*remoteBuilds, error) {
+	r := &remoteBuilds{opts: opts, builds: map[string]*remoteBuild{}}
+	if opts.Folder == "" {
+		root, err := paths.MkTempDir("", "arduino-builder-remote")
+		if err != nil {
+			return nil, err
+		}
+		r.root = root
+		r.temporary = true
+	} else {
+		r.root = paths.New(opts.Folder)
+		if err := r.root.MkdirAll(); err != nil {
+			return nil, err
+		}
+	}
+	r.cachePath = r.root.Join("cache")
+	if err := r.cachePath.MkdirAll(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// create creates the folder of a new build, which can't be downloaded from
+// until it is added
+func (r *remoteBuilds) create() (*remoteBuild, error) {
+	r.prune()
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	build := &remoteBuild{
+		id:        hex.EncodeToString(id[:]),
+		artifacts: map[string]*paths.Path{},
+	}
+	build.dir = r.root.Join(build.id)
+	if err := build.dir.MkdirAll(); err != nil {
+		return nil, err
+	}
+	return build, nil
+}
+
+func (r *remoteBuilds) add(build *remoteBuild) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	build.expires = time.Now().Add(remoteBuildExpiry)
+	r.builds[build.id] = build
+}
+
+// artifact returns the path of an artifact of a build, extending the life
+// of the build
+func (r *remoteBuilds) artifact(id, name string) (*paths.Path, error) {
+	r.prune()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	build, ok := r.builds[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "remote build %s not found or expired", id)
+	}
+	path, ok := build.artifacts[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "artifact %s not found", name)
+	}
+	build.expires = time.Now().Add(remoteBuildExpiry)
+	return path, nil
+}
+
+func (r *remoteBuilds) delete(id string) bool {
+	r.mux.Lock()
+	build, ok := r.builds[id]
+	delete(r.builds, id)
+	r.mux.Unlock()
+	if ok {
+		build.dir.RemoveAll()
+	}
+	return ok
+}
+
+// prune deletes the expired builds
+func (r *remoteBuilds) prune() {
+	now := time.Now()
+	var expired []*remoteBuild
+	r.mux.Lock()
+	for id, build := range r.builds {
+		if now.After(build.expires) {
+			expired = append(expired, build)
+			delete(r.builds, id)
+		}
+	}
+	r.mux.Unlock()
+	for _, build := range expired {
+		build.dir.RemoveAll()
+	}
+}
+
+// close deletes the temporary folder of the remote builds
+func (r *remoteBuilds) close() {
+	if r.temporary {
+		r.root.RemoveAll()
+	}
+}
+
+// remoteEventStream sends the events of a remote build, with the paths in
+// the folder of the build made relative to it, so that they match the files
+// uploaded by the client. The paths of the hardware, tools and libraries of
+// the daemon are sent as they are.
+type remoteEventStream struct {
+	stream events.Stream
+	build  *remoteBuild
+	prefix string
+}
+
+func (s *remoteEventStream) Send(event *pb.BuildEvent) error {
+	switch e := event.Event.(type) {
+	case *pb.BuildEvent_Log:
+		e.Log.Message = s.relative(e.Log.Message)
+		for i, arg := range e.Log.Arguments {
+			e.Log.Arguments[i] = s.relative(arg)
+		}
+	case *pb.BuildEvent_Diagnostic:
+		s.relativeDiagnostic(e.Diagnostic)
+	case *pb.BuildEvent_Artifact:
+		path := paths.New(e.Artifact.Path)
+		s.build.artifacts[path.Base()] = path
+		e.Artifact.Path = path.Base()
+	case *pb.BuildEvent_Result:
+		e.Result.Error = s.relative(e.Result.Error)
+		e.Result.BuildID = s.build.id
+	}
+	return s.stream.Send(event)
+}
+
+func (s *remoteEventStream) relativeDiagnostic(d *pb.CompilerDiagnostic) {
+	d.File = s.relative(d.File)
+	d.Message = s.relative(d.Message)
+	d.Context = s.relative(d.Context)
+	for _, location := range d.IncludedFrom {
+		location.File = s.relative(location.File)
+	}
+	for _, note := range d.Notes {
+		s.relativeDiagnostic(note)
+	}
+}
+
+func (s *remoteEventStream) relative(str string) string {
+	return strings.Replace(str, s.prefix, "", -1)
+}
+
+func (s *builderServer) RemoteBuild(stream pb.Builder_RemoteBuildServer) error {
+	if s.remote == nil {
+		return status.Error(codes.FailedPrecondition, "remote builds are not enabled on this daemon")
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	params := first.GetParams()
+	if params == nil {
+		return status.Error(codes.InvalidArgument, "the first message must carry the params")
+	}
+	switch params.WarningsLevel {
+	case "", "none", "default", "more", "all":
+	default:
+		return status.Errorf(codes.InvalidArgument, "invalid warnings level %s", params.WarningsLevel)
+	}
+
+	build, err := s.remote.create()
+	if err != nil {
+		return status.Errorf(codes.Internal, "creating the build folder: %s", err)
+	}
+	added := false
+	defer func() {
+		if !added {
+			build.dir.RemoveAll()
+		}
+	}()
+
+	archive := build.dir.Join("archive")
+	if err := receiveArchive(stream, archive); err != nil {
+		return err
+	}
+	sketchFolder := build.dir.Join("sketch")
+	if err := extractArchive(params.Format, archive, sketchFolder); err != nil {
+		return status.Errorf(codes.InvalidArgument, "extracting the archive: %s", err)
+	}
+	archive.Remove()
+	sketch, err := remoteSketchLocation(sketchFolder, params.SketchPath)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	opts := s.remote.opts
+	args := &pb.BuildParamsV2{
+		HardwareFolders:         opts.HardwareFolders,
+		ToolsFolders:            opts.ToolsFolders,
+		BuiltInLibrariesFolders: opts.BuiltInLibrariesFolders,
+		OtherLibrariesFolders:   opts.OtherLibrariesFolders,
+		SketchLocation:          sketch.String(),
+		Fqbn:                    params.Fqbn,
+		ArduinoAPIVersion:       opts.ArduinoAPIVersion,
+		BuildCachePath:          s.remote.cachePath.String(),
+		BuildPath:               build.dir.Join("build").String(),
+		WarningsLevel:           params.WarningsLevel,
+		Verbose:                 params.Verbose,
+		Priority:                params.Priority,
+	}
+	events := &remoteEventStream{
+		stream: stream,
+		build:  build,
+		prefix: build.dir.String() + string(os.PathSeparator),
+	}
+	if err := s.buildWithEvents(stream.Context(), args, events); err != nil {
+		return err
+	}
+	s.remote.add(build)
+	added = true
+	return nil
+}
+
+// receiveArchive writes the archive chunks sent on stream to path, until
+// the client closes its side of the stream
+func receiveArchive(stream pb.Builder_RemoteBuildServer, path *paths.Path) error {
+	f, err := path.Create()
+	if err != nil {
+		return status.Errorf(codes.Internal, "creating the archive: %s", err)
+	}
+	defer f.Close()
+	size := 0
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		chunk := req.GetArchiveChunk()
+		if size += len(chunk); size > maxRemoteArchiveSize {
+			return status.Errorf(codes.ResourceExhausted, "the archive is larger than %d bytes", maxRemoteArchiveSize)
+		}
+		if _, err := f.Write(chunk); err != nil {
+			return status.Errorf(codes.Internal, "writing the archive: %s", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return status.Errorf(codes.Internal, "writing the archive: %s", err)
+	}
+	return nil
+}
+
+func (s *builderServer) DownloadArtifact(args *pb.DownloadArtifactParams, stream pb.Builder_DownloadArtifactServer) error {
+	if s.remote == nil {
+		return status.Error(codes.FailedPrecondition, "remote builds are not enabled on this daemon")
+	}
+	path, err := s.remote.artifact(args.BuildID, args.Name)
+	if err != nil {
+		return err
+	}
+	f, err := path.Open()
+	if err != nil {
+		return status.Errorf(codes.NotFound, "artifact %s not found", args.Name)
+	}
+	defer f.Close()
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.FileChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "reading the artifact: %s", err)
+		}
+	}
+}
+
+func (s *builderServer) DeleteRemoteBuild(ctx context.Context, args *pb.DeleteRemoteBuildParams) (*pb.Response, error) {
+	if s.remote == nil {
+		return nil, status.Error(codes.FailedPrecondition, "remote builds are not enabled on this daemon")
+	}
+	if !s.remote.delete(args.BuildID) {
+		return nil, status.Errorf(codes.NotFound, "remote build %s not found or expired", args.BuildID)
+	}
+	return &pb.Response{Line: "Remote build " + args.BuildID + " deleted"}, nil
+}
@@ -29,23 +29,34 @@
 package grpc
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
 	"net"
-	"os"
+	"net/http"
+	"net/http/pprof"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/arduino/arduino-builder/completion"
+	"github.com/arduino/arduino-builder/events"
 	pb "github.com/arduino/arduino-builder/grpc/proto"
-	"github.com/arduino/arduino-cli/arduino/cores"
+	"github.com/arduino/arduino-builder/sourcemap"
 	"github.com/arduino/arduino-cli/legacy/builder"
 	"github.com/arduino/arduino-cli/legacy/builder/i18n"
 	"github.com/arduino/arduino-cli/legacy/builder/types"
-	"github.com/arduino/arduino-cli/legacy/builder/utils"
 	"github.com/arduino/go-paths-helper"
-	"github.com/fsnotify/fsnotify"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 type StreamLogger struct {
@@ -77,185 +88,635 @@ func (s StreamLogger) Name() string {
 }
 
 type builderServer struct {
-	resp    []*pb.Response
-	ctx     *types.Context
-	watcher *fsnotify.Watcher
-}
-
-func (s *builderServer) watch() {
-	folders := []paths.PathList{s.ctx.HardwareDirs, s.ctx.BuiltInToolsDirs, s.ctx.BuiltInLibrariesDirs, s.ctx.OtherLibrariesDirs}
-
-	for _, category := range folders {
-		for _, folder := range category {
-			if !s.ctx.WatchedLocations.Contains(folder) {
-				var subfolders []string
-				utils.FindAllSubdirectories(folder.String(), &subfolders)
-				subfolders = append(subfolders, folder.String())
-				for _, element := range subfolders {
-					s.watcher.Add(element)
-					s.ctx.WatchedLocations.AddIfMissing(paths.New(element))
-				}
-			}
+	// ctx holds the daemon-wide settings, it is used as a template for the
+	// contexts of each request and is never used to run a build directly
+	ctx       *types.Context
+	hardware  *hardwareCache
+	libraries *librariesCache
+	// trimMux serializes the evictions of the cached data beyond the
+	// memory limit
+	trimMux   sync.Mutex
+	scheduler *scheduler
+	metrics   *metrics
+	// watcher drops the cached data loaded from the folders that change, it
+	// is nil if watching is not supported
+	watcher *watcher
+	// remote keeps the files of the remote builds, it is nil if remote
+	// builds are not enabled
+	remote *remoteBuilds
+
+	daemon *Daemon
+}
+
+// newBuildContext creates a new context for a single request out of the
+// given parameters. Every request gets its own context, so that concurrent
+// requests don't overwrite each other's build state: only the parsed
+// hardware, tools and libraries, which builds don't modify, are shared
+// between them.
+func (s *builderServer) newBuildContext(args *pb.BuildParamsV2) (*types.Context, error) {
+	fqbn, err := toCoresFQBN(args.Fqbn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &types.Context{}
+	ctx.IgnoreSketchFolderNameErrors = s.ctx.IgnoreSketchFolderNameErrors
+	ctx.UseArduinoPreprocessor = s.ctx.UseArduinoPreprocessor
+	ctx.DebugLevel = s.ctx.DebugLevel
+
+	ctx.HardwareDirs = paths.NewPathList(args.HardwareFolders...)
+	ctx.BuiltInToolsDirs = paths.NewPathList(args.ToolsFolders...)
+	ctx.BuiltInLibrariesDirs = paths.NewPathList(args.BuiltInLibrariesFolders...)
+	ctx.OtherLibrariesDirs = paths.NewPathList(args.OtherLibrariesFolders...)
+	ctx.SketchLocation = paths.New(args.SketchLocation)
+	for _, prop := range args.CustomBuildProperties {
+		ctx.CustomBuildProperties = append(ctx.CustomBuildProperties, prop.Key+"="+prop.Value)
+	}
+	ctx.ArduinoAPIVersion = args.ArduinoAPIVersion
+	ctx.FQBN = fqbn
+	ctx.USBVidPid = args.VidPid
+	ctx.BuildCachePath = paths.New(args.BuildCachePath)
+	ctx.BuildPath = paths.New(args.BuildPath)
+	if ctx.BuildPath == nil {
+		// pick it now rather than leaving it to the builder, so that the
+		// processes of the build can be found if it gets canceled
+		ctx.BuildPath = defaultBuildPath(ctx.SketchLocation)
+	}
+	ctx.WarningsLevel = args.WarningsLevel
+	ctx.SetLogger(i18n.NoopLogger{})
+
+	overrides, err := sourceOverrides(ctx.SketchLocation, args.Overlays)
+	if err != nil {
+		return nil, err
+	}
+	ctx.SourceOverride = overrides
+
+	if s.watcher != nil {
+		s.watcher.Add(ctx.HardwareDirs)
+		s.watcher.Add(ctx.BuiltInToolsDirs)
+		s.watcher.Add(ctx.BuiltInLibrariesDirs)
+		s.watcher.Add(ctx.OtherLibrariesDirs)
+	}
+
+	return ctx, nil
+}
+
+// loadHardware gives ctx the hardware, tools and libraries it needs, from
+// the caches when they are already loaded. Loading them can take as long as
+// a build, so it is done only once the scheduler has started the job.
+func (s *builderServer) loadHardware(ctx *types.Context) error {
+	pm, allTools, err := s.hardware.Get(ctx.HardwareDirs, ctx.BuiltInToolsDirs)
+	if err != nil {
+		return fmt.Errorf("loading hardware: %s", err)
+	}
+	ctx.PackageManager = pm
+	ctx.AllTools = allTools
+	if board, err := pm.FindBoardWithFQBN(ctx.FQBN.String()); err == nil {
+		if requiredTools, err := pm.FindToolsRequiredForBoard(board); err == nil {
+			ctx.RequiredTools = requiredTools
 		}
 	}
+	// tools are already loaded in the shared PackageManager, the builder
+	// must not load them again
+	ctx.CanUseCachedTools = true
+	ctx.LibrariesManager = s.libraries.Get(ctx)
+	return nil
 }
 
-func (s *builderServer) DropCache(ctx context.Context, args *pb.VerboseParams) (*pb.Response, error) {
-	s.ctx.CanUseCachedTools = false
-	response := pb.Response{Line: "Tools cache dropped"}
-	return &response, nil
+// invalidate drops the cached data loaded from the changed paths. The
+// hardware is reloaded only when a file it is parsed from changes, not when
+// e.g. the sources of a core are edited; a PackageManager can't be reloaded
+// one platform at a time, since it is shared by the running builds.
+func (s *builderServer) invalidate(changed []string) {
+	for _, path := range changed {
+		if affectsHardware(path) {
+			s.hardware.DropContaining(path)
+		}
+		s.libraries.DropContaining(path)
+	}
 }
 
-// GetFeature returns the feature at the given point.
-func (s *builderServer) Autocomplete(ctx context.Context, args *pb.BuildParams) (*pb.Response, error) {
+// run runs a build step on buildCtx once the scheduler lets it start,
+// caching the libraries it loaded for the following builds
+func (s *builderServer) run(ctx context.Context, buildCtx *types.Context, priority pb.JobPriority, step func(*types.Context) error) error {
+	job, err := s.scheduler.enqueue(ctx, buildCtx, priority)
+	if err != nil {
+		return err
+	}
+	defer s.scheduler.done(job)
+	s.metrics.jobStarted(job)
+
+	buildCtx.Jobs = job.compilerJobs
+	timer := newPhaseTimer(buildCtx, s.metrics)
+	buildCtx.SetLogger(timer)
+	err = s.loadHardware(buildCtx)
+	if err == nil {
+		err = runCancelable(job.ctx, buildCtx, step)
+	}
+	timer.finish()
+	s.metrics.jobEnded(job, err)
+	if job.ctx.Err() == nil {
+		s.libraries.Store(buildCtx)
+	}
+	if limit := s.daemon.opts.CacheMemoryLimit; limit > 0 {
+		s.trimMux.Lock()
+		trimCaches(limit, s.hardware, s.libraries)
+		s.trimMux.Unlock()
+	}
+	return err
+}
 
-	s.ctx.HardwareDirs = paths.NewPathList(strings.Split(args.HardwareFolders, ",")...)
-	s.ctx.BuiltInToolsDirs = paths.NewPathList(strings.Split(args.ToolsFolders, ",")...)
-	s.ctx.BuiltInLibrariesDirs = paths.NewPathList(strings.Split(args.BuiltInLibrariesFolders, ",")...)
-	s.ctx.OtherLibrariesDirs = paths.NewPathList(strings.Split(args.OtherLibrariesFolders, ",")...)
-	s.ctx.SketchLocation = paths.New(args.SketchLocation)
-	s.ctx.CustomBuildProperties = strings.Split(args.CustomBuildProperties, ",")
-	s.ctx.ArduinoAPIVersion = args.ArduinoAPIVersion
-	if fqbn, err := cores.ParseFQBN(args.FQBN); err != nil {
-		return nil, fmt.Errorf("parsing fqbn: %s", err)
-	} else {
-		s.ctx.FQBN = fqbn
+// jobPriority returns the priority requested in args, or def if none is
+func jobPriority(args *pb.BuildParamsV2, def pb.JobPriority) pb.JobPriority {
+	if args.Priority == pb.JobPriority_DEFAULT_PRIORITY {
+		return def
 	}
-	s.ctx.Verbose = false //p.Verbose
-	s.ctx.BuildCachePath = paths.New(args.BuildCachePath)
-	s.ctx.BuildPath = paths.New(args.BuildPath)
-	s.ctx.WarningsLevel = args.WarningsLevel
-	s.ctx.PrototypesSection = ""
-	s.ctx.CodeCompleteAt = args.CodeCompleteAt
-	s.ctx.CodeCompletions = ""
+	return args.Priority
+}
 
-	s.ctx.IncludeFolders = s.ctx.IncludeFolders[0:0]
-	s.ctx.LibrariesObjectFiles = s.ctx.LibrariesObjectFiles[0:0]
-	s.ctx.CoreObjectsFiles = s.ctx.CoreObjectsFiles[0:0]
-	s.ctx.SketchObjectFiles = s.ctx.SketchObjectFiles[0:0]
+// buildPriority returns the priority requested in args for a full build.
+// Builds can't run as interactive jobs, which start beyond the limit of
+// concurrent builds: any client could otherwise overload the daemon.
+func buildPriority(args *pb.BuildParamsV2) pb.JobPriority {
+	priority := jobPriority(args, pb.JobPriority_VERIFY)
+	if priority < pb.JobPriority_VERIFY {
+		return pb.JobPriority_VERIFY
+	}
+	return priority
+}
 
-	s.ctx.ImportedLibraries = s.ctx.ImportedLibraries[0:0]
+func (s *builderServer) DropCache(ctx context.Context, args *pb.VerboseParams) (*pb.Response, error) {
+	s.hardware.Clear()
+	s.libraries.Clear()
+	response := pb.Response{Line: "Tools cache dropped"}
+	return &response, nil
+}
 
-	//s.watch()
+func (s *builderServer) GetVersion(ctx context.Context, args *pb.VersionParams) (*pb.Version, error) {
+	return &pb.Version{Version: s.daemon.opts.Version}, nil
+}
 
-	oldlogger := s.ctx.GetLogger()
-	logger := i18n.NoopLogger{}
-	s.ctx.SetLogger(logger)
+func (s *builderServer) GetCapabilities(ctx context.Context, args *pb.CapabilitiesParams) (*pb.Capabilities, error) {
+	res := &pb.Capabilities{Loggers: s.daemon.opts.Loggers}
+	for service, info := range s.daemon.grpcServer.GetServiceInfo() {
+		for _, method := range info.Methods {
+			res.Rpcs = append(res.Rpcs, "/"+service+"/"+method.Name)
+		}
+	}
+	sort.Strings(res.Rpcs)
+	res.ExperimentalFeatures = []*pb.ExperimentalFeature{
+		{Name: "arduino-preprocessor", Enabled: s.ctx.UseArduinoPreprocessor},
+	}
+	return res, nil
+}
 
-	err := builder.RunPreprocess(s.ctx)
+func (s *builderServer) Shutdown(ctx context.Context, args *pb.ShutdownParams) (*pb.Response, error) {
+	s.daemon.Shutdown()
+	return &pb.Response{Line: "Shutting down"}, nil
+}
 
-	response := pb.Response{Line: s.ctx.CodeCompletions}
-	s.ctx.SetLogger(oldlogger)
+func (s *builderServer) ListJobs(ctx context.Context, args *pb.ListJobsParams) (*pb.JobList, error) {
+	return &pb.JobList{Jobs: s.scheduler.list()}, nil
+}
 
-	return &response, err
+func (s *builderServer) CancelJob(ctx context.Context, args *pb.CancelJobParams) (*pb.Response, error) {
+	if !s.scheduler.cancel(args.Id) {
+		return nil, status.Errorf(codes.NotFound, "no job with id %d", args.Id)
+	}
+	return &pb.Response{Line: fmt.Sprintf("Job %d canceled", args.Id)}, nil
 }
 
 // GetFeature returns the feature at the given point.
-func (s *builderServer) Build(args *pb.BuildParams, stream pb.Builder_BuildServer) error {
+func (s *builderServer) Autocomplete(ctx context.Context, args *pb.BuildParams) (*pb.Response, error) {
+	params, err := upgradeBuildParams(args)
+	if err != nil {
+		return nil, err
+	}
+	buildCtx, err := s.newBuildContext(params)
+	if err != nil {
+		return nil, err
+	}
+	buildCtx.Verbose = false //p.Verbose
+	buildCtx.CodeCompleteAt = params.CodeCompleteAt
 
-	s.ctx.HardwareDirs = paths.NewPathList(strings.Split(args.HardwareFolders, ",")...)
-	s.ctx.BuiltInToolsDirs = paths.NewPathList(strings.Split(args.ToolsFolders, ",")...)
-	s.ctx.BuiltInLibrariesDirs = paths.NewPathList(strings.Split(args.BuiltInLibrariesFolders, ",")...)
-	s.ctx.OtherLibrariesDirs = paths.NewPathList(strings.Split(args.OtherLibrariesFolders, ",")...)
-	s.ctx.SketchLocation = paths.New(args.SketchLocation)
-	s.ctx.CustomBuildProperties = strings.Split(args.CustomBuildProperties, ",")
-	s.ctx.ArduinoAPIVersion = args.ArduinoAPIVersion
-	if fqbn, err := cores.ParseFQBN(args.FQBN); err != nil {
-		return fmt.Errorf("parsing fqbn: %s", err)
-	} else {
-		s.ctx.FQBN = fqbn
+	if err := s.run(ctx, buildCtx, pb.JobPriority_INTERACTIVE, builder.RunPreprocess); err != nil {
+		return nil, err
 	}
-	s.ctx.Verbose = args.Verbose
-	s.ctx.BuildCachePath = paths.New(args.BuildCachePath)
-	s.ctx.BuildPath = paths.New(args.BuildPath)
-	s.ctx.WarningsLevel = args.WarningsLevel
-	s.ctx.PrototypesSection = ""
-	s.ctx.CodeCompleteAt = ""
 
-	s.ctx.IncludeFolders = s.ctx.IncludeFolders[0:0]
-	s.ctx.LibrariesObjectFiles = s.ctx.LibrariesObjectFiles[0:0]
-	s.ctx.CoreObjectsFiles = s.ctx.CoreObjectsFiles[0:0]
-	s.ctx.SketchObjectFiles = s.ctx.SketchObjectFiles[0:0]
+	return &pb.Response{Line: buildCtx.CodeCompletions}, nil
+}
 
-	s.ctx.ImportedLibraries = s.ctx.ImportedLibraries[0:0]
+func (s *builderServer) AutocompleteV2(ctx context.Context, args *pb.BuildParamsV2) (*pb.CompletionList, error) {
+	buildCtx, err := s.newBuildContext(args)
+	if err != nil {
+		return nil, err
+	}
+	buildCtx.Verbose = false
+	buildCtx.CodeCompleteAt = args.CodeCompleteAt
 
-	// setup logger to send via protobuf
-	oldlogger := s.ctx.GetLogger()
-	logger := StreamLogger{stream}
-	s.ctx.SetLogger(logger)
+	diagnostics := &events.DiagnosticsCollector{}
+	buildCtx.SetLogger(events.NewEventLogger(diagnostics, buildCtx))
+
+	err = s.run(ctx, buildCtx, jobPriority(args, pb.JobPriority_INTERACTIVE), builder.RunPreprocess)
+	if isCanceled(err) {
+		return nil, err
+	}
 
-	//s.watch()
+	res := &pb.CompletionList{
+		Completions: completion.Parse(buildCtx.CodeCompletions),
+		Diagnostics: diagnostics.Diagnostics,
+		Output:      buildCtx.CodeCompletions,
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res, nil
+}
 
-	err := builder.RunBuilder(s.ctx)
-	s.ctx.SetLogger(oldlogger)
+// GetFeature returns the feature at the given point.
+func (s *builderServer) Build(args *pb.BuildParams, stream pb.Builder_BuildServer) error {
+	params, err := upgradeBuildParams(args)
+	if err != nil {
+		return err
+	}
+	buildCtx, err := s.newBuildContext(params)
 	if err != nil {
 		return err
 	}
+	buildCtx.Verbose = params.Verbose
+
+	// setup logger to send via protobuf
+	buildCtx.SetLogger(StreamLogger{stream})
+
+	if err := s.run(stream.Context(), buildCtx, pb.JobPriority_VERIFY, builder.RunBuilder); err != nil {
+		return err
+	}
 
 	// No feature was found, return an unnamed feature
 	return nil
 }
 
-/*
-func (h *WatchHandler) ServeJSONRPC(c context.Context, params *json.RawMessage) (interface{}, *jsonrpc.Error) {
+func (s *builderServer) Preprocess(ctx context.Context, args *pb.BuildParamsV2) (*pb.PreprocessResult, error) {
+	buildCtx, err := s.newBuildContext(args)
+	if err != nil {
+		return nil, err
+	}
+	buildCtx.Verbose = false
 
-	var p WatchParams
-	if err := jsonrpc.Unmarshal(params, &p); err != nil {
+	if err := s.run(ctx, buildCtx, jobPriority(args, pb.JobPriority_INTERACTIVE), builder.RunPreprocess); err != nil {
 		return nil, err
 	}
 
-	err := h.watcher.Add(p.Path)
+	res := &pb.PreprocessResult{Source: buildCtx.Source}
+	for _, mapping := range sourcemap.Parse(buildCtx.Source) {
+		res.LineMap = append(res.LineMap, &pb.LineMapping{
+			GeneratedLine: int32(mapping.GeneratedLine),
+			Lines:         int32(mapping.Lines),
+			File:          mapping.File,
+			OriginalLine:  int32(mapping.OriginalLine),
+		})
+	}
+	return res, nil
+}
+
+func (s *builderServer) DumpProperties(ctx context.Context, args *pb.BuildParamsV2) (*pb.BuildProperties, error) {
+	buildCtx, err := s.newBuildContext(args)
 	if err != nil {
-		return nil, jsonrpc.ErrInvalidParams()
+		return nil, err
+	}
+	buildCtx.Verbose = false
+
+	if err := s.run(ctx, buildCtx, jobPriority(args, pb.JobPriority_INTERACTIVE), builder.RunParseHardware); err != nil {
+		return nil, err
+	}
+
+	res := &pb.BuildProperties{}
+	keys := buildCtx.BuildProperties.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		res.Properties = append(res.Properties, &pb.BuildProperty{Key: key, Value: buildCtx.BuildProperties.Get(key)})
+	}
+	return res, nil
+}
+
+func (s *builderServer) BuildV2(args *pb.BuildParamsV2, stream pb.Builder_BuildV2Server) error {
+	return s.buildWithEvents(stream.Context(), args, stream)
+}
+
+// buildWithEvents builds the sketch, sending the build progress as events
+// on stream. A failed build is reported with a BuildResult event, so an
+// error is returned only if the build could not start or was canceled.
+func (s *builderServer) buildWithEvents(ctx context.Context, args *pb.BuildParamsV2, stream events.Stream) error {
+	buildCtx, err := s.newBuildContext(args)
+	if err != nil {
+		return err
+	}
+	buildCtx.Verbose = args.Verbose
+	buildCtx.Progress.PrintEnabled = true
+
+	logger := events.NewEventLogger(stream, buildCtx)
+	buildCtx.SetLogger(logger)
+
+	err = s.run(ctx, buildCtx, buildPriority(args), builder.RunBuilder)
+	if isCanceled(err) {
+		return err
 	}
-	return BuildResult{
-		Message: "OK " + p.Path,
-	}, nil
+	if err == nil {
+		logger.SendArtifacts(buildCtx)
+	}
+	logger.SendResult(buildCtx, err)
+	return nil
 }
-*/
 
-func startWatching(ctx *types.Context) *fsnotify.Watcher {
-	watcher, err := fsnotify.NewWatcher()
+func (s *builderServer) Watch(args *pb.BuildParamsV2, stream pb.Builder_WatchServer) error {
+	ctx := stream.Context()
+
+	changes := make(chan []string)
+	w, err := newWatcher(func(changed []string) {
+		select {
+		case changes <- changed:
+		case <-ctx.Done():
+		}
+	})
 	if err != nil {
-		log.Fatal(err)
+		return status.Errorf(codes.Unavailable, "can't watch folders: %s", err)
+	}
+	defer w.Close()
+
+	sketchFolder := paths.New(args.SketchLocation)
+	if sketchFolder != nil && !sketchFolder.IsDir() {
+		sketchFolder = sketchFolder.Parent()
 	}
+	w.Add(paths.PathList{sketchFolder})
+	w.Add(paths.NewPathList(args.HardwareFolders...))
+	w.Add(paths.NewPathList(args.BuiltInLibrariesFolders...))
+	w.Add(paths.NewPathList(args.OtherLibrariesFolders...))
 
-	go func() {
-		for {
-			select {
-			case event := <-watcher.Events:
-				ctx.CanUseCachedTools = false
-				log.Println("event:", event)
-			case err := <-watcher.Errors:
-				log.Println("error:", err)
+	if err := s.buildWithEvents(ctx, args, stream); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.daemon.done:
+			return status.Error(codes.Unavailable, "the daemon is shutting down")
+		case changed := <-changes:
+			changed = filterWatchedChanges(changed, args.BuildPath)
+			if len(changed) == 0 {
+				continue
+			}
+			// don't wait for the daemon watcher to notice the change
+			s.invalidate(changed)
+			stream.Send(&pb.BuildEvent{Event: &pb.BuildEvent_Changed{Changed: &pb.FilesChanged{Paths: changed}}})
+			if err := s.buildWithEvents(ctx, args, stream); err != nil {
+				return err
 			}
 		}
-	}()
-	return watcher
+	}
 }
 
-func newServerWithWatcher(ctx *types.Context, watcher *fsnotify.Watcher) *builderServer {
-	s := new(builderServer)
-	s.ctx = ctx
-	s.watcher = watcher
-	return s
+// filterWatchedChanges removes from changed the paths that must not trigger
+// a rebuild: the files written by the build itself, in case the build path
+// is inside the sketch folder, and the hidden and backup files written by
+// editors.
+func filterWatchedChanges(changed []string, buildPath string) []string {
+	var res []string
+	for _, path := range changed {
+		if buildPath != "" && anyContains(paths.NewPathList(buildPath), path) {
+			continue
+		}
+		base := filepath.Base(path)
+		if strings.HasPrefix(base, ".") || strings.HasSuffix(base, "~") {
+			continue
+		}
+		res = append(res, path)
+	}
+	return res
 }
 
-func newServer(ctx *types.Context) *builderServer {
+func newServer(ctx *types.Context, daemon *Daemon) *builderServer {
 	s := new(builderServer)
 	s.ctx = ctx
+	s.daemon = daemon
+	cacheEntries := daemon.opts.CacheEntries
+	if cacheEntries <= 0 {
+		cacheEntries = 8
+	}
+	s.hardware = newHardwareCache(cacheEntries)
+	s.libraries = newLibrariesCache(cacheEntries)
+	s.scheduler = newScheduler(daemon.opts.MaxBuilds, daemon.opts.CompilerJobs)
+	s.metrics = newMetrics()
+	if watcher, err := newWatcher(s.invalidate); err != nil {
+		log.Println("can't watch folders, cached data may become stale:", err)
+	} else {
+		s.watcher = watcher
+	}
 	return s
 }
 
-func RegisterAndServeJsonRPC(ctx *types.Context) {
-	lis, err := net.Listen("tcp", "localhost:12345")
-	if err != nil {
-		//can't spawn two grpc servers on the same port
-		os.Exit(0)
+// Options are the settings of the daemon
+type Options struct {
+	// Version is the version of the builder reported to clients
+	Version string
+	// Loggers are the loggers accepted by the command line, reported to
+	// clients
+	Loggers []string
+	// TLSCertFile and TLSKeyFile enable TLS when set
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile, when set, requires clients to present a certificate
+	// signed by one of the CAs it contains
+	TLSClientCAFile string
+	// TokenFile, when set, contains a shared secret that clients must send
+	// as a bearer token in the "authorization" metadata of each call
+	TokenFile string
+	// IdleTimeout, when set, shuts the daemon down once no call has been in
+	// progress for that long
+	IdleTimeout time.Duration
+	// ParentPID, when set, shuts the daemon down once the process with that
+	// pid exits
+	ParentPID int
+	// MaxBuilds is the number of builds run at the same time, defaults to 2
+	MaxBuilds int
+	// CompilerJobs is the number of compiler processes run at the same time
+	// by all the builds, defaults to the number of CPUs
+	CompilerJobs int
+	// JSONRPCOrigins are the origins of the web pages allowed to call the
+	// JSON-RPC endpoint, besides the endpoint's own. "*" allows any page.
+	JSONRPCOrigins []string
+	// CacheEntries is the number of sets of hardware folders, and of sets of
+	// libraries folders and platforms, whose parsed data is kept in memory.
+	// Defaults to 8, the least recently used are dropped beyond it.
+	CacheEntries int
+	// CacheMemoryLimit, when set, makes the daemon drop the least recently
+	// used data while its heap is larger than that many bytes, keeping at
+	// least the most recent hardware and libraries
+	CacheMemoryLimit uint64
+	// RemoteBuilds, when set, enables the builds of the sketches uploaded
+	// by clients
+	RemoteBuilds *RemoteBuildOptions
+}
+
+// Daemon serves the Builder service, along with the standard gRPC health
+// service, and optionally its JSON-RPC flavor
+type Daemon struct {
+	opts         Options
+	builder      *builderServer
+	grpcServer   *grpc.Server
+	healthServer *health.Server
+	httpServer   *http.Server
+	// metricsServer serves the Prometheus metrics and the pprof profiles
+	metricsServer *http.Server
+	// tlsConfig and auth are nil if TLS and tokens are not enabled
+	tlsConfig *tls.Config
+	auth      *tokenAuth
+	activity  *activity
+	// done is closed when the daemon starts shutting down
+	done         chan struct{}
+	shutdownOnce sync.Once
+}
+
+// NewDaemon creates a daemon running its builds with the settings in ctx
+func NewDaemon(ctx *types.Context, opts Options) (*Daemon, error) {
+	d := &Daemon{
+		opts:         opts,
+		healthServer: health.NewServer(),
+		activity:     newActivity(),
+		done:         make(chan struct{}),
+	}
+
+	var serverOpts []grpc.ServerOption
+	if opts.TLSCertFile != "" || opts.TLSKeyFile != "" || opts.TLSClientCAFile != "" {
+		config, err := tlsConfig(opts.TLSCertFile, opts.TLSKeyFile, opts.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		d.tlsConfig = config
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(config)))
+	}
+	if opts.TokenFile != "" {
+		token, err := ReadToken(opts.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		d.auth = &tokenAuth{token: token}
+		serverOpts = append(serverOpts,
+			grpc.ChainUnaryInterceptor(d.auth.unaryInterceptor),
+			grpc.ChainStreamInterceptor(d.auth.streamInterceptor))
 	}
-	//watcher := startWatching(ctx)
+	serverOpts = append(serverOpts,
+		grpc.ChainUnaryInterceptor(d.activity.unaryInterceptor),
+		grpc.ChainStreamInterceptor(d.activity.streamInterceptor))
+
+	d.grpcServer = grpc.NewServer(serverOpts...)
+	d.builder = newServer(ctx, d)
+	if opts.RemoteBuilds != nil {
+		remote, err := newRemoteBuilds(opts.RemoteBuilds)
+		if err != nil {
+			return nil, err
+		}
+		d.builder.remote = remote
+	}
+	pb.RegisterBuilderServer(d.grpcServer, d.builder)
+	healthpb.RegisterHealthServer(d.grpcServer, d.healthServer)
+	d.healthServer.SetServingStatus("proto.Builder", healthpb.HealthCheckResponse_SERVING)
+	d.httpServer = &http.Server{Handler: &jsonrpcHandler{daemon: d}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", d.builder.serveMetrics)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	d.metricsServer = &http.Server{Handler: d.requireToken(mux)}
+	return d, nil
+}
+
+// Serve serves the Builder service on lis until lis is closed or the daemon
+// is shut down
+func (d *Daemon) Serve(lis net.Listener) error {
+	if d.opts.IdleTimeout > 0 || d.opts.ParentPID > 0 {
+		go d.shutdownWhenUnneeded()
+	}
+	err := d.grpcServer.Serve(lis)
+	if d.builder.remote != nil {
+		d.builder.remote.close()
+	}
+	if err != nil && err != errListenerClosed && err != grpc.ErrServerStopped {
+		return err
+	}
+	return nil
+}
 
-	grpcServer := grpc.NewServer()
-	pb.RegisterBuilderServer(grpcServer, newServer(ctx))
-	grpcServer.Serve(lis)
+// ServeJSONRPC serves the JSON-RPC flavor of the Builder service on lis,
+// over HTTP and WebSocket, until lis is closed or the daemon is shut down
+func (d *Daemon) ServeJSONRPC(lis net.Listener) error {
+	return d.serveHTTP(d.httpServer, lis)
+}
+
+// ServeMetrics serves the Prometheus metrics of the daemon on lis, at
+// /metrics, along with the pprof profiles at /debug/pprof/
+func (d *Daemon) ServeMetrics(lis net.Listener) error {
+	return d.serveHTTP(d.metricsServer, lis)
+}
+
+func (d *Daemon) serveHTTP(server *http.Server, lis net.Listener) error {
+	if d.tlsConfig != nil {
+		lis = tls.NewListener(lis, d.tlsConfig)
+	}
+	err := server.Serve(lis)
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// requireToken makes handler refuse the requests without the token of the
+// daemon, if any
+func (d *Daemon) requireToken(handler http.Handler) http.Handler {
+	if d.auth == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !d.auth.valid(r.Header["Authorization"]) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing or invalid token", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Shutdown stops accepting new calls and makes the Serve methods return as
+// soon as the calls in progress are completed
+func (d *Daemon) Shutdown() {
+	d.shutdownOnce.Do(func() {
+		d.healthServer.Shutdown()
+		close(d.done)
+		go d.grpcServer.GracefulStop()
+		go d.httpServer.Shutdown(context.Background())
+		go d.metricsServer.Shutdown(context.Background())
+	})
+}
+
+// shutdownWhenUnneeded shuts the daemon down once it has been idle for
+// longer than the idle timeout or its parent process exits
+func (d *Daemon) shutdownWhenUnneeded() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+		}
+		if d.opts.IdleTimeout > 0 && d.activity.idleFor() >= d.opts.IdleTimeout {
+			d.Shutdown()
+		}
+		if d.opts.ParentPID > 0 && !processExists(d.opts.ParentPID) {
+			d.Shutdown()
+		}
+	}
 }
@@ -0,0 +1,321 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultMaxBuilds = 2
+
+// scheduler decides when the jobs of the daemon run. At most maxBuilds jobs
+// run at the same time, the others wait in a queue ordered by priority. An
+// extra slot is kept for interactive jobs, so that completions don't wait
+// for long builds to finish, but they can't take more than maxBuilds slots
+// either, so that they don't hold back the builds. The compiler processes
+// run by the builds are limited by a budget shared by all of them.
+type scheduler struct {
+	maxBuilds int
+	jobBudget int
+
+	mux     sync.Mutex
+	lastID  int64
+	queue   []*job
+	running map[int64]*job
+	// builds counts the running jobs that are not interactive, interactive
+	// the others
+	builds      int
+	interactive int
+	usedJobs    int
+	// buildPaths are the build paths of all the jobs queued so far, the
+	// only ones DropCacheV2 may delete
+	buildPaths map[string]bool
+}
+
+// job is a build step waiting for, or holding, a slot of the scheduler
+type job struct {
+	id           int64
+	method       string
+	sketch       string
+	fqbn         string
+	buildPath    string
+	cachePath    string
+	priority     pb.JobPriority
+	queuedAt     time.Time
+	startedAt    time.Time
+	compilerJobs int
+
+	// ctx is canceled by CancelJob or when the call that started the job
+	// ends
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started chan struct{}
+}
+
+// newScheduler creates a scheduler running at most maxBuilds jobs and
+// jobBudget compiler processes at the same time. Zero values pick the
+// defaults: two builds and as many processes as the CPUs.
+func newScheduler(maxBuilds, jobBudget int) *scheduler {
+	if maxBuilds <= 0 {
+		maxBuilds = defaultMaxBuilds
+	}
+	if jobBudget <= 0 {
+		jobBudget = runtime.NumCPU()
+	}
+	return &scheduler{maxBuilds: maxBuilds, jobBudget: jobBudget, running: map[int64]*job{}, buildPaths: map[string]bool{}}
+}
+
+// methodKey is the context key of the name of the calls that don't come
+// through gRPC
+type methodKey struct{}
+
+// callMethod returns the name of the call ctx belongs to
+func callMethod(ctx context.Context) string {
+	if method, ok := grpc.Method(ctx); ok {
+		return method
+	}
+	method, _ := ctx.Value(methodKey{}).(string)
+	return method
+}
+
+// enqueue queues a job for buildCtx and waits for it to start. The job must
+// be released with done once it ends.
+func (s *scheduler) enqueue(ctx context.Context, buildCtx *types.Context, priority pb.JobPriority) (*job, error) {
+	j := &job{
+		method:   callMethod(ctx),
+		priority: priority,
+		queuedAt: time.Now(),
+		started:  make(chan struct{}),
+	}
+	if buildCtx.SketchLocation != nil {
+		j.sketch = buildCtx.SketchLocation.String()
+	}
+	if buildCtx.FQBN != nil {
+		j.fqbn = buildCtx.FQBN.String()
+	}
+	if buildCtx.BuildPath != nil {
+		j.buildPath = filepath.Clean(buildCtx.BuildPath.String())
+	}
+	if buildCtx.BuildCachePath != nil {
+		j.cachePath = filepath.Clean(buildCtx.BuildCachePath.String())
+	}
+	j.ctx, j.cancel = context.WithCancel(ctx)
+
+	s.mux.Lock()
+	s.lastID++
+	j.id = s.lastID
+	if j.buildPath != "" {
+		s.buildPaths[j.buildPath] = true
+	}
+	s.queue = append(s.queue, j)
+	sort.SliceStable(s.queue, func(a, b int) bool { return s.queue[a].priority < s.queue[b].priority })
+	s.schedule()
+	s.mux.Unlock()
+
+	select {
+	case <-j.started:
+		return j, nil
+	case <-j.ctx.Done():
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for i, queued := range s.queue {
+		if queued == j {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			j.cancel()
+			return nil, status.FromContextError(j.ctx.Err()).Err()
+		}
+	}
+	// started in the meantime, the caller will notice the cancellation
+	return j, nil
+}
+
+// done releases the slot and the compiler processes held by j
+func (s *scheduler) done(j *job) {
+	s.mux.Lock()
+	delete(s.running, j.id)
+	s.usedJobs -= j.compilerJobs
+	if j.priority == pb.JobPriority_INTERACTIVE {
+		s.interactive--
+	} else {
+		s.builds--
+	}
+	s.schedule()
+	s.mux.Unlock()
+	j.cancel()
+}
+
+// schedule starts the queued jobs in priority order, as long as they fit.
+// Interactive jobs that don't fit don't hold back the builds queued after
+// them, and the other way around. It must be called with mux locked.
+func (s *scheduler) schedule() {
+	for i := 0; i < len(s.queue) && len(s.running) < s.maxBuilds+1; {
+		j := s.queue[i]
+		if j.priority == pb.JobPriority_INTERACTIVE {
+			if s.interactive >= s.maxBuilds {
+				i++
+				continue
+			}
+			s.interactive++
+			// completions and preprocessing run a single compiler at a time
+			j.compilerJobs = 1
+		} else {
+			free := s.jobBudget - s.usedJobs
+			if s.builds >= s.maxBuilds || free <= 0 {
+				i++
+				continue
+			}
+			s.builds++
+			j.compilerJobs = s.jobBudget / s.maxBuilds
+			if j.compilerJobs > free {
+				j.compilerJobs = free
+			}
+			if j.compilerJobs < 1 {
+				j.compilerJobs = 1
+			}
+		}
+
+		s.queue = append(s.queue[:i], s.queue[i+1:]...)
+		s.running[j.id] = j
+		s.usedJobs += j.compilerJobs
+		j.startedAt = time.Now()
+		close(j.started)
+	}
+}
+
+// cancel cancels the job with the given id, returning false if there is no
+// such job
+func (s *scheduler) cancel(id int64) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if j, ok := s.running[id]; ok {
+		j.cancel()
+		return true
+	}
+	for _, j := range s.queue {
+		if j.id == id {
+			j.cancel()
+			return true
+		}
+	}
+	return false
+}
+
+// exclusive runs fn while no job can start
+func (s *scheduler) exclusive(fn func()) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	fn()
+}
+
+// inUse returns true if a running job uses path as its build path or build
+// cache path. It must be called with mux locked.
+func (s *scheduler) inUse(path string) bool {
+	path = filepath.Clean(path)
+	for _, j := range s.running {
+		if j.buildPath == path || j.cachePath == path {
+			return true
+		}
+	}
+	return false
+}
+
+// knownBuildPath returns true if path has been the build path of a job. It
+// must be called with mux locked.
+func (s *scheduler) knownBuildPath(path string) bool {
+	return s.buildPaths[filepath.Clean(path)]
+}
+
+// stats returns the number of queued and running jobs, and of the compiler
+// processes allotted to the running ones
+func (s *scheduler) stats() (queued, running, compilerJobs int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.queue), len(s.running), s.usedJobs
+}
+
+// list returns the running jobs, then the queued ones
+func (s *scheduler) list() []*pb.Job {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var running []*job
+	for _, j := range s.running {
+		running = append(running, j)
+	}
+	sort.Slice(running, func(a, b int) bool { return running[a].id < running[b].id })
+
+	var res []*pb.Job
+	for _, j := range running {
+		res = append(res, j.toProto(pb.Job_RUNNING))
+	}
+	for _, j := range s.queue {
+		res = append(res, j.toProto(pb.Job_QUEUED))
+	}
+	return res
+}
+
+func (j *job) toProto(state pb.Job_State) *pb.Job {
+	res := &pb.Job{
+		Id:             j.id,
+		Method:         j.method,
+		SketchLocation: j.sketch,
+		Fqbn:           j.fqbn,
+		Priority:       j.priority,
+		State:          state,
+		QueuedAt:       unixMillis(j.queuedAt),
+		CompilerJobs:   int32(j.compilerJobs),
+	}
+	if state == pb.Job_RUNNING {
+		res.StartedAt = unixMillis(j.startedAt)
+	}
+	return res
+}
+
+func unixMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// isCanceled returns true if err comes from a canceled call or job
+func isCanceled(err error) bool {
+	code := status.Code(err)
+	return code == codes.Canceled || code == codes.DeadlineExceeded
+}
@@ -0,0 +1,161 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"golang.org/x/net/context"
+)
+
+// queueJob enqueues a job with the given priority and waits for it to be
+// queued or started, sending it on started once it starts
+func queueJob(t *testing.T, s *scheduler, priority pb.JobPriority, started chan<- *job) {
+	queued, running, _ := s.stats()
+	go func() {
+		j, err := s.enqueue(context.Background(), &types.Context{}, priority)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		started <- j
+	}()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if nowQueued, nowRunning, _ := s.stats(); nowQueued+nowRunning > queued+running {
+			return
+		}
+	}
+	t.Fatal("the job was never queued")
+}
+
+func startJob(t *testing.T, s *scheduler, priority pb.JobPriority) *job {
+	j, err := s.enqueue(context.Background(), &types.Context{}, priority)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func nextStarted(t *testing.T, started <-chan *job) *job {
+	select {
+	case j := <-started:
+		return j
+	case <-time.After(5 * time.Second):
+		t.Fatal("no job started")
+		return nil
+	}
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	s := newScheduler(1, 4)
+	build := startJob(t, s, pb.JobPriority_VERIFY)
+	if build.compilerJobs != 4 {
+		t.Errorf("build got %d compiler jobs, want 4", build.compilerJobs)
+	}
+
+	// builds wait for the running one, in priority order
+	started := make(chan *job, 10)
+	queueJob(t, s, pb.JobPriority_BATCH, started)
+	queueJob(t, s, pb.JobPriority_VERIFY, started)
+
+	// a completion doesn't wait for the builds
+	completion := startJob(t, s, pb.JobPriority_INTERACTIVE)
+	if completion.compilerJobs != 1 {
+		t.Errorf("completion got %d compiler jobs, want 1", completion.compilerJobs)
+	}
+	s.done(completion)
+
+	s.done(build)
+	if j := nextStarted(t, started); j.priority != pb.JobPriority_VERIFY {
+		t.Errorf("started a %s job, want VERIFY", j.priority)
+	} else {
+		s.done(j)
+	}
+	if j := nextStarted(t, started); j.priority != pb.JobPriority_BATCH {
+		t.Errorf("started a %s job, want BATCH", j.priority)
+	} else {
+		s.done(j)
+	}
+}
+
+func TestSchedulerInteractiveCeiling(t *testing.T) {
+	s := newScheduler(1, 4)
+	completion := startJob(t, s, pb.JobPriority_INTERACTIVE)
+
+	// interactive jobs take at most as many slots as builds, and the ones
+	// waiting don't hold back the builds queued after them
+	started := make(chan *job, 10)
+	queueJob(t, s, pb.JobPriority_INTERACTIVE, started)
+	queueJob(t, s, pb.JobPriority_VERIFY, started)
+	build := nextStarted(t, started)
+	if build.priority != pb.JobPriority_VERIFY {
+		t.Fatalf("started a %s job, want VERIFY", build.priority)
+	}
+	select {
+	case j := <-started:
+		t.Fatalf("a %s job started beyond the ceiling", j.priority)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.done(completion)
+	if j := nextStarted(t, started); j.priority != pb.JobPriority_INTERACTIVE {
+		t.Errorf("started a %s job, want INTERACTIVE", j.priority)
+	} else {
+		s.done(j)
+	}
+	s.done(build)
+	if queued, running, compilerJobs := s.stats(); queued != 0 || running != 0 || compilerJobs != 0 {
+		t.Errorf("stats() = %d, %d, %d once all jobs are done, want zeros", queued, running, compilerJobs)
+	}
+}
+
+func TestSchedulerCancelQueued(t *testing.T) {
+	s := newScheduler(1, 4)
+	build := startJob(t, s, pb.JobPriority_VERIFY)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := s.enqueue(ctx, &types.Context{}, pb.JobPriority_VERIFY)
+		errs <- err
+	}()
+	for queued, _, _ := s.stats(); queued == 0; queued, _, _ = s.stats() {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-errs; !isCanceled(err) {
+		t.Errorf("enqueue() = %v, want a cancellation", err)
+	}
+	if queued, _, _ := s.stats(); queued != 0 {
+		t.Errorf("%d jobs still queued after the cancellation", queued)
+	}
+	s.done(build)
+}
@@ -0,0 +1,173 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/go-paths-helper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// warmupSketch is built to compile the core of each board
+const warmupSketch = "void setup() {}\n\nvoid loop() {}\n"
+
+func (s *builderServer) Warmup(args *pb.WarmupParams, stream pb.Builder_WarmupServer) error {
+	return s.warmup(stream.Context(), args, stream.Send)
+}
+
+// Warmup warms the daemon up like the Warmup call, until the daemon shuts
+// down. The failed builds of the cores are returned as an error.
+func (d *Daemon) Warmup(args *pb.WarmupParams) error {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), methodKey{}, "preload"))
+	defer cancel()
+	go func() {
+		select {
+		case <-d.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var failures []string
+	err := d.builder.warmup(ctx, args, func(event *pb.WarmupEvent) error {
+		if result := event.GetBuildEvent().GetResult(); result != nil && !result.Success {
+			failures = append(failures, event.Fqbn+": "+result.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return errors.New("warming up " + strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// warmup loads the hardware and tools in the folders of args, then builds
+// an empty sketch for each board, which compiles its core into the build
+// cache and indexes the libraries of its platform. The progress is sent
+// with send.
+func (s *builderServer) warmup(ctx context.Context, args *pb.WarmupParams, send func(*pb.WarmupEvent) error) error {
+	if len(args.Fqbns) > 0 && args.BuildCachePath == "" {
+		return status.Error(codes.InvalidArgument, "compiling the cores requires a build cache path")
+	}
+	boards := int32(len(args.Fqbns))
+
+	send(&pb.WarmupEvent{Step: pb.WarmupEvent_LOADING_HARDWARE, Boards: boards})
+	hardwareDirs := paths.NewPathList(args.HardwareFolders...)
+	toolsDirs := paths.NewPathList(args.ToolsFolders...)
+	if _, _, err := s.hardware.Get(hardwareDirs, toolsDirs); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "loading hardware: %s", err)
+	}
+	if s.watcher != nil {
+		s.watcher.Add(hardwareDirs)
+		s.watcher.Add(toolsDirs)
+	}
+
+	if boards > 0 {
+		tmp, err := paths.MkTempDir("", "arduino-builder-warmup")
+		if err != nil {
+			return status.Errorf(codes.Internal, "creating the warmup sketch: %s", err)
+		}
+		defer tmp.RemoveAll()
+		sketch := tmp.Join("warmup", "warmup.ino")
+		if err := sketch.Parent().MkdirAll(); err != nil {
+			return status.Errorf(codes.Internal, "creating the warmup sketch: %s", err)
+		}
+		if err := sketch.WriteFile([]byte(warmupSketch)); err != nil {
+			return status.Errorf(codes.Internal, "creating the warmup sketch: %s", err)
+		}
+
+		priority := args.Priority
+		if priority == pb.JobPriority_DEFAULT_PRIORITY {
+			priority = pb.JobPriority_BATCH
+		}
+		for i, fqbn := range args.Fqbns {
+			params := &pb.BuildParamsV2{
+				HardwareFolders:         args.HardwareFolders,
+				ToolsFolders:            args.ToolsFolders,
+				BuiltInLibrariesFolders: args.BuiltInLibrariesFolders,
+				OtherLibrariesFolders:   args.OtherLibrariesFolders,
+				SketchLocation:          sketch.String(),
+				Fqbn:                    fqbn,
+				ArduinoAPIVersion:       args.ArduinoAPIVersion,
+				CustomBuildProperties:   args.CustomBuildProperties,
+				BuildCachePath:          args.BuildCachePath,
+				BuildPath:               tmp.Join(fmt.Sprintf("build%d", i)).String(),
+				Priority:                priority,
+			}
+			events := &warmupEventStream{
+				send:   send,
+				fqbn:   pb.FormatFQBN(fqbn),
+				board:  int32(i + 1),
+				boards: boards,
+			}
+			err := s.buildWithEvents(ctx, params, events)
+			if isCanceled(err) {
+				return err
+			}
+			if err != nil {
+				// the build could not start, report it like a failed one
+				result := &pb.BuildResult{ExitCode: 1, Error: err.Error()}
+				events.Send(&pb.BuildEvent{Event: &pb.BuildEvent_Result{Result: result}})
+			}
+		}
+	}
+
+	return send(&pb.WarmupEvent{Step: pb.WarmupEvent_DONE, Boards: boards})
+}
+
+// warmupEventStream sends the events of the build of a core as
+// WarmupEvents. The artifacts of the empty sketch are left out.
+type warmupEventStream struct {
+	send   func(*pb.WarmupEvent) error
+	fqbn   string
+	board  int32
+	boards int32
+}
+
+func (s *warmupEventStream) Send(event *pb.BuildEvent) error {
+	if event.GetArtifact() != nil {
+		return nil
+	}
+	return s.send(&pb.WarmupEvent{
+		Step:       pb.WarmupEvent_COMPILING_CORE,
+		Fqbn:       s.fqbn,
+		Board:      s.board,
+		Boards:     s.boards,
+		BuildEvent: event,
+	})
+}
@@ -0,0 +1,121 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"testing"
+
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/go-paths-helper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWarmupWithoutBoards(t *testing.T) {
+	tmp, err := paths.MkTempDir("", "arduino-builder-warmup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.RemoveAll()
+	for _, folder := range []string{"hardware", "tools"} {
+		if err := tmp.Join(folder).MkdirAll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &builderServer{hardware: newHardwareCache(8), libraries: newLibrariesCache(8), scheduler: newScheduler(1, 1)}
+	args := &pb.WarmupParams{
+		HardwareFolders: []string{tmp.Join("hardware").String()},
+		ToolsFolders:    []string{tmp.Join("tools").String()},
+	}
+
+	// only the hardware is loaded, without any build
+	var steps []pb.WarmupEvent_Step
+	err = s.warmup(context.Background(), args, func(event *pb.WarmupEvent) error {
+		steps = append(steps, event.Step)
+		if event.Boards != 0 {
+			t.Errorf("got %d boards, want 0", event.Boards)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[0] != pb.WarmupEvent_LOADING_HARDWARE || steps[1] != pb.WarmupEvent_DONE {
+		t.Errorf("got steps %v, want LOADING_HARDWARE and DONE", steps)
+	}
+	if stats := s.hardware.stats(); stats.misses != 1 || stats.entries != 1 {
+		t.Errorf("got %d misses and %d entries, want the hardware loaded once", stats.misses, stats.entries)
+	}
+	if queued, running, _ := s.scheduler.stats(); queued != 0 || running != 0 {
+		t.Errorf("got %d jobs queued and %d running, want none", queued, running)
+	}
+
+	// the builds following the warmup find the hardware loaded
+	if _, _, err := s.hardware.Get(paths.NewPathList(args.HardwareFolders...), paths.NewPathList(args.ToolsFolders...)); err != nil {
+		t.Fatal(err)
+	}
+	if stats := s.hardware.stats(); stats.hits != 1 {
+		t.Errorf("got %d hits, want 1", stats.hits)
+	}
+}
+
+func TestWarmupRequiresBuildCache(t *testing.T) {
+	s := &builderServer{hardware: newHardwareCache(8), libraries: newLibrariesCache(8), scheduler: newScheduler(1, 1)}
+	args := &pb.WarmupParams{Fqbns: []*pb.FQBN{{Package: "arduino", Architecture: "avr", BoardID: "uno"}}}
+	sent := 0
+	err := s.warmup(context.Background(), args, func(event *pb.WarmupEvent) error {
+		sent++
+		return nil
+	})
+	if status.Code(err) != codes.InvalidArgument || sent != 0 {
+		t.Errorf("got %v after %d events, want InvalidArgument before any event", err, sent)
+	}
+}
+
+func TestWarmupEventStream(t *testing.T) {
+	var got []*pb.WarmupEvent
+	stream := &warmupEventStream{
+		send:   func(event *pb.WarmupEvent) error { got = append(got, event); return nil },
+		fqbn:   "arduino:avr:uno",
+		board:  2,
+		boards: 3,
+	}
+	log := &pb.BuildEvent{Event: &pb.BuildEvent_Log{Log: &pb.LogRecord{Message: "Compiling core..."}}}
+	stream.Send(log)
+	stream.Send(&pb.BuildEvent{Event: &pb.BuildEvent_Artifact{Artifact: &pb.Artifact{Path: "/tmp/build/warmup.ino.hex"}}})
+
+	if len(got) != 1 {
+		t.Fatalf("got %d events, want the log only", len(got))
+	}
+	event := got[0]
+	if event.Step != pb.WarmupEvent_COMPILING_CORE || event.Fqbn != "arduino:avr:uno" || event.Board != 2 || event.Boards != 3 || event.BuildEvent != log {
+		t.Errorf("got %v, want the log of board 2 of 3", event)
+	}
+}
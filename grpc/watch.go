@@ -0,0 +1,161 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+package grpc
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/arduino/arduino-cli/legacy/builder/utils"
+	"github.com/arduino/go-paths-helper"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the watcher waits for the changes to settle
+// before reporting them, so that e.g. a core installed by the Boards Manager
+// is reported once and not for each of its files.
+const watchDebounce = 500 * time.Millisecond
+
+// watcher watches a set of folders, with all their subfolders, and reports
+// the paths changed inside them.
+type watcher struct {
+	fsw      *fsnotify.Watcher
+	onChange func(changed []string)
+
+	mux     sync.Mutex
+	watched map[string]bool
+}
+
+func newWatcher(onChange func(changed []string)) (*watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &watcher{
+		fsw:      fsw,
+		onChange: onChange,
+		watched:  map[string]bool{},
+	}
+	go w.run()
+	return w, nil
+}
+
+// Add starts watching the given folders, unless they are watched already
+func (w *watcher) Add(folders paths.PathList) {
+	for _, folder := range folders {
+		if folder != nil {
+			w.add(folder.String())
+		}
+	}
+}
+
+func (w *watcher) add(folder string) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if w.watched[folder] {
+		return
+	}
+
+	var subfolders []string
+	utils.FindAllSubdirectories(folder, &subfolders)
+	subfolders = append(subfolders, folder)
+	for _, element := range subfolders {
+		if w.watched[element] {
+			continue
+		}
+		if err := w.fsw.Add(element); err != nil {
+			log.Println("error watching", element+":", err)
+			continue
+		}
+		w.watched[element] = true
+	}
+}
+
+// forget removes folder and its subfolders from the watched ones, fsnotify
+// already stopped watching them when they have been removed
+func (w *watcher) forget(folder string) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	for watched := range w.watched {
+		if anyContains(paths.NewPathList(folder), watched) {
+			delete(w.watched, watched)
+		}
+	}
+}
+
+func (w *watcher) run() {
+	changed := map[string]bool{}
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create != 0 {
+				// new folders must be watched as well
+				if path := paths.New(event.Name); path.IsDir() {
+					w.add(event.Name)
+				}
+			}
+			if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				w.forget(event.Name)
+			}
+			changed[event.Name] = true
+			// a tick that fired meanwhile would report the changes early
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(watchDebounce)
+		case <-timer.C:
+			var list []string
+			for path := range changed {
+				list = append(list, path)
+			}
+			changed = map[string]bool{}
+			w.onChange(list)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Println("error:", err)
+		}
+	}
+}
+
+// Close stops watching
+func (w *watcher) Close() error {
+	return w.fsw.Close()
+}
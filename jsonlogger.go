@@ -0,0 +1,107 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/arduino/arduino-builder/events"
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+)
+
+// jsonLogLine is a line written by the json logger. Type is "log" for the
+// messages of the builder, "output" for the output of the compiler,
+// "diagnostic" for the errors and warnings found in it and "progress" for
+// the progress of the build.
+type jsonLogLine struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	Phase     string    `json:"phase,omitempty"`
+	Level     string    `json:"level,omitempty"`
+	MessageID string    `json:"messageId,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Arguments []string  `json:"arguments,omitempty"`
+	Percent   *float32  `json:"percent,omitempty"`
+	File      string    `json:"file,omitempty"`
+	Line      int32     `json:"line,omitempty"`
+	Column    int32     `json:"column,omitempty"`
+	Severity  string    `json:"severity,omitempty"`
+}
+
+// newJSONLogger creates the logger selected with "-logger json" for the
+// build in ctx, writing one JSON object per line to w
+func newJSONLogger(w io.Writer, ctx *types.Context) *events.EventLogger {
+	return events.NewEventLogger(&jsonLinesStream{encoder: json.NewEncoder(w)}, ctx)
+}
+
+// jsonLinesStream writes the events of the builder as JSON lines. The
+// EventLogger sends them one at a time, in order.
+type jsonLinesStream struct {
+	encoder *json.Encoder
+	phase   pb.BuildPhase
+}
+
+func (s *jsonLinesStream) Send(event *pb.BuildEvent) error {
+	line := &jsonLogLine{Time: time.Now()}
+	switch e := event.Event.(type) {
+	case *pb.BuildEvent_Progress:
+		s.phase = e.Progress.Phase
+		percent := e.Progress.Percent
+		line.Type = "progress"
+		line.Percent = &percent
+	case *pb.BuildEvent_Log:
+		line.Type = "log"
+		line.Level = strings.ToLower(e.Log.Level.String())
+		line.MessageID = e.Log.MessageID
+		line.Message = e.Log.Message
+		line.Arguments = e.Log.Arguments
+		if e.Log.MessageID == "" {
+			line.Type = "output"
+			line.Level = ""
+		}
+	case *pb.BuildEvent_Diagnostic:
+		line.Type = "diagnostic"
+		line.File = e.Diagnostic.File
+		line.Line = e.Diagnostic.Line
+		line.Column = e.Diagnostic.Column
+		line.Severity = strings.ToLower(strings.Replace(e.Diagnostic.Severity.String(), "_", " ", -1))
+		line.Message = e.Diagnostic.Message
+	default:
+		return nil
+	}
+	if s.phase != pb.BuildPhase_UNKNOWN_PHASE {
+		line.Phase = strings.ToLower(s.phase.String())
+	}
+	return s.encoder.Encode(line)
+}
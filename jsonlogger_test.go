@@ -0,0 +1,59 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+)
+
+func TestJSONLoggerWritesToWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newJSONLogger(&buf, &types.Context{})
+	logger.Println("info", "Using library {0} in folder: {1}", "Servo", "/libraries/Servo")
+	logger.UnformattedFprintln(os.Stderr, "sketch.ino:3:1: error: 'foo' was not declared in this scope")
+
+	var kinds []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		record := &jsonLogLine{}
+		if err := json.Unmarshal([]byte(line), record); err != nil {
+			t.Fatalf("invalid line %q: %s", line, err)
+		}
+		kinds = append(kinds, record.Type)
+	}
+	if got, want := strings.Join(kinds, ","), "log,output,diagnostic"; got != want {
+		t.Errorf("written types = %s, want %s", got, want)
+	}
+}
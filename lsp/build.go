@@ -0,0 +1,320 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package lsp
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/arduino/arduino-builder/events"
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/arduino/cores"
+	"github.com/arduino/arduino-cli/legacy/builder"
+	"github.com/arduino/arduino-cli/legacy/builder/i18n"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"github.com/arduino/go-paths-helper"
+)
+
+// loadHardware loads the hardware and the tools on first use. They are kept
+// for the whole session, so that they are not parsed again on every
+// keystroke. The builds share them, so the steps of the builder writing to
+// the platforms and boards run here, leaving the builds nothing to write.
+func (s *Server) loadHardware() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.pm != nil {
+		return nil
+	}
+
+	ctx := &types.Context{HardwareDirs: s.ctx.HardwareDirs, BuiltInToolsDirs: s.ctx.BuiltInToolsDirs}
+	ctx.SetLogger(i18n.NoopLogger{})
+	commands := []types.Command{
+		&builder.HardwareLoader{},
+		&builder.PlatformKeysRewriteLoader{},
+		&builder.RewriteHardwareKeys{},
+		&builder.ToolsLoader{},
+		&builder.AddBuildBoardPropertyIfMissing{},
+	}
+	for _, command := range commands {
+		if err := command.Run(ctx); err != nil {
+			return fmt.Errorf("loading hardware: %s", err)
+		}
+	}
+	if board, err := ctx.PackageManager.FindBoardWithFQBN(s.ctx.FQBN.String()); err == nil {
+		if requiredTools, err := ctx.PackageManager.FindToolsRequiredForBoard(board); err == nil {
+			s.requiredTools = requiredTools
+		}
+	}
+	s.pm = ctx.PackageManager
+	s.allTools = ctx.AllTools
+	return nil
+}
+
+// buildPath returns the build folder of the sketch in sketchFolder to use
+// for the given kind of build: completions and full builds run at the same
+// time, so they can't share a folder
+func (s *Server) buildPath(sketchFolder, kind string) *paths.Path {
+	return s.tempDir.Join(fmt.Sprintf("%x", md5.Sum([]byte(sketchFolder))), kind)
+}
+
+// newBuildContext creates the context for a build of the sketch in
+// sketchFolder, with the given files overridden by the contents of the
+// editor buffers
+func (s *Server) newBuildContext(sketchFolder string, buildPath *paths.Path, overrides map[string]string) (*types.Context, error) {
+	if err := s.loadHardware(); err != nil {
+		return nil, err
+	}
+	if err := buildPath.MkdirAll(); err != nil {
+		return nil, err
+	}
+
+	ctx := &types.Context{}
+	ctx.IgnoreSketchFolderNameErrors = true
+	ctx.UseArduinoPreprocessor = s.ctx.UseArduinoPreprocessor
+	ctx.DebugLevel = s.ctx.DebugLevel
+	ctx.HardwareDirs = s.ctx.HardwareDirs
+	ctx.BuiltInToolsDirs = s.ctx.BuiltInToolsDirs
+	ctx.BuiltInLibrariesDirs = s.ctx.BuiltInLibrariesDirs
+	ctx.OtherLibrariesDirs = s.ctx.OtherLibrariesDirs
+	ctx.CustomBuildProperties = s.ctx.CustomBuildProperties
+	ctx.ArduinoAPIVersion = s.ctx.ArduinoAPIVersion
+	ctx.FQBN = s.ctx.FQBN
+	ctx.USBVidPid = s.ctx.USBVidPid
+	ctx.BuildCachePath = s.ctx.BuildCachePath
+	ctx.WarningsLevel = s.ctx.WarningsLevel
+	ctx.SketchLocation = paths.New(sketchFolder)
+	ctx.BuildPath = buildPath
+	ctx.SourceOverride = overrides
+	ctx.SetLogger(i18n.NoopLogger{})
+
+	s.mux.Lock()
+	ctx.PackageManager = s.pm
+	ctx.AllTools = s.allTools
+	ctx.RequiredTools = s.requiredTools
+	if s.libraries != nil && folderStamp(s.librariesFolders) != s.librariesStamp {
+		s.libraries = nil
+	}
+	ctx.LibrariesManager = s.libraries
+	s.mux.Unlock()
+	// tools are already loaded in the shared PackageManager, the builder
+	// must not load them again
+	ctx.CanUseCachedTools = true
+	return ctx, nil
+}
+
+// run runs a build step on ctx, keeping the libraries it loaded for the
+// following builds
+func (s *Server) run(ctx *types.Context, step func(*types.Context) error) error {
+	lock := s.buildPathLock(ctx.BuildPath)
+	lock.Lock()
+	err := step(ctx)
+	lock.Unlock()
+
+	s.mux.Lock()
+	if s.libraries == nil && ctx.LibrariesManager != nil {
+		s.libraries = ctx.LibrariesManager
+		s.librariesFolders = librariesFolders(ctx)
+		s.librariesStamp = folderStamp(s.librariesFolders)
+	}
+	s.mux.Unlock()
+	return err
+}
+
+func (s *Server) buildPathLock(buildPath *paths.Path) *sync.Mutex {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	lock, ok := s.buildPathLocks[buildPath.String()]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.buildPathLocks[buildPath.String()] = lock
+	}
+	return lock
+}
+
+// librariesFolders returns the folders the libraries of the build in ctx
+// are loaded from, including the ones bundled with its platforms
+func librariesFolders(ctx *types.Context) paths.PathList {
+	res := paths.NewPathList()
+	res.AddAll(ctx.BuiltInLibrariesDirs)
+	res.AddAll(ctx.OtherLibrariesDirs)
+	for _, platform := range []*cores.PlatformRelease{ctx.TargetPlatform, ctx.ActualPlatform} {
+		if platform != nil && platform.InstallDir != nil {
+			res.Add(platform.InstallDir.Join("libraries"))
+		}
+	}
+	return res
+}
+
+// folderStamp returns a stamp of the modification times of folders, which
+// change when an entry is added to or removed from one of them
+func folderStamp(folders paths.PathList) string {
+	var stamp strings.Builder
+	for _, folder := range folders {
+		if info, err := folder.Stat(); err == nil {
+			fmt.Fprintf(&stamp, "%d\n", info.ModTime().UnixNano())
+		} else {
+			stamp.WriteString("-\n")
+		}
+	}
+	return stamp.String()
+}
+
+// requestBuild queues a build of the sketch in sketchFolder, replacing the
+// one already queued if any
+func (s *Server) requestBuild(sketchFolder string) {
+	s.buildsMux.Lock()
+	s.pendingBuilds[sketchFolder] = s.overrides(sketchFolder)
+	s.buildsMux.Unlock()
+	select {
+	case s.buildRequested <- struct{}{}:
+	default:
+	}
+}
+
+// runBuilds runs the queued builds one at a time until stopBuilds is called
+func (s *Server) runBuilds() {
+	defer close(s.buildsDone)
+	for range s.buildRequested {
+		for {
+			s.buildsMux.Lock()
+			sketchFolder, overrides, ok := "", map[string]string(nil), false
+			for sketchFolder, overrides = range s.pendingBuilds {
+				ok = true
+				delete(s.pendingBuilds, sketchFolder)
+				break
+			}
+			s.buildsMux.Unlock()
+			if !ok {
+				break
+			}
+			s.build(sketchFolder, overrides)
+		}
+	}
+}
+
+// stopBuilds waits for the running build to end and drops the queued ones
+func (s *Server) stopBuilds() {
+	s.stopBuildsOnce.Do(func() {
+		s.buildsMux.Lock()
+		s.pendingBuilds = map[string]map[string]string{}
+		s.buildsMux.Unlock()
+		close(s.buildRequested)
+		<-s.buildsDone
+	})
+}
+
+// build compiles the sketch in sketchFolder and publishes the diagnostics of
+// the compiler, clearing the ones published by the previous build
+func (s *Server) build(sketchFolder string, overrides map[string]string) {
+	ctx, err := s.newBuildContext(sketchFolder, s.buildPath(sketchFolder, "build"), overrides)
+	if err != nil {
+		s.conn.notify("window/showMessage", &showMessageParams{Type: messageTypeError, Message: err.Error()})
+		return
+	}
+	collector := &events.DiagnosticsCollector{}
+	ctx.SetLogger(events.NewEventLogger(collector, ctx))
+	err = s.run(ctx, builder.RunBuilder)
+
+	// the diagnostics are already mapped to the files of the sketch, their
+	// notes are published on their own
+	byURI := map[string][]*diagnostic{}
+	var publish func(d *pb.CompilerDiagnostic)
+	publish = func(d *pb.CompilerDiagnostic) {
+		uri := pathToURI(d.File)
+		byURI[uri] = append(byURI[uri], s.toDiagnostic(d.File, int(d.Line), int(d.Column), d))
+		for _, note := range d.Notes {
+			publish(note)
+		}
+	}
+	for _, d := range collector.Diagnostics {
+		publish(d)
+	}
+	if err != nil && len(byURI) == 0 {
+		// the build failed before running the compiler
+		s.conn.notify("window/showMessage", &showMessageParams{Type: messageTypeError, Message: err.Error()})
+	}
+
+	published := map[string]bool{}
+	uris := []string{}
+	for uri := range byURI {
+		uris = append(uris, uri)
+	}
+	for uri := range s.published[sketchFolder] {
+		if _, ok := byURI[uri]; !ok {
+			uris = append(uris, uri)
+		}
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		diagnostics := byURI[uri]
+		if diagnostics == nil {
+			diagnostics = []*diagnostic{}
+		} else {
+			published[uri] = true
+		}
+		s.conn.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+	}
+	s.published[sketchFolder] = published
+}
+
+// toDiagnostic converts a diagnostic of the compiler, found at the given
+// line and column of file, to an LSP one spanning the identifier it points
+// at
+func (s *Server) toDiagnostic(file string, line, column int, d *pb.CompilerDiagnostic) *diagnostic {
+	res := &diagnostic{Source: "arduino-builder", Message: d.Message}
+	switch d.Severity {
+	case pb.CompilerDiagnostic_ERROR, pb.CompilerDiagnostic_FATAL_ERROR:
+		res.Severity = diagnosticSeverityError
+	case pb.CompilerDiagnostic_WARNING:
+		res.Severity = diagnosticSeverityWarning
+	default:
+		res.Severity = diagnosticSeverityInformation
+	}
+	if line < 1 {
+		line = 1
+	}
+	text := s.fileLine(file, line-1)
+	start, end := 0, 0
+	if column > 0 {
+		_, start, end = wordAt(text, column-1)
+		if end == start {
+			start = column - 1
+			end = column - 1
+		}
+	}
+	res.Range = textRange{
+		Start: position{Line: line - 1, Character: utf16Column(text, start)},
+		End:   position{Line: line - 1, Character: utf16Column(text, end)},
+	}
+	return res
+}
@@ -0,0 +1,250 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/arduino/arduino-builder/completion"
+	"github.com/arduino/arduino-builder/diagnostics"
+	pb "github.com/arduino/arduino-builder/grpc/proto"
+	"github.com/arduino/arduino-cli/legacy/builder"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+)
+
+// resolve returns the file a position refers to, the folder of its sketch,
+// the text of the line and the byte offset of the position within it
+func (s *Server) resolve(params *textDocumentPositionParams) (path, sketchFolder, text string, column int, err error) {
+	path, ok := uriToPath(params.TextDocument.URI)
+	if !ok {
+		return "", "", "", 0, newError(codeInvalidParams, "unsupported URI: %s", params.TextDocument.URI)
+	}
+	sketchFolder, err = findSketchFolder(path)
+	if err != nil {
+		return "", "", "", 0, err
+	}
+	text = s.fileLine(path, params.Position.Line)
+	return path, sketchFolder, text, byteColumn(text, params.Position.Character), nil
+}
+
+func (s *Server) completion(params *textDocumentPositionParams) (*completionList, error) {
+	path, sketchFolder, text, column, err := s.resolve(params)
+	if err != nil {
+		return nil, err
+	}
+	// complete from the beginning of the word being typed, the client
+	// filters the results as the user types
+	_, start, _ := wordAt(text[:column], column)
+	completions, err := s.codeComplete(path, sketchFolder, params.Position.Line+1, start+1)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &completionList{Items: []*completionItem{}}
+	for _, completion := range completions {
+		res.Items = append(res.Items, toCompletionItem(completion))
+	}
+	return res, nil
+}
+
+// codeComplete returns the completions at the given line and column of
+// file, numbered from 1
+func (s *Server) codeComplete(file, sketchFolder string, line, column int) ([]*pb.Completion, error) {
+	ctx, err := s.newBuildContext(sketchFolder, s.buildPath(sketchFolder, "preprocess"), s.overrides(sketchFolder))
+	if err != nil {
+		return nil, err
+	}
+	// code completion is provided by arduino-preprocessor only
+	ctx.UseArduinoPreprocessor = true
+	ctx.CodeCompleteAt = fmt.Sprintf("%s:%d:%d", file, line, column)
+
+	err = s.run(ctx, builder.RunPreprocess)
+	completions := completion.Parse(ctx.CodeCompletions)
+	// clang still completes sketches with errors elsewhere
+	if err != nil && len(completions) == 0 {
+		return nil, err
+	}
+	return completions, nil
+}
+
+func toCompletionItem(completion *pb.Completion) *completionItem {
+	item := &completionItem{
+		Label:         completion.Label,
+		Detail:        strings.TrimSpace(completion.ReturnType + " " + completion.Signature),
+		Documentation: completion.Documentation,
+		SortText:      fmt.Sprintf("%d%s", completion.Priority, completion.Label),
+	}
+	switch completion.Kind {
+	case pb.Completion_FUNCTION, pb.Completion_OVERLOAD:
+		item.Kind = completionItemKindFunction
+	case pb.Completion_VARIABLE:
+		item.Kind = completionItemKindVariable
+	case pb.Completion_MACRO:
+		item.Kind = completionItemKindConstant
+	case pb.Completion_PATTERN:
+		item.Kind = completionItemKindSnippet
+	default:
+		item.Kind = completionItemKindText
+	}
+	if completion.InsertText != "" {
+		item.InsertText = completion.InsertText
+		item.InsertTextFormat = insertTextFormatSnippet
+	}
+	return item
+}
+
+func (s *Server) hover(params *textDocumentPositionParams) (*hover, error) {
+	path, sketchFolder, text, column, err := s.resolve(params)
+	if err != nil {
+		return nil, err
+	}
+	word, start, end := wordAt(text, column)
+	if word == "" {
+		return nil, nil
+	}
+
+	var contents []string
+	// the symbols of the sketch first, then anything clang knows about
+	if symbols, err := s.findSymbols(sketchFolder, word); err == nil && len(symbols) > 0 {
+		contents = append(contents, "```cpp\n"+declaration(symbols[0].tag)+"\n```")
+	} else if completions, err := s.codeComplete(path, sketchFolder, params.Position.Line+1, start+1); err == nil {
+		seen := map[string]bool{}
+		for _, completion := range completions {
+			detail := strings.TrimSpace(completion.ReturnType + " " + completion.Signature)
+			if completion.Label != word || completion.Kind == pb.Completion_PATTERN || seen[detail] {
+				continue
+			}
+			seen[detail] = true
+			content := "```cpp\n" + detail + "\n```"
+			if completion.Documentation != "" {
+				content += "\n" + completion.Documentation
+			}
+			contents = append(contents, content)
+		}
+	}
+	if len(contents) == 0 {
+		return nil, nil
+	}
+
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: strings.Join(contents, "\n\n")},
+		Range: &textRange{
+			Start: position{Line: params.Position.Line, Character: utf16Column(text, start)},
+			End:   position{Line: params.Position.Line, Character: utf16Column(text, end)},
+		},
+	}, nil
+}
+
+func (s *Server) definition(params *textDocumentPositionParams) ([]*location, error) {
+	_, sketchFolder, text, column, err := s.resolve(params)
+	if err != nil {
+		return nil, err
+	}
+	word, _, _ := wordAt(text, column)
+	if word == "" {
+		return nil, nil
+	}
+	symbols, err := s.findSymbols(sketchFolder, word)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*location{}
+	for _, symbol := range symbols {
+		// prototypes only when there is nothing better
+		if symbol.tag.Kind == "prototype" && len(res) > 0 {
+			continue
+		}
+		line := s.fileLine(symbol.file, symbol.line-1)
+		start, end := strings.Index(line, word), 0
+		if start == -1 {
+			start = 0
+		} else {
+			end = start + len(word)
+		}
+		res = append(res, &location{
+			URI: pathToURI(symbol.file),
+			Range: textRange{
+				Start: position{Line: symbol.line - 1, Character: utf16Column(line, start)},
+				End:   position{Line: symbol.line - 1, Character: utf16Column(line, end)},
+			},
+		})
+	}
+	return res, nil
+}
+
+// symbol is a declaration found in a sketch by ctags, along with its
+// location in the sketch files
+type symbol struct {
+	tag  *types.CTag
+	file string
+	line int
+}
+
+// findSymbols returns the declarations of name in the sketch in
+// sketchFolder, definitions first. Only the symbols of the sketch itself are
+// found, not the ones of the core and of the libraries.
+func (s *Server) findSymbols(sketchFolder, name string) ([]*symbol, error) {
+	ctx, err := s.newBuildContext(sketchFolder, s.buildPath(sketchFolder, "preprocess"), s.overrides(sketchFolder))
+	if err != nil {
+		return nil, err
+	}
+	// the symbols come from the ctags run while generating the prototypes,
+	// which arduino-preprocessor doesn't do
+	ctx.UseArduinoPreprocessor = false
+
+	if err := s.run(ctx, builder.RunPreprocess); err != nil {
+		return nil, err
+	}
+
+	remap := diagnostics.NewRemapper(ctx, sketchFolder)
+	var res []*symbol
+	for _, tag := range ctx.CTagsOfPreprocessedSource {
+		if tag.FunctionName != name {
+			continue
+		}
+		file, line := remap.ToOriginal(tag.Filename, tag.Line)
+		res = append(res, &symbol{tag: tag, file: file, line: line})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].tag.Kind != "prototype" && res[j].tag.Kind == "prototype"
+	})
+	return res, nil
+}
+
+// declaration returns the code declaring the symbol of tag
+func declaration(tag *types.CTag) string {
+	if tag.Kind == "function" && tag.Prototype != "" {
+		return strings.TrimSpace(tag.PrototypeModifiers + " " + strings.TrimSuffix(tag.Prototype, ";"))
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(tag.Code), "{"))
+}
@@ -0,0 +1,394 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+// Package lsp implements a Language Server Protocol server for sketches,
+// speaking JSON-RPC on a pair of streams, usually stdin and stdout. It
+// provides completion, hover, go-to-definition and the diagnostics of the
+// compiler through the preprocessor of the builder, building all the
+// sketches with the options given on the command line.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/arduino/arduino-cli/arduino/cores"
+	"github.com/arduino/arduino-cli/arduino/cores/packagemanager"
+	"github.com/arduino/arduino-cli/arduino/libraries/librariesmanager"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"github.com/arduino/go-paths-helper"
+)
+
+// Server is a language server for the sketches built with the settings of
+// a template context
+type Server struct {
+	// ctx holds the settings given on the command line, it is used as a
+	// template for the context of each build
+	ctx     *types.Context
+	version string
+	conn    *conn
+
+	initialized  bool
+	shuttingDown bool
+
+	// inflight holds the replies of the requests being handled, keyed by
+	// their ID, so that they can be canceled. handlers waits for them.
+	inflightMux sync.Mutex
+	inflight    map[string]func(result interface{}, err error)
+	handlers    sync.WaitGroup
+
+	// tempDir holds the build folders of the sketches
+	tempDir *paths.Path
+
+	// documents holds the text of the documents opened by the client, keyed
+	// by their path
+	docsMux   sync.Mutex
+	documents map[string]string
+
+	// the hardware, tools and libraries loaded by the first build, they are
+	// shared by all the following ones. The libraries are loaded again once
+	// a library is installed or removed, which changes the stamp of the
+	// libraries folders.
+	mux              sync.Mutex
+	pm               *packagemanager.PackageManager
+	allTools         []*cores.ToolRelease
+	requiredTools    []*cores.ToolRelease
+	libraries        *librariesmanager.LibrariesManager
+	librariesFolders paths.PathList
+	librariesStamp   string
+	// buildPathLocks keeps the builds sharing a build folder, like a
+	// completion and a hover on the same sketch, from running together
+	buildPathLocks map[string]*sync.Mutex
+
+	// the sketches saved since the last build, along with the overrides of
+	// their unsaved files
+	buildsMux      sync.Mutex
+	pendingBuilds  map[string]map[string]string
+	buildRequested chan struct{}
+	buildsDone     chan struct{}
+	stopBuildsOnce sync.Once
+	// published holds the URIs of the files each sketch last published
+	// diagnostics for, it is used by the builds only
+	published map[string]map[string]bool
+}
+
+// NewServer creates a new Server, building sketches with the settings of
+// ctx. version is reported to the clients.
+func NewServer(ctx *types.Context, version string) *Server {
+	return &Server{
+		ctx:            ctx,
+		version:        version,
+		documents:      map[string]string{},
+		inflight:       map[string]func(result interface{}, err error){},
+		buildPathLocks: map[string]*sync.Mutex{},
+		pendingBuilds:  map[string]map[string]string{},
+		buildRequested: make(chan struct{}, 1),
+		buildsDone:     make(chan struct{}),
+		published:      map[string]map[string]bool{},
+	}
+}
+
+// Serve reads requests from in and writes responses to out until the client
+// sends the exit notification. An error is returned if the client exits
+// without asking to shutdown the server first, or closes the connection.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	tempDir, err := paths.MkTempDir("", "arduino-lsp-")
+	if err != nil {
+		return err
+	}
+	s.tempDir = tempDir
+	defer s.tempDir.RemoveAll()
+
+	s.conn = newConn(in, out)
+	go s.runBuilds()
+	defer s.stopBuilds()
+
+	for {
+		req, err := s.conn.read()
+		if resErr, ok := err.(*responseError); ok {
+			s.conn.reply(nil, nil, resErr)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if req.Method == "exit" {
+			if !s.shuttingDown {
+				return errors.New("exit requested without shutdown")
+			}
+			return nil
+		}
+		if req.ID == nil {
+			s.handleNotification(req)
+			continue
+		}
+		if s.initialized && !s.shuttingDown && req.Method != "initialize" && req.Method != "shutdown" {
+			s.dispatch(req)
+			continue
+		}
+		// the requests changing the state of the server are handled in
+		// order, like the notifications
+		result, err := s.handleRequest(req)
+		if err := s.conn.reply(req.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch handles req in a goroutine of its own, so that a slow completion
+// doesn't hold back the messages that follow it
+func (s *Server) dispatch(req *request) {
+	key := string(*req.ID)
+	var once sync.Once
+	reply := func(result interface{}, err error) {
+		once.Do(func() {
+			s.inflightMux.Lock()
+			delete(s.inflight, key)
+			s.inflightMux.Unlock()
+			s.conn.reply(req.ID, result, err)
+		})
+	}
+	s.inflightMux.Lock()
+	s.inflight[key] = reply
+	s.inflightMux.Unlock()
+
+	s.handlers.Add(1)
+	go func() {
+		defer s.handlers.Done()
+		reply(s.handleFeature(req))
+	}()
+}
+
+// cancel replies to the request with the given ID as canceled, if it is
+// still being handled. The builder can't be stopped halfway, so the request
+// goes on, but its result is dropped.
+func (s *Server) cancel(id json.RawMessage) {
+	s.inflightMux.Lock()
+	reply, ok := s.inflight[string(id)]
+	s.inflightMux.Unlock()
+	if ok {
+		reply(nil, newError(codeRequestCancelled, "request canceled"))
+	}
+}
+
+func (s *Server) handleRequest(req *request) (interface{}, error) {
+	if req.Method == "initialize" {
+		params := &initializeParams{}
+		if err := unmarshalParams(req, params); err != nil {
+			return nil, err
+		}
+		s.initialized = true
+		return &initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync: textDocumentSyncOptions{
+					OpenClose: true,
+					Change:    textDocumentSyncFull,
+					Save:      saveOptions{IncludeText: false},
+				},
+				CompletionProvider: completionOptions{TriggerCharacters: []string{".", ">", ":"}},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "arduino-builder", Version: s.version},
+		}, nil
+	}
+	if !s.initialized {
+		return nil, newError(codeServerNotInitialized, "server not initialized")
+	}
+	if s.shuttingDown {
+		return nil, newError(codeInvalidRequest, "server is shutting down")
+	}
+
+	if req.Method == "shutdown" {
+		s.shuttingDown = true
+		s.stopBuilds()
+		s.handlers.Wait()
+		return nil, nil
+	}
+	return s.handleFeature(req)
+}
+
+// handleFeature handles the requests of the language features, which may
+// run concurrently
+func (s *Server) handleFeature(req *request) (interface{}, error) {
+	switch req.Method {
+	case "textDocument/completion":
+		params := &textDocumentPositionParams{}
+		if err := unmarshalParams(req, params); err != nil {
+			return nil, err
+		}
+		return s.completion(params)
+	case "textDocument/hover":
+		params := &textDocumentPositionParams{}
+		if err := unmarshalParams(req, params); err != nil {
+			return nil, err
+		}
+		return s.hover(params)
+	case "textDocument/definition":
+		params := &textDocumentPositionParams{}
+		if err := unmarshalParams(req, params); err != nil {
+			return nil, err
+		}
+		return s.definition(params)
+	}
+	return nil, newError(codeMethodNotFound, "method not supported: %s", req.Method)
+}
+
+// handleNotification handles a notification from the client. Notifications
+// get no reply, so invalid ones are dropped.
+func (s *Server) handleNotification(req *request) {
+	if !s.initialized || s.shuttingDown {
+		return
+	}
+
+	switch req.Method {
+	case "$/cancelRequest":
+		params := &cancelParams{}
+		if unmarshalParams(req, params) == nil {
+			s.cancel(params.ID)
+		}
+	case "textDocument/didOpen":
+		params := &didOpenTextDocumentParams{}
+		if unmarshalParams(req, params) == nil {
+			s.setDocument(params.TextDocument.URI, &params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		params := &didChangeTextDocumentParams{}
+		if unmarshalParams(req, params) == nil && len(params.ContentChanges) > 0 {
+			text := params.ContentChanges[len(params.ContentChanges)-1].Text
+			s.setDocument(params.TextDocument.URI, &text)
+		}
+	case "textDocument/didClose":
+		params := &didCloseTextDocumentParams{}
+		if unmarshalParams(req, params) == nil {
+			s.setDocument(params.TextDocument.URI, nil)
+		}
+	case "textDocument/didSave":
+		params := &didSaveTextDocumentParams{}
+		if unmarshalParams(req, params) != nil {
+			return
+		}
+		if params.Text != nil {
+			s.setDocument(params.TextDocument.URI, params.Text)
+		}
+		if path, ok := uriToPath(params.TextDocument.URI); ok {
+			if sketchFolder, err := findSketchFolder(path); err == nil {
+				s.requestBuild(sketchFolder)
+			}
+		}
+	}
+}
+
+func unmarshalParams(req *request, params interface{}) error {
+	if err := json.Unmarshal(req.Params, params); err != nil {
+		return newError(codeInvalidParams, "invalid params: %s", err)
+	}
+	return nil
+}
+
+// setDocument sets the text of the document with the given URI, or forgets
+// about it if text is nil
+func (s *Server) setDocument(uri string, text *string) {
+	path, ok := uriToPath(uri)
+	if !ok {
+		return
+	}
+	s.docsMux.Lock()
+	defer s.docsMux.Unlock()
+	if text == nil {
+		delete(s.documents, path)
+	} else {
+		s.documents[path] = *text
+	}
+}
+
+// readFile returns the text of the file at path, from the buffer of the
+// client if the file is open
+func (s *Server) readFile(path string) (string, error) {
+	s.docsMux.Lock()
+	text, ok := s.documents[path]
+	s.docsMux.Unlock()
+	if ok {
+		return text, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	return string(data), err
+}
+
+// fileLine returns the given line of the file at path, numbered from 0
+func (s *Server) fileLine(path string, line int) string {
+	text, err := s.readFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[line], "\r")
+}
+
+// overrides returns the text of the files of the sketch in sketchFolder
+// that are open in the client, keyed by their path relative to the sketch
+// folder as the builder wants them
+func (s *Server) overrides(sketchFolder string) map[string]string {
+	s.docsMux.Lock()
+	defer s.docsMux.Unlock()
+	res := map[string]string{}
+	for path, text := range s.documents {
+		if rel, err := filepath.Rel(sketchFolder, path); err == nil && !strings.HasPrefix(rel, "..") {
+			res[rel] = text
+		}
+	}
+	return res
+}
+
+// findSketchFolder returns the folder of the sketch the file at path belongs
+// to: the closest folder containing a .ino or .pde file
+func findSketchFolder(path string) (string, error) {
+	for folder := filepath.Dir(path); ; {
+		files, _ := ioutil.ReadDir(folder)
+		for _, file := range files {
+			ext := strings.ToLower(filepath.Ext(file.Name()))
+			if !file.IsDir() && (ext == ".ino" || ext == ".pde") {
+				return folder, nil
+			}
+		}
+		parent := filepath.Dir(folder)
+		if parent == folder {
+			return "", newError(codeInvalidParams, "%s is not part of a sketch", path)
+		}
+		folder = parent
+	}
+}
@@ -35,16 +35,22 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
 	"runtime/pprof"
 	"runtime/trace"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/arduino/arduino-builder/diagnostics"
 	"github.com/arduino/arduino-builder/grpc"
+	"github.com/arduino/arduino-builder/grpc/client"
+	"github.com/arduino/arduino-builder/lsp"
 	"github.com/arduino/arduino-cli/arduino/cores"
 	"github.com/arduino/arduino-cli/legacy/builder"
 	"github.com/arduino/arduino-cli/legacy/builder/i18n"
@@ -68,6 +74,18 @@ func (h *foldersFlag) Set(folder string) error {
 	return nil
 }
 
+// stringsFlag collects the values of a flag that can be added multiple times
+type stringsFlag []string
+
+func (h *stringsFlag) String() string {
+	return fmt.Sprint(*h)
+}
+
+func (h *stringsFlag) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
 type propertiesFlag []string
 
 func (h *propertiesFlag) String() string {
@@ -87,6 +105,8 @@ func main() {
 	var librariesBuiltInFoldersFlag foldersFlag
 	var librariesFoldersFlag foldersFlag
 	var customBuildPropertiesFlag propertiesFlag
+	var daemonPreloadFlag stringsFlag
+	var diagnosticsOutputFlag stringsFlag
 
 	preprocessFlag := flag.Bool("preprocess", false, "preprocess the given sketch")
 	dumpPrefsFlag := flag.Bool("dump-prefs", false, "dumps build properties used when compiling")
@@ -106,9 +126,33 @@ func main() {
 	quietFlag := flag.Bool("quiet", false, "if 'true' doesn't print any warnings or progress or whatever")
 	debugLevelFlag := flag.Int("debug-level", builder.DEFAULT_DEBUG_LEVEL, "Turns on debugging messages. The higher, the chattier")
 	warningsLevelFlag := flag.String("warnings", "", "Sets warnings level. Available values are 'none', 'default', 'more' and 'all'")
-	loggerFlag := flag.String("logger", "human", "Sets type of logger. Available values are 'human', 'humantags', 'machine'")
+	loggerFlag := flag.String("logger", "human", "Sets type of logger. Available values are 'human', 'humantags', 'machine', 'json'")
 	versionFlag := flag.Bool("version", false, "prints version and exits")
 	daemonFlag := flag.Bool("daemon", false, "daemonizes and serves its functions via rpc")
+	daemonTLSCertFlag := flag.String("daemon-tls-cert", "", "enables TLS for the daemon, using the certificate in the given PEM file")
+	daemonTLSKeyFlag := flag.String("daemon-tls-key", "", "private key of the certificate given with 'daemon-tls-cert'")
+	daemonTLSClientCAFlag := flag.String("daemon-tls-client-ca", "", "requires clients of the daemon to present a certificate signed by one of the CAs in the given PEM file")
+	daemonTokenFileFlag := flag.String("daemon-token-file", "", "requires clients of the daemon to send the token contained in the given file as a bearer token")
+	daemonIdleTimeoutFlag := flag.Duration("daemon-idle-timeout", 0, "shuts the daemon down once it has been idle for the given time, e.g. '30m'")
+	daemonParentPidFlag := flag.Int("daemon-parent-pid", 0, "shuts the daemon down once the process with the given pid exits")
+	daemonListenFlag := flag.String("daemon-listen", "localhost:12345", "address the daemon listens on. Available values are 'host:port' (port 0 picks a free port), 'unix:/path/to/socket' and 'stdio'")
+	daemonMaxBuildsFlag := flag.Int("daemon-max-builds", 0, "number of builds the daemon runs at the same time, the others are queued. Defaults to 2, 'jobs' compiler processes are shared among them")
+	flag.Var(&daemonPreloadFlag, "daemon-preload", "makes the daemon load the hardware, tools and libraries of the given build options file as soon as it starts, compiling the core of its board into 'build-cache' if given. Can be added multiple times")
+	daemonCacheEntriesFlag := flag.Int("daemon-cache-entries", 0, "number of hardware setups, and of libraries setups, the daemon keeps parsed in memory. Defaults to 8, the least recently used are dropped beyond it")
+	daemonCacheMemoryFlag := flag.Uint64("daemon-cache-memory", 0, "makes the daemon drop the least recently used hardware and libraries setups while its memory usage exceeds the given number of megabytes")
+	daemonJSONRPCListenFlag := flag.String("daemon-jsonrpc-listen", "", "also serves the daemon functions as JSON-RPC 2.0 over HTTP and WebSocket on the given address, either 'host:port' or 'unix:/path/to/socket'")
+	daemonJSONRPCOriginsFlag := flag.String("daemon-jsonrpc-origins", "", "comma separated list of the origins of the web pages allowed to call the JSON-RPC endpoint, '*' allows any page")
+	daemonRemoteBuildsFlag := flag.Bool("daemon-remote-builds", false, "lets clients of the daemon upload sketches to build against the given 'hardware', 'tools' and 'libraries' folders, and download the artifacts")
+	daemonRemoteBuildsFolderFlag := flag.String("daemon-remote-builds-folder", "", "folder holding the sketches and the build outputs of remote builds. Defaults to a temporary folder deleted when the daemon exits")
+	daemonMetricsListenFlag := flag.String("daemon-metrics-listen", "", "serves the Prometheus metrics of the daemon at /metrics and its pprof profiles at /debug/pprof/ on the given address, either 'host:port' or 'unix:/path/to/socket'")
+	connectFlag := flag.String("connect", "", "runs the build, or the 'preprocess', 'dump-prefs' and 'code-complete-at' actions, on the daemon listening on the given address, either 'host:port' or 'unix:/path/to/socket', forwarding the other flags. The token in 'daemon-token-file' is sent, if given")
+	connectTLSFlag := flag.Bool("connect-tls", false, "connects to the daemon over TLS, implied by the other 'connect-tls' flags")
+	connectTLSCAFlag := flag.String("connect-tls-ca", "", "checks the certificate of the daemon against the CAs in the given PEM file instead of the system ones")
+	connectTLSCertFlag := flag.String("connect-tls-cert", "", "presents the certificate in the given PEM file to the daemon")
+	connectTLSKeyFlag := flag.String("connect-tls-key", "", "private key of the certificate given with 'connect-tls-cert'")
+	resultJSONFlag := flag.String("result-json", "", "writes a JSON summary of the build to the given file: artifacts with their SHA-256, sizes, libraries, platform and tools versions, warnings count, duration and exit status")
+	flag.Var(&diagnosticsOutputFlag, "diagnostics-output", "writes the errors and warnings of the compiler, mapped to the sketch files and tagged with the sketch, library or core they come from, to the given file: as SARIF 2.1.0 if its name ends with '.sarif', as JSON otherwise. Can be added multiple times")
+	lspFlag := flag.Bool("lsp", false, "speaks the Language Server Protocol on stdin and stdout, providing completion, hover, go-to-definition and diagnostics for the sketches built with the given options")
 	vidPidFlag := flag.String("vid-pid", "", "specify to use vid/pid specific build properties, as defined in boards.txt")
 	jobsFlag := flag.Int("jobs", 0, "specify how many concurrent gcc processes should run at the same time. Defaults to the number of available cores on the running machine")
 	traceFlag := flag.Bool("trace", false, "traces the whole process lifecycle")
@@ -166,17 +210,127 @@ func main() {
 
 	if *daemonFlag {
 		ctx.SetLogger(i18n.NoopLogger{})
-		grpc.RegisterAndServeJsonRPC(ctx)
+		daemonOptions := grpc.Options{
+			Version:          VERSION,
+			Loggers:          []string{"human", "humantags", "machine", "json"},
+			TLSCertFile:      *daemonTLSCertFlag,
+			TLSKeyFile:       *daemonTLSKeyFlag,
+			TLSClientCAFile:  *daemonTLSClientCAFlag,
+			TokenFile:        *daemonTokenFileFlag,
+			IdleTimeout:      *daemonIdleTimeoutFlag,
+			ParentPID:        *daemonParentPidFlag,
+			MaxBuilds:        *daemonMaxBuildsFlag,
+			CompilerJobs:     *jobsFlag,
+			CacheEntries:     *daemonCacheEntriesFlag,
+			CacheMemoryLimit: *daemonCacheMemoryFlag << 20,
+		}
+		if *daemonJSONRPCOriginsFlag != "" {
+			daemonOptions.JSONRPCOrigins = strings.Split(*daemonJSONRPCOriginsFlag, ",")
+		}
+		if *daemonRemoteBuildsFlag {
+			remote := &grpc.RemoteBuildOptions{
+				ArduinoAPIVersion: *coreAPIVersionFlag,
+				Folder:            *daemonRemoteBuildsFolderFlag,
+			}
+			var err error
+			if remote.HardwareFolders, err = toSliceOfUnquoted(hardwareFoldersFlag); err != nil {
+				printCompleteError(err)
+			}
+			if remote.ToolsFolders, err = toSliceOfUnquoted(toolsFoldersFlag); err != nil {
+				printCompleteError(err)
+			}
+			if remote.OtherLibrariesFolders, err = toSliceOfUnquoted(librariesFoldersFlag); err != nil {
+				printCompleteError(err)
+			}
+			if remote.BuiltInLibrariesFolders, err = toSliceOfUnquoted(librariesBuiltInFoldersFlag); err != nil {
+				printCompleteError(err)
+			}
+			if len(remote.HardwareFolders) == 0 || len(remote.ToolsFolders) == 0 {
+				printErrorMessageAndFlagUsage(errors.New("Parameters 'hardware' and 'tools' are mandatory for remote builds"))
+			}
+			daemonOptions.RemoteBuilds = remote
+		}
+		daemon, err := grpc.NewDaemon(ctx, daemonOptions)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		lis, err := grpc.Listen(*daemonListenFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		// stdout is the transport itself when serving on stdio
+		if *daemonListenFlag != "stdio" {
+			fmt.Println("Daemon listening on " + lis.Addr().String())
+		}
+		if len(daemonPreloadFlag) > 0 {
+			buildCachePath, err := unquote(*buildCachePathFlag)
+			if err != nil {
+				printCompleteError(err)
+			}
+			if buildCachePath != "" {
+				if err := paths.New(buildCachePath).MkdirAll(); err != nil {
+					printCompleteError(err)
+				}
+			}
+			go func() {
+				for _, file := range daemonPreloadFlag {
+					params, err := preloadParams(file, buildCachePath)
+					if err == nil {
+						err = daemon.Warmup(params)
+					}
+					if err != nil {
+						fmt.Fprintln(os.Stderr, "preloading "+file+": "+err.Error())
+					}
+				}
+			}()
+		}
+		// serveHTTP serves one of the HTTP endpoints of the daemon in the
+		// background
+		serveHTTP := func(name, address string, serve func(net.Listener) error) {
+			if address == "stdio" {
+				fmt.Fprintln(os.Stderr, "the "+name+" endpoint can't be served on stdio")
+				os.Exit(1)
+			}
+			lis, err := grpc.Listen(address)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if *daemonListenFlag != "stdio" {
+				fmt.Println(name + " listening on " + lis.Addr().String())
+			}
+			go func() {
+				if err := serve(lis); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			}()
+		}
+		if *daemonJSONRPCListenFlag != "" {
+			serveHTTP("JSON-RPC", *daemonJSONRPCListenFlag, daemon.ServeJSONRPC)
+		}
+		if *daemonMetricsListenFlag != "" {
+			serveHTTP("Metrics", *daemonMetricsListenFlag, daemon.ServeMetrics)
+		}
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+		go func() {
+			<-signals
+			daemon.Shutdown()
+		}()
+		if err := daemon.Serve(lis); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	if *buildOptionsFileFlag != "" {
 		buildOptions := properties.NewMap()
 		if _, err := os.Stat(*buildOptionsFileFlag); err == nil {
-			data, err := ioutil.ReadFile(*buildOptionsFileFlag)
-			if err != nil {
-				printCompleteError(err)
-			}
-			err = json.Unmarshal(data, &buildOptions)
+			buildOptions, err = readBuildOptions(*buildOptionsFileFlag)
 			if err != nil {
 				printCompleteError(err)
 			}
@@ -319,12 +473,68 @@ func main() {
 	} else if *loggerFlag == "machine" {
 		ctx.SetLogger(i18n.MachineLogger{})
 		ctx.Progress.PrintEnabled = true
+	} else if *loggerFlag == "json" {
+		ctx.SetLogger(newJSONLogger(os.Stdout, ctx))
+		ctx.Progress.PrintEnabled = true
 	} else if *loggerFlag == "humantags" {
 		ctx.SetLogger(i18n.HumanTagsLogger{})
 	} else {
 		ctx.SetLogger(i18n.HumanLogger{})
 	}
 
+	if *lspFlag {
+		// stdout is the transport of the protocol
+		ctx.SetLogger(i18n.NoopLogger{})
+		if err := lsp.NewServer(ctx, VERSION).Serve(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *connectFlag != "" {
+		opts := client.Options{
+			TLS:         *connectTLSFlag,
+			TLSCAFile:   *connectTLSCAFlag,
+			TLSCertFile: *connectTLSCertFlag,
+			TLSKeyFile:  *connectTLSKeyFlag,
+		}
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "debug-level":
+				fmt.Fprintln(os.Stderr, "-debug-level can't be used with -connect, set it when starting the daemon")
+				os.Exit(1)
+			case "result-json":
+				fmt.Fprintln(os.Stderr, "-result-json can't be used with -connect, only local builds are summarized")
+				os.Exit(1)
+			case "diagnostics-output":
+				fmt.Fprintln(os.Stderr, "-diagnostics-output can't be used with -connect, only the diagnostics of local builds are written")
+				os.Exit(1)
+			}
+		})
+		if *daemonTokenFileFlag != "" {
+			token, err := grpc.ReadToken(*daemonTokenFileFlag)
+			if err != nil {
+				printCompleteError(err)
+			}
+			opts.Token = token
+		}
+		action := daemonBuild
+		if *dumpPrefsFlag {
+			action = daemonDumpPrefs
+		} else if *codeCompleteAtFlag != "" {
+			ctx.CodeCompleteAt = *codeCompleteAtFlag
+			action = daemonCodeComplete
+		} else if *preprocessFlag {
+			action = daemonPreprocess
+		} else if flag.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Last parameter must be the sketch to compile")
+			flag.Usage()
+			os.Exit(1)
+		}
+		os.Exit(runOnDaemon(*connectFlag, opts, ctx, action, *loggerFlag == "json" && !*quietFlag))
+	}
+
 	var err error
 	if *dumpPrefsFlag {
 		err = builder.RunParseHardwareAndDumpBuildProperties(ctx)
@@ -337,7 +547,24 @@ func main() {
 			flag.Usage()
 			os.Exit(1)
 		}
+		var collector *diagnostics.Collector
+		if *resultJSONFlag != "" || len(diagnosticsOutputFlag) > 0 {
+			collector = diagnostics.NewCollector(ctx.GetLogger())
+			ctx.SetLogger(collector)
+		}
+		startedAt := time.Now()
 		err = builder.RunBuilder(ctx)
+		if *resultJSONFlag != "" {
+			summary := newBuildSummary(ctx, err, startedAt, countWarnings(collector.Diagnostics()))
+			if err := writeBuildSummary(*resultJSONFlag, summary); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+		if len(diagnosticsOutputFlag) > 0 {
+			if err := writeDiagnostics(ctx, collector.Diagnostics(), diagnosticsOutputFlag); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
 	}
 
 	if err != nil {
@@ -360,6 +587,20 @@ func toExitCode(err error) int {
 	return 1
 }
 
+// readBuildOptions reads a build options file, like the build.options.json
+// written by the builder in the build path
+func readBuildOptions(file string) (*properties.Map, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	buildOptions := properties.NewMap()
+	if err := json.Unmarshal(data, &buildOptions); err != nil {
+		return nil, err
+	}
+	return buildOptions, nil
+}
+
 func toSliceOfUnquoted(value []string) ([]string, error) {
 	var values []string
 	for _, v := range value {
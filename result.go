@@ -0,0 +1,232 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/arduino/arduino-builder/diagnostics"
+	"github.com/arduino/arduino-builder/events"
+	"github.com/arduino/arduino-cli/arduino/cores"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	paths "github.com/arduino/go-paths-helper"
+)
+
+// buildSummary is the summary of a build written with -result-json
+type buildSummary struct {
+	Success   bool   `json:"success"`
+	ExitCode  int    `json:"exitCode"`
+	Error     string `json:"error,omitempty"`
+	FQBN      string `json:"fqbn"`
+	Sketch    string `json:"sketch"`
+	BuildPath string `json:"buildPath"`
+	// Platform is the platform of the board, CorePlatform the one of its
+	// core when the board uses the core of another platform
+	Platform     *summaryPlatform   `json:"platform,omitempty"`
+	CorePlatform *summaryPlatform   `json:"corePlatform,omitempty"`
+	Core         string             `json:"core,omitempty"`
+	Tools        []*summaryTool     `json:"tools"`
+	Libraries    []*summaryLibrary  `json:"libraries"`
+	Artifacts    []*summaryArtifact `json:"artifacts"`
+	Sizes        []*summarySize     `json:"sizes"`
+	Warnings     int                `json:"warnings"`
+	StartedAt    time.Time          `json:"startedAt"`
+	DurationMs   int64              `json:"durationMs"`
+}
+
+type summaryPlatform struct {
+	Package      string `json:"package"`
+	Architecture string `json:"architecture"`
+	Version      string `json:"version"`
+	Path         string `json:"path"`
+}
+
+type summaryTool struct {
+	Package string `json:"package,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+}
+
+type summaryLibrary struct {
+	Name     string `json:"name"`
+	Version  string `json:"version,omitempty"`
+	Path     string `json:"path"`
+	Location string `json:"location"`
+}
+
+type summaryArtifact struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type summarySize struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	// 0 if the board doesn't define a maximum
+	MaxSize int `json:"maxSize"`
+}
+
+// newBuildSummary summarizes the build in ctx, which started at the given
+// time and ended with err
+func newBuildSummary(ctx *types.Context, err error, startedAt time.Time, warnings int) *buildSummary {
+	summary := &buildSummary{
+		Success:    err == nil,
+		Tools:      []*summaryTool{},
+		Libraries:  []*summaryLibrary{},
+		Artifacts:  []*summaryArtifact{},
+		Sizes:      []*summarySize{},
+		Warnings:   warnings,
+		StartedAt:  startedAt,
+		DurationMs: int64(time.Since(startedAt) / time.Millisecond),
+	}
+	if err != nil {
+		summary.ExitCode = toExitCode(err)
+		summary.Error = err.Error()
+	}
+	if ctx.FQBN != nil {
+		summary.FQBN = ctx.FQBN.String()
+	}
+	if ctx.SketchLocation != nil {
+		summary.Sketch = ctx.SketchLocation.String()
+	}
+	if ctx.BuildPath != nil {
+		summary.BuildPath = ctx.BuildPath.String()
+	}
+
+	summary.Platform = newSummaryPlatform(ctx.TargetPlatform)
+	if ctx.ActualPlatform != ctx.TargetPlatform {
+		summary.CorePlatform = newSummaryPlatform(ctx.ActualPlatform)
+	}
+	if ctx.BuildProperties != nil {
+		summary.Core = ctx.BuildProperties.Get("build.core")
+	}
+	for _, tool := range ctx.RequiredTools {
+		t := &summaryTool{Name: tool.Tool.Name}
+		if tool.Tool.Package != nil {
+			t.Package = tool.Tool.Package.Name
+		}
+		if tool.Version != nil {
+			t.Version = tool.Version.String()
+		}
+		if tool.InstallDir != nil {
+			t.Path = tool.InstallDir.String()
+		}
+		summary.Tools = append(summary.Tools, t)
+	}
+	for _, lib := range ctx.ImportedLibraries {
+		l := &summaryLibrary{Name: lib.Name, Location: lib.Location.String()}
+		if lib.Version != nil {
+			l.Version = lib.Version.String()
+		}
+		if lib.InstallDir != nil {
+			l.Path = lib.InstallDir.String()
+		}
+		summary.Libraries = append(summary.Libraries, l)
+	}
+
+	summary.Artifacts = buildArtifacts(ctx)
+	for _, section := range ctx.ExecutableSectionsSize {
+		summary.Sizes = append(summary.Sizes, &summarySize{Name: section.Name, Size: section.Size, MaxSize: section.MaxSize})
+	}
+	return summary
+}
+
+func newSummaryPlatform(platform *cores.PlatformRelease) *summaryPlatform {
+	if platform == nil || platform.Platform == nil {
+		return nil
+	}
+	res := &summaryPlatform{Architecture: platform.Platform.Architecture}
+	if platform.Platform.Package != nil {
+		res.Package = platform.Platform.Package.Name
+	}
+	if platform.Version != nil {
+		res.Version = platform.Version.String()
+	}
+	if platform.InstallDir != nil {
+		res.Path = platform.InstallDir.String()
+	}
+	return res
+}
+
+// buildArtifacts returns the files produced by the build in ctx, with
+// their checksum
+func buildArtifacts(ctx *types.Context) []*summaryArtifact {
+	res := []*summaryArtifact{}
+	for _, artifact := range events.Artifacts(ctx) {
+		file := paths.New(artifact.Path)
+		sum, err := fileSHA256(file)
+		if err != nil {
+			continue
+		}
+		res = append(res, &summaryArtifact{Name: file.Base(), Path: artifact.Path, Size: artifact.Size, SHA256: sum})
+	}
+	return res
+}
+
+func fileSHA256(file *paths.Path) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeBuildSummary(file string, summary *buildSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// countWarnings returns the number of warnings among the diagnostics of
+// the compiler, not counting the notes attached to them
+func countWarnings(list []*diagnostics.Diagnostic) int {
+	n := 0
+	for _, d := range list {
+		if d.Severity == diagnostics.SeverityWarning {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,143 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arduino/arduino-builder/diagnostics"
+	"github.com/arduino/arduino-cli/arduino/cores"
+	"github.com/arduino/arduino-cli/legacy/builder/types"
+	"github.com/arduino/go-paths-helper"
+	properties "github.com/arduino/go-properties-orderedmap"
+)
+
+// compilerOutput has two warnings, one of them with a note, and an error
+const compilerOutput = `/tmp/build/sketch/Blink.ino.cpp: In function 'void setup()':
+/tmp/build/sketch/Blink.ino.cpp:4:7: warning: unused variable 'x' [-Wunused-variable]
+/tmp/build/sketch/Blink.ino.cpp:5:7: warning: unused variable 'y' [-Wunused-variable]
+/tmp/build/sketch/Blink.ino.cpp:5:7: note: declared here
+/tmp/build/sketch/Blink.ino.cpp:6:3: error: 'foo' was not declared in this scope
+`
+
+func sha256Of(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewBuildSummary(t *testing.T) {
+	buildPath, err := paths.MkTempDir("", "arduino-builder-summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buildPath.RemoveAll()
+	files := map[string]string{
+		"Blink.ino.hex":      ":00000001FF\n",
+		"Blink.ino.elf":      "\x7fELF",
+		"build.options.json": "{}",
+	}
+	for name, contents := range files {
+		if err := buildPath.Join(name).WriteFile([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// folders named like the artifacts are not artifacts
+	if err := buildPath.Join("Blink.ino.d").MkdirAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	fqbn, err := cores.ParseFQBN("arduino:avr:uno")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &types.Context{}
+	ctx.FQBN = fqbn
+	ctx.SketchLocation = paths.New("/work/Blink")
+	ctx.BuildPath = buildPath
+	ctx.BuildProperties = properties.NewMap()
+	ctx.BuildProperties.Set("build.project_name", "Blink.ino")
+	ctx.BuildProperties.Set("build.core", "arduino")
+	ctx.ExecutableSectionsSize = []types.ExecutableSectionSize{
+		{Name: "text", Size: 924, MaxSize: 32256},
+		{Name: "data", Size: 9, MaxSize: 2048},
+	}
+	startedAt := time.Now().Add(-2 * time.Second)
+
+	summary := newBuildSummary(ctx, nil, startedAt, countWarnings(diagnostics.Parse(compilerOutput)))
+	if !summary.Success || summary.ExitCode != 0 || summary.Error != "" {
+		t.Errorf("got success %v, exit code %d and error %q, want a successful build", summary.Success, summary.ExitCode, summary.Error)
+	}
+	if summary.FQBN != "arduino:avr:uno" || summary.Sketch != "/work/Blink" || summary.BuildPath != buildPath.String() || summary.Core != "arduino" {
+		t.Errorf("got fqbn %s, sketch %s, build path %s and core %s", summary.FQBN, summary.Sketch, summary.BuildPath, summary.Core)
+	}
+	if summary.Warnings != 2 {
+		t.Errorf("got %d warnings, want 2", summary.Warnings)
+	}
+	if summary.DurationMs < 2000 {
+		t.Errorf("got a duration of %dms, want at least 2000ms", summary.DurationMs)
+	}
+
+	artifacts := map[string]*summaryArtifact{}
+	for _, artifact := range summary.Artifacts {
+		artifacts[artifact.Name] = artifact
+	}
+	if len(artifacts) != 2 {
+		t.Errorf("got artifacts %v, want Blink.ino.hex and Blink.ino.elf", artifacts)
+	}
+	for _, name := range []string{"Blink.ino.hex", "Blink.ino.elf"} {
+		artifact := artifacts[name]
+		if artifact == nil {
+			t.Errorf("%s is missing from the artifacts", name)
+			continue
+		}
+		if artifact.Path != buildPath.Join(name).String() || artifact.Size != int64(len(files[name])) || artifact.SHA256 != sha256Of(files[name]) {
+			t.Errorf("got artifact %+v, want %s with size %d and sha256 %s", artifact, name, len(files[name]), sha256Of(files[name]))
+		}
+	}
+
+	if len(summary.Sizes) != 2 || *summary.Sizes[0] != (summarySize{Name: "text", Size: 924, MaxSize: 32256}) || *summary.Sizes[1] != (summarySize{Name: "data", Size: 9, MaxSize: 2048}) {
+		t.Errorf("got sizes %v, want the sections of the build", summary.Sizes)
+	}
+}
+
+func TestNewBuildSummaryFailure(t *testing.T) {
+	summary := newBuildSummary(&types.Context{}, errors.New("compilation failed"), time.Now(), 0)
+	if summary.Success || summary.ExitCode != 1 || summary.Error != "compilation failed" {
+		t.Errorf("got success %v, exit code %d and error %q, want a failure with exit code 1", summary.Success, summary.ExitCode, summary.Error)
+	}
+	// the lists are written as [] rather than null
+	if summary.Artifacts == nil || summary.Sizes == nil || summary.Tools == nil || summary.Libraries == nil {
+		t.Errorf("got nil lists in %+v", summary)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+// Package sourcemap maps the lines of the sources generated by the builder
+// back to the lines of the sketch files they come from, following the #line
+// directives the builder adds while merging and preprocessing the sketch.
+package sourcemap
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var lineDirective = regexp.MustCompile(`^\s*#\s*line\s+(\d+)(?:\s+("(?:[^"\\]|\\.)*"))?`)
+
+// Mapping states that Lines lines of the generated source, starting from
+// GeneratedLine, come from File, starting from OriginalLine. Lines are
+// numbered from 1.
+type Mapping struct {
+	GeneratedLine int
+	Lines         int
+	File          string
+	OriginalLine  int
+}
+
+// Map lists the mappings of a generated source, sorted by GeneratedLine
+type Map []*Mapping
+
+// Parse builds the Map of source out of its #line directives. Lines before
+// the first directive, and the directives themselves, are not mapped.
+func Parse(source string) Map {
+	var res Map
+	var current *Mapping
+	file := ""
+	for i, line := range strings.Split(strings.TrimSuffix(source, "\n"), "\n") {
+		match := lineDirective.FindStringSubmatch(line)
+		if match == nil {
+			if current != nil {
+				current.Lines++
+			}
+			continue
+		}
+		if match[2] != "" {
+			if unquoted, err := strconv.Unquote(match[2]); err == nil {
+				file = unquoted
+			}
+		}
+		originalLine, _ := strconv.Atoi(match[1])
+		current = &Mapping{GeneratedLine: i + 2, File: file, OriginalLine: originalLine}
+		res = append(res, current)
+	}
+	return res
+}
+
+// ToOriginal returns the file and line the given generated line comes from.
+// ok is false if the line is not mapped.
+func (m Map) ToOriginal(line int) (file string, originalLine int, ok bool) {
+	for _, mapping := range m {
+		if line >= mapping.GeneratedLine && line < mapping.GeneratedLine+mapping.Lines {
+			return mapping.File, mapping.OriginalLine + line - mapping.GeneratedLine, true
+		}
+	}
+	return "", 0, false
+}
+
+// ToGenerated returns the generated line coming from the given line of file.
+// ok is false if the line doesn't end up in the generated source. The
+// prototypes added by the builder are mapped to the lines of their
+// functions too: they come before the functions, so they are skipped by
+// looking for the last matching mapping.
+func (m Map) ToGenerated(file string, line int) (generatedLine int, ok bool) {
+	for i := len(m) - 1; i >= 0; i-- {
+		mapping := m[i]
+		if mapping.File == file && line >= mapping.OriginalLine && line < mapping.OriginalLine+mapping.Lines {
+			return mapping.GeneratedLine + line - mapping.OriginalLine, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,109 @@
+/*
+ * This file is part of Arduino Builder.
+ *
+ * Arduino Builder is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA  02110-1301  USA
+ *
+ * As a special exception, you may use this file as part of a free software
+ * library without restriction.  Specifically, if other files instantiate
+ * templates or use macros or inline functions from this file, or you compile
+ * this file and link it with other files to produce an executable, this
+ * file does not by itself cause the resulting executable to be covered by
+ * the GNU General Public License.  This exception does not however
+ * invalidate any other reasons why the executable file might be covered by
+ * the GNU General Public License.
+ *
+ * Copyright 2020 Arduino LLC (http://www.arduino.cc/)
+ */
+
+package sourcemap
+
+import (
+	"reflect"
+	"testing"
+)
+
+// source is what the builder generates out of a sketch with two tabs, with
+// the prototypes it adds before the first function
+const source = `#include <Arduino.h>
+#line 1 "/work/Blink/Blink.ino"
+#line 1 "/work/Blink/Blink.ino"
+void setup();
+#line 5 "/work/Blink/Blink.ino"
+void loop();
+#line 1 "/work/Blink/Blink.ino"
+void setup() {
+  pinMode(13, OUTPUT);
+}
+
+void loop() {}
+#line 1 "/work/Blink/tab with \"quotes\".ino"
+int x;
+#line 10
+int y;
+`
+
+func TestParse(t *testing.T) {
+	want := Map{
+		{GeneratedLine: 3, Lines: 0, File: "/work/Blink/Blink.ino", OriginalLine: 1},
+		{GeneratedLine: 4, Lines: 1, File: "/work/Blink/Blink.ino", OriginalLine: 1},
+		{GeneratedLine: 6, Lines: 1, File: "/work/Blink/Blink.ino", OriginalLine: 5},
+		{GeneratedLine: 8, Lines: 5, File: "/work/Blink/Blink.ino", OriginalLine: 1},
+		{GeneratedLine: 14, Lines: 1, File: "/work/Blink/tab with \"quotes\".ino", OriginalLine: 1},
+		// the file carries over to the directives without one
+		{GeneratedLine: 16, Lines: 1, File: "/work/Blink/tab with \"quotes\".ino", OriginalLine: 10},
+	}
+	got := Parse(source)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() =")
+		for _, mapping := range got {
+			t.Errorf("  %+v", *mapping)
+		}
+	}
+}
+
+func TestToOriginal(t *testing.T) {
+	m := Parse(source)
+	tests := []struct {
+		line int
+		file string
+		want int
+		ok   bool
+	}{
+		{1, "", 0, false},
+		{4, "/work/Blink/Blink.ino", 1, true},
+		{9, "/work/Blink/Blink.ino", 2, true},
+		{12, "/work/Blink/Blink.ino", 5, true},
+		{14, "/work/Blink/tab with \"quotes\".ino", 1, true},
+		{16, "/work/Blink/tab with \"quotes\".ino", 10, true},
+		{17, "", 0, false},
+	}
+	for _, test := range tests {
+		file, line, ok := m.ToOriginal(test.line)
+		if file != test.file || line != test.want || ok != test.ok {
+			t.Errorf("ToOriginal(%d) = %s, %d, %v, want %s, %d, %v", test.line, file, line, ok, test.file, test.want, test.ok)
+		}
+	}
+}
+
+func TestToGenerated(t *testing.T) {
+	m := Parse(source)
+	// the prototype of loop is skipped for the function itself
+	if line, ok := m.ToGenerated("/work/Blink/Blink.ino", 5); !ok || line != 12 {
+		t.Errorf("ToGenerated(Blink.ino, 5) = %d, %v, want 12, true", line, ok)
+	}
+	if _, ok := m.ToGenerated("/work/Blink/other.ino", 1); ok {
+		t.Error("ToGenerated() mapped a file that is not in the source")
+	}
+}